@@ -1,13 +1,48 @@
 package bots
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
 	"gopkg.in/tucnak/telebot.v2"
 	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
+// requestTimeout bounds the service calls made for a single bot command
+const requestTimeout = 10 * time.Second
+
 type TelegramBot struct {
-	bot *telebot.Bot
+	bot                 *telebot.Bot
+	userService         service.UserService
+	goalService         service.GoalService
+	viewService         service.ViewService
+	gamificationService service.GamificationService
+	friendService       service.FriendService
+
+	running atomic.Bool // running is set once the bot is started, announcements wait for it
+}
+
+func NewTelegramBot(
+	userService service.UserService,
+	goalService service.GoalService,
+	viewService service.ViewService,
+	gamificationService service.GamificationService,
+	friendService service.FriendService,
+) *TelegramBot {
+	return &TelegramBot{
+		userService:         userService,
+		goalService:         goalService,
+		viewService:         viewService,
+		gamificationService: gamificationService,
+		friendService:       friendService,
+	}
 }
 
 func (tb *TelegramBot) Initialize(botToken, webAppURL string) error {
@@ -37,11 +72,365 @@ func (tb *TelegramBot) Initialize(botToken, webAppURL string) error {
 		if err != nil {
 			log.Printf("Failed to send message: %v", err)
 		}
+
+		// deep links to friend invites open the bot with /start invite_<code>
+		if code, ok := model.ParseStartParameter(m.Payload); ok {
+			tb.acceptInvite(m, code)
+		}
 	})
 
+	tb.bot.Handle("/goals", tb.handleGoals)
+	tb.bot.Handle("/view", tb.handleView)
+	tb.bot.Handle("/profile", tb.handleProfile)
+	tb.bot.Handle("/invite", tb.handleInvite)
+	tb.bot.Handle("/leaderboard", tb.handleLeaderboard)
+
 	return nil
 }
 
 func (tb *TelegramBot) Start() {
+	tb.running.Store(true)
 	go tb.bot.Start()
 }
+
+// handleGoals lists the active goals of the sender, "/goals archived" lists the archived ones
+func (tb *TelegramBot) handleGoals(m *telebot.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	filter := model.GoalFilter{Archived: model.ArchiveExclude}
+	empty := "You have no active goals."
+	if strings.TrimSpace(m.Payload) == "archived" {
+		filter.Archived = model.ArchiveOnly
+		empty = "You have no archived goals."
+	}
+
+	goals, err := tb.goalService.List(ctx, user.ID, filter)
+	if err != nil {
+		log.Printf("Failed to list goals: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return
+	}
+
+	if len(goals) == 0 {
+		tb.reply(m, empty)
+		return
+	}
+
+	tb.reply(m, formatGoals(goals))
+}
+
+// handleView lists the goals matching a saved view, "/view" alone lists the saved views
+func (tb *TelegramBot) handleView(m *telebot.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	name := strings.TrimSpace(m.Payload)
+	if name == "" {
+		views, err := tb.viewService.List(ctx, user.ID)
+		if err != nil {
+			log.Printf("Failed to list views: %v", err)
+			tb.reply(m, "Something went wrong, please try again later.")
+			return
+		}
+
+		if len(views) == 0 {
+			tb.reply(m, "You have no saved views, create one in the web app.")
+			return
+		}
+
+		var b strings.Builder
+		b.WriteString("Your views:\n")
+		for _, view := range views {
+			fmt.Fprintf(&b, "/view %s (%s)\n", view.Name, view.Filter)
+		}
+
+		tb.reply(m, b.String())
+		return
+	}
+
+	view, goals, err := tb.viewService.GoalsByName(ctx, user.ID, name)
+	if err != nil {
+		if errors.Is(err, storage.ErrViewNotFound) {
+			tb.reply(m, fmt.Sprintf("You have no view named %q, send /view to list them.", name))
+			return
+		}
+
+		log.Printf("Failed to list view goals: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return
+	}
+
+	if len(goals) == 0 {
+		tb.reply(m, fmt.Sprintf("No goals match %s.", view.Name))
+		return
+	}
+
+	tb.reply(m, formatGoals(goals))
+}
+
+// handleProfile shows the level, XP, streak and achievements of the sender
+func (tb *TelegramBot) handleProfile(m *telebot.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	profile, err := tb.gamificationService.Profile(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to get profile: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Level %d, %d XP (%d XP to level %d)\n", profile.Level.Level, profile.XP, profile.NextLevelXP-profile.XP, profile.Level.Level+1)
+	fmt.Fprintf(&b, "Streak: %d days\n", profile.Streak)
+
+	if len(profile.Achievements) == 0 {
+		b.WriteString("No achievements yet, complete a chapter to earn your first one.")
+	} else {
+		b.WriteString("\nAchievements:\n")
+		for _, achievement := range profile.Achievements {
+			fmt.Fprintf(&b, "%s %s: %s\n", achievement.Emoji, achievement.Name, achievement.Description)
+		}
+	}
+
+	tb.reply(m, b.String())
+}
+
+// handleInvite replies with a deep link that befriends whoever opens it with the sender
+func (tb *TelegramBot) handleInvite(m *telebot.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	invite, err := tb.friendService.CreateInvite(ctx, user.ID)
+	if err != nil {
+		log.Printf("Failed to create invite: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return
+	}
+
+	tb.reply(m, fmt.Sprintf("Send this link to a friend, it works once and expires on %s:\n%s",
+		invite.ExpiresAt.Format("2 Jan 2006"), invite.DeepLink(tb.bot.Me.Username)))
+}
+
+// acceptInvite befriends the sender with the inviter and lets the inviter know
+func (tb *TelegramBot) acceptInvite(m *telebot.Message, code string) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	friend, err := tb.friendService.AcceptInvite(ctx, user.ID, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, storage.ErrInviteNotFound):
+			tb.reply(m, "This invite has expired or was used already, ask your friend for a new one.")
+		case errors.Is(err, storage.ErrAlreadyFriends):
+			tb.reply(m, "You are friends already.")
+		case errors.Is(err, service.ErrSelfInvite):
+			tb.reply(m, "Send the invite to a friend, it cannot be used by yourself.")
+		default:
+			log.Printf("Failed to accept invite: %v", err)
+			tb.reply(m, "Something went wrong, please try again later.")
+		}
+		return
+	}
+
+	tb.reply(m, fmt.Sprintf("You and %s are friends now, see how you compare with /leaderboard.", friend.FirstName))
+
+	inviter, err := tb.userService.Get(ctx, friend.ID)
+	if err != nil {
+		log.Printf("Failed to get inviter: %v", err)
+		return
+	}
+
+	text := fmt.Sprintf("%s accepted your invite, you are friends now.", user.FirstName)
+	if _, err := tb.bot.Send(&telebot.User{ID: inviter.TelegramID}, text); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
+}
+
+// handleLeaderboard ranks the sender and their friends, "/leaderboard month chapters" changes the period and ranking
+func (tb *TelegramBot) handleLeaderboard(m *telebot.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), requestTimeout)
+	defer cancel()
+
+	user, ok := tb.currentUser(ctx, m)
+	if !ok {
+		return
+	}
+
+	var period, metric string
+	for _, arg := range strings.Fields(strings.ToLower(m.Payload)) {
+		switch arg {
+		case model.LeaderboardWeek, model.LeaderboardMonth:
+			period = arg
+		case model.LeaderboardByXP, model.LeaderboardByChapters:
+			metric = arg
+		default:
+			tb.reply(m, "Use /leaderboard [week|month] [xp|chapters].")
+			return
+		}
+	}
+
+	leaderboard, err := tb.friendService.Leaderboard(ctx, user.ID, period, metric)
+	if err != nil {
+		log.Printf("Failed to get leaderboard: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return
+	}
+
+	if len(leaderboard.Entries) < 2 {
+		tb.reply(m, "Invite friends with /invite to compete with them.")
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Leaderboard of this %s by %s:\n", leaderboard.Period, leaderboard.Metric)
+	for _, entry := range leaderboard.Entries {
+		score := fmt.Sprintf("%d XP", entry.XP)
+		if leaderboard.Metric == model.LeaderboardByChapters {
+			score = fmt.Sprintf("%d chapters", entry.Chapters)
+		}
+
+		name := entry.FirstName
+		if entry.Me {
+			name += " (you)"
+		}
+
+		fmt.Fprintf(&b, "%d. %s, %s\n", entry.Rank, name, score)
+	}
+
+	tb.reply(m, b.String())
+}
+
+// HandleEvent announces achievement.unlocked and focus.ended to the user. Announcements that cannot ever be
+// delivered, because the bot is not running or the user blocked it, are logged and dropped rather than retried.
+func (tb *TelegramBot) HandleEvent(ctx context.Context, event *model.Event) error {
+	var text string
+	switch event.Type {
+	case model.EventAchievementUnlocked:
+		var achievement model.UnlockedAchievement
+		if err := event.Decode(&achievement); err != nil {
+			return fmt.Errorf("failed to decode achievement: %w", err)
+		}
+
+		text = fmt.Sprintf("%s Achievement unlocked: %s\n%s", achievement.Emoji, achievement.Name, achievement.Description)
+	case model.EventFocusEnded:
+		var session model.FocusSession
+		if err := event.Decode(&session); err != nil {
+			return fmt.Errorf("failed to decode focus session: %w", err)
+		}
+
+		text = formatFocusEnded(&session)
+	default:
+		return nil
+	}
+
+	if !tb.running.Load() {
+		log.Printf("Telegram bot is not running, dropping %s event %s", event.Type, event.ID)
+		return nil
+	}
+
+	user, err := tb.userService.Get(ctx, event.UserID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	if _, err := tb.bot.Send(&telebot.User{ID: user.TelegramID}, text); err != nil {
+		if isForbidden(err) {
+			log.Printf("Telegram refused %s event %s for user %s, dropping it: %v", event.Type, event.ID, event.UserID, err)
+			return nil
+		}
+
+		return fmt.Errorf("failed to send %s: %w", event.Type, err)
+	}
+
+	return nil
+}
+
+// isForbidden reports whether Telegram refused the message for good, e.g. because the user blocked the bot
+// or never started it. telebot reports unknown API errors as plain errors, so the code is matched in the text.
+func isForbidden(err error) bool {
+	var apiErr *telebot.APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.Code == http.StatusForbidden || strings.HasPrefix(apiErr.Description, "Forbidden")
+	}
+
+	return strings.Contains(err.Error(), "Forbidden") || strings.Contains(err.Error(), "(403)")
+}
+
+func formatFocusEnded(session *model.FocusSession) string {
+	focused := (time.Duration(session.FocusedSeconds) * time.Second).Round(time.Minute)
+
+	if session.Completed {
+		return fmt.Sprintf("Pomodoro on %s done, %s focused. Take a %d minute break.", session.ChapterTitle, formatDuration(focused), model.PomodoroBreakMinutes)
+	}
+
+	return fmt.Sprintf("Focus session on %s ended, %s focused.", session.ChapterTitle, formatDuration(focused))
+}
+
+// formatDuration prints whole minutes as 1h 5m or 25m
+func formatDuration(d time.Duration) string {
+	hours, minutes := int(d.Hours()), int(d.Minutes())%60
+	if hours > 0 {
+		return fmt.Sprintf("%dh %dm", hours, minutes)
+	}
+
+	return fmt.Sprintf("%dm", minutes)
+}
+
+func formatGoals(goals []*model.Goal) string {
+	var b strings.Builder
+	for i, goal := range goals {
+		fmt.Fprintf(&b, "%d. %s (%d%%, due %s)\n", i+1, goal.Title, goal.Progress, goal.Deadline.Format("2 Jan 2006"))
+	}
+
+	return b.String()
+}
+
+// currentUser resolves the sender, users who have not signed up through the web app are asked to do so
+func (tb *TelegramBot) currentUser(ctx context.Context, m *telebot.Message) (*model.User, bool) {
+	user, err := tb.userService.GetByTelegramID(ctx, m.Sender.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrorUserNotFound) {
+			tb.reply(m, "Open the web app with /start to sign up first.")
+			return nil, false
+		}
+
+		log.Printf("Failed to get user: %v", err)
+		tb.reply(m, "Something went wrong, please try again later.")
+		return nil, false
+	}
+
+	return user, true
+}
+
+func (tb *TelegramBot) reply(m *telebot.Message, text string) {
+	if _, err := tb.bot.Send(m.Sender, text); err != nil {
+		log.Printf("Failed to send message: %v", err)
+	}
+}
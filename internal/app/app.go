@@ -10,9 +10,10 @@ import (
 	"syscall"
 	"time"
 
-	"github.com/nordew/Strive/internal/bots"
+	"github.com/nordew/Strive/internal/api/bots"
 	"github.com/nordew/Strive/internal/config"
 	"github.com/nordew/Strive/internal/controller/http/v1"
+	"github.com/nordew/Strive/internal/model"
 	"github.com/nordew/Strive/internal/service"
 	"github.com/nordew/Strive/internal/storage"
 	"github.com/nordew/Strive/pkg/auth"
@@ -32,10 +33,44 @@ func MustRun() {
 	defer pgPool.Close()
 
 	logger := logger.New()
+	transactor := storage.NewTransactor(pgPool)
+	outboxStorage := storage.NewOutboxStorage(pgPool)
+	eventBus := service.NewEventBus(outboxStorage, logger)
+	goalStorage := storage.NewGoalStorage(pgPool)
+	auditStorage := storage.NewAuditStorage(pgPool)
+	auditService := service.NewAuditService(auditStorage, goalStorage, time.Duration(cfg.AuditRetentionDays)*24*time.Hour, logger)
+	revisionStorage := storage.NewRevisionStorage(pgPool)
+	revisionService := service.NewRevisionService(revisionStorage, goalStorage, transactor, logger)
 	userStorage := storage.NewUserStorage(pgPool)
 	jwtAuth := auth.NewAuth(logger)
-	userService := service.NewUserService(userStorage, jwtAuth, logger)
-	router := v1.NewController(userService)
+	userService := service.NewUserService(userStorage, eventBus, auditService, transactor, jwtAuth, logger)
+	webhookStorage := storage.NewWebhookStorage(pgPool)
+	webhookService := service.NewWebhookService(webhookStorage, eventBus, transactor, logger)
+	goalService := service.NewGoalService(goalStorage, eventBus, auditService, revisionService, transactor, logger)
+	templateStorage := storage.NewTemplateStorage(pgPool)
+	templateService := service.NewTemplateService(templateStorage, goalStorage, eventBus, auditService, revisionService, transactor, logger)
+	exportService := service.NewExportService(goalStorage, logger)
+	importService := service.NewImportService(goalStorage, eventBus, auditService, revisionService, transactor, logger)
+	calendarFeedStorage := storage.NewCalendarFeedStorage(pgPool)
+	calendarService := service.NewCalendarService(calendarFeedStorage, goalStorage, logger)
+	calDAVCredentialStorage := storage.NewCalDAVCredentialStorage(pgPool)
+	calDAVService := service.NewCalDAVService(calDAVCredentialStorage, goalStorage, goalService, transactor, logger)
+	trashService := service.NewTrashService(goalStorage, time.Duration(cfg.TrashRetentionDays)*24*time.Hour, logger)
+	archiveService := service.NewArchiveService(goalStorage, goalService, time.Duration(cfg.ArchiveAfterDays)*24*time.Hour, logger)
+	idempotencyStorage := storage.NewIdempotencyStorage(pgPool)
+	idempotencyService := service.NewIdempotencyService(idempotencyStorage, time.Duration(cfg.IdempotencyKeyTTLHours)*time.Hour, logger)
+	tagStorage := storage.NewTagStorage(pgPool)
+	tagService := service.NewTagService(tagStorage, goalStorage, eventBus, auditService, revisionService, transactor, logger)
+	viewStorage := storage.NewViewStorage(pgPool)
+	viewService := service.NewViewService(viewStorage, goalService, logger)
+	focusStorage := storage.NewFocusStorage(pgPool)
+	focusService := service.NewFocusService(focusStorage, goalStorage, eventBus, transactor, logger)
+	statsService := service.NewStatsService(goalStorage, focusStorage, logger)
+	gamificationStorage := storage.NewGamificationStorage(pgPool)
+	gamificationService := service.NewGamificationService(gamificationStorage, eventBus, transactor, logger)
+	friendStorage := storage.NewFriendStorage(pgPool)
+	friendService := service.NewFriendService(friendStorage, userStorage, transactor, cfg.BotUsername, logger)
+	router := v1.NewController(userService, goalService, templateService, exportService, importService, calendarService, calDAVService, webhookService, auditService, revisionService, trashService, idempotencyService, tagService, viewService, statsService, gamificationService, friendService, focusService)
 
 	server := &http.Server{
 		Addr:    fmt.Sprintf(":%d", cfg.HTTPPort),
@@ -50,10 +85,35 @@ func MustRun() {
 		}
 	}()
 
+	// Register event subscribers before the bus starts relaying the outbox
+	eventBus.Subscribe(webhookService, model.WebhookEvents...)
+	eventBus.Subscribe(statsService)
+	eventBus.Subscribe(gamificationService, model.XPEvents...)
+	// achievements and focus sessions ending while the bot is not running are not announced
+	telegramBot := bots.NewTelegramBot(userService, goalService, viewService, gamificationService, friendService)
+	eventBus.Subscribe(telegramBot, model.EventAchievementUnlocked, model.EventFocusEnded)
+
+	go eventBus.Run(ctx)
+
+	// Deliver webhooks until shutdown
+	go webhookService.RunDispatcher(ctx)
+
+	go auditService.RunRetention(ctx)
+
+	go trashService.RunPurge(ctx)
+
+	go archiveService.RunAutoArchive(ctx)
+
+	go idempotencyService.RunPurge(ctx)
+
+	go focusService.RunTimer(ctx)
+
 	// Start the Telegram bot in a separate goroutine
 	go func() {
 		log.Println("Initializing bots bot...")
-		if err := bots.InitBot(cfg.BOTToken, cfg.WebAppURL); err != nil {
+		botManager := bots.NewBotManager()
+		botManager.RegisterBot("telegram", telegramBot)
+		if err := botManager.StartBot("telegram", cfg.BOTToken, cfg.WebAppURL); err != nil {
 			log.Fatalf("failed to init bots bot: %v", err)
 		}
 	}()
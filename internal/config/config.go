@@ -11,6 +11,18 @@ type Config struct {
 	HTTPPort    int    `env:"HTTP_PORT"`
 	BOTToken    string `env:"BOT_TOKEN"`
 	WebAppURL   string `env:"WEB_APP_URL"`
+	// BotUsername is the username of the Telegram bot, friend invites created through the API link to it when set
+	BotUsername string `env:"BOT_USERNAME"`
+
+	// AuditRetentionDays is how long audit entries are kept, 0 keeps them forever
+	AuditRetentionDays int `env:"AUDIT_RETENTION_DAYS" env-default:"365"`
+	// TrashRetentionDays is how long deleted goals, chapters and comments stay in the trash, 0 keeps them forever
+	TrashRetentionDays int `env:"TRASH_RETENTION_DAYS" env-default:"30"`
+	// ArchiveAfterDays is how long completed goals stay active before they are archived, 0 never archives them
+	ArchiveAfterDays int `env:"ARCHIVE_AFTER_DAYS" env-default:"30"`
+	// IdempotencyKeyTTLHours is how long responses are kept for requests retried with an Idempotency-Key,
+	// 0 turns idempotency keys off
+	IdempotencyKeyTTLHours int `env:"IDEMPOTENCY_KEY_TTL_HOURS" env-default:"24"`
 }
 
 var (
@@ -0,0 +1,192 @@
+// Package caldav implements the subset of WebDAV and CalDAV (RFC 4918, RFC 4791) task apps need to sync.
+// Every open goal is a task list and its chapters are VTODO resources named <chapter id>.ics:
+//
+//	/caldav/principals/<user id>/
+//	/caldav/calendars/<user id>/
+//	/caldav/calendars/<user id>/<goal id>/
+//	/caldav/calendars/<user id>/<goal id>/<chapter id>.ics
+//
+// Clients authenticate with HTTP Basic auth, the user ID as user name and the app password from settings.
+// New tasks must be named by a UUID, which becomes the chapter ID.
+package caldav
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+const allowedMethods = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+
+type resourceKind int
+
+const (
+	kindRoot resourceKind = iota
+	kindPrincipal
+	kindHome
+	kindCalendar
+	kindTask
+)
+
+// target is the resource a request path points to
+type target struct {
+	kind      resourceKind
+	userID    string
+	goalID    string
+	chapterID string
+}
+
+type Handler struct {
+	calDAVService service.CalDAVService
+	prefix        string
+}
+
+// NewHandler returns a handler serving the CalDAV tree below prefix, e.g. "/caldav"
+func NewHandler(calDAVService service.CalDAVService, prefix string) *Handler {
+	return &Handler{
+		calDAVService: calDAVService,
+		prefix:        strings.TrimSuffix(prefix, "/"),
+	}
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", allowedMethods)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	userID, ok := h.authenticate(w, r)
+	if !ok {
+		return
+	}
+
+	t, ok := h.parsePath(r.URL.Path)
+	if !ok {
+		http.Error(w, "not found", http.StatusNotFound)
+		return
+	}
+
+	if t.kind != kindRoot && t.userID != userID {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	t.userID = userID
+	r = r.WithContext(service.WithActor(r.Context(), userID))
+
+	switch r.Method {
+	case "PROPFIND":
+		h.propfind(w, r, t)
+	case "REPORT":
+		h.report(w, r, t)
+	case http.MethodGet, http.MethodHead:
+		h.get(w, r, t)
+	case http.MethodPut:
+		h.put(w, r, t)
+	case http.MethodDelete:
+		h.delete(w, r, t)
+	default:
+		w.Header().Set("Allow", allowedMethods)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username, password, ok := r.BasicAuth()
+	if ok {
+		if _, err := uuid.Parse(username); err == nil {
+			err := h.calDAVService.Authenticate(r.Context(), username, password)
+			if err == nil {
+				return username, true
+			}
+
+			if !errors.Is(err, service.ErrInvalidCredentials) {
+				log.Printf("caldav: failed to authenticate: %v", err)
+				http.Error(w, "internal error", http.StatusInternalServerError)
+				return "", false
+			}
+		}
+	}
+
+	w.Header().Set("WWW-Authenticate", `Basic realm="Strive", charset="UTF-8"`)
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return "", false
+}
+
+// parsePath maps a request path to a resource, IDs must be UUIDs
+func (h *Handler) parsePath(path string) (target, bool) {
+	path = strings.TrimPrefix(path, h.prefix)
+	segments := strings.FieldsFunc(path, func(r rune) bool { return r == '/' })
+
+	if len(segments) == 0 {
+		return target{kind: kindRoot}, true
+	}
+
+	for i, segment := range segments {
+		if i == 3 {
+			segment = strings.TrimSuffix(segment, ".ics")
+		}
+
+		if i > 0 && !isUUID(segment) {
+			return target{}, false
+		}
+	}
+
+	switch {
+	case segments[0] == "principals" && len(segments) == 2:
+		return target{kind: kindPrincipal, userID: segments[1]}, true
+	case segments[0] == "calendars" && len(segments) == 2:
+		return target{kind: kindHome, userID: segments[1]}, true
+	case segments[0] == "calendars" && len(segments) == 3:
+		return target{kind: kindCalendar, userID: segments[1], goalID: segments[2]}, true
+	case segments[0] == "calendars" && len(segments) == 4 && strings.HasSuffix(segments[3], ".ics"):
+		return target{kind: kindTask, userID: segments[1], goalID: segments[2], chapterID: strings.ToLower(strings.TrimSuffix(segments[3], ".ics"))}, true
+	default:
+		return target{}, false
+	}
+}
+
+func (h *Handler) principalPath(userID string) string {
+	return h.prefix + "/principals/" + userID + "/"
+}
+
+func (h *Handler) homePath(userID string) string {
+	return h.prefix + "/calendars/" + userID + "/"
+}
+
+func (h *Handler) calendarPath(userID, goalID string) string {
+	return h.homePath(userID) + goalID + "/"
+}
+
+func (h *Handler) taskPath(userID, goalID, chapterID string) string {
+	return h.calendarPath(userID, goalID) + chapterID + ".ics"
+}
+
+// handleErr maps service errors to the status codes CalDAV clients understand
+func handleErr(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, storage.ErrGoalNotFound), errors.Is(err, storage.ErrChapterNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, storage.ErrVersionConflict):
+		http.Error(w, err.Error(), http.StatusPreconditionFailed)
+	case errors.Is(err, service.ErrUnmetDependencies):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, service.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		log.Printf("caldav: %v", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}
+}
+
+func isUUID(value string) bool {
+	_, err := uuid.Parse(value)
+	return err == nil
+}
@@ -0,0 +1,343 @@
+package caldav
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (h *Handler) propfind(w http.ResponseWriter, r *http.Request, t target) {
+	body, err := parseBody(r)
+	if err != nil {
+		http.Error(w, "invalid propfind body", http.StatusBadRequest)
+		return
+	}
+
+	var requested []xml.Name
+	if body != nil {
+		requested = requestedProps(body)
+	}
+
+	// infinite depth is not supported and treated like 1
+	withChildren := r.Header.Get("Depth") != "0"
+
+	var responses []response
+	switch t.kind {
+	case kindRoot, kindPrincipal:
+		responses = append(responses, h.principalResponse(r.URL.Path, t.userID, t.kind == kindRoot))
+	case kindHome:
+		responses = append(responses, h.homeResponse(t.userID))
+		if withChildren {
+			goals, err := h.calDAVService.GetCalendars(r.Context(), t.userID)
+			if err != nil {
+				handleErr(w, err)
+				return
+			}
+
+			for _, goal := range goals {
+				_, chapters, err := h.calDAVService.GetCalendar(r.Context(), t.userID, goal.ID)
+				if err != nil {
+					handleErr(w, err)
+					return
+				}
+
+				responses = append(responses, h.calendarResponse(t.userID, goal, chapters))
+			}
+		}
+	case kindCalendar:
+		goal, chapters, err := h.calDAVService.GetCalendar(r.Context(), t.userID, t.goalID)
+		if err != nil {
+			handleErr(w, err)
+			return
+		}
+
+		responses = append(responses, h.calendarResponse(t.userID, goal, chapters))
+		if withChildren {
+			for _, chapter := range chapters {
+				responses = append(responses, h.taskResponse(t.userID, chapter, false))
+			}
+		}
+	case kindTask:
+		_, chapter, err := h.findTask(r, t)
+		if err != nil {
+			handleErr(w, err)
+			return
+		}
+
+		responses = append(responses, h.taskResponse(t.userID, chapter, false))
+	}
+
+	writeMultistatus(w, responses, requested)
+}
+
+// report answers calendar-query and calendar-multiget, the only reports task sync needs
+func (h *Handler) report(w http.ResponseWriter, r *http.Request, t target) {
+	body, err := parseBody(r)
+	if err != nil || body == nil || body.XMLName.Space != nsCalDAV {
+		http.Error(w, "unsupported report", http.StatusForbidden)
+		return
+	}
+
+	requested := requestedProps(body)
+	withData := requested == nil
+	for _, name := range requested {
+		if name == propCalendarData {
+			withData = true
+		}
+	}
+
+	var responses []response
+	switch body.XMLName.Local {
+	case "calendar-query":
+		if t.kind != kindCalendar {
+			http.Error(w, "calendar-query is only supported on task lists", http.StatusForbidden)
+			return
+		}
+
+		if !queriesTodos(body) {
+			writeMultistatus(w, nil, requested)
+			return
+		}
+
+		_, chapters, err := h.calDAVService.GetCalendar(r.Context(), t.userID, t.goalID)
+		if err != nil {
+			handleErr(w, err)
+			return
+		}
+
+		for _, chapter := range chapters {
+			responses = append(responses, h.taskResponse(t.userID, chapter, withData))
+		}
+	case "calendar-multiget":
+		calendars := make(map[string][]*model.Chapter)
+
+		for _, child := range body.Children {
+			if child.XMLName.Space != nsDAV || child.XMLName.Local != "href" {
+				continue
+			}
+
+			resp := response{href: strings.TrimSpace(child.Text), status: http.StatusNotFound}
+
+			if path, err := url.Parse(resp.href); err == nil {
+				if task, ok := h.parsePath(path.Path); ok && task.kind == kindTask && task.userID == t.userID {
+					chapters, ok := calendars[task.goalID]
+					if !ok {
+						_, chapters, _ = h.calDAVService.GetCalendar(r.Context(), t.userID, task.goalID)
+						calendars[task.goalID] = chapters
+					}
+
+					if chapter := findChapter(chapters, task.chapterID); chapter != nil {
+						resp = h.taskResponse(t.userID, chapter, withData)
+					}
+				}
+			}
+
+			responses = append(responses, resp)
+		}
+	default:
+		http.Error(w, "unsupported report", http.StatusForbidden)
+		return
+	}
+
+	writeMultistatus(w, responses, requested)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request, t target) {
+	if t.kind != kindTask {
+		w.Header().Set("Allow", "OPTIONS, PROPFIND, REPORT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	_, chapter, err := h.findTask(r, t)
+	if err != nil {
+		handleErr(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", chapter.ETag())
+	w.Header().Set("Last-Modified", chapter.UpdatedAt.UTC().Format(http.TimeFormat))
+
+	if r.Header.Get("If-None-Match") == chapter.ETag() {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	var body bytes.Buffer
+	if err := encodeTask(&body, chapter); err != nil {
+		handleErr(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", taskContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(body.Len()))
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		w.Write(body.Bytes())
+	}
+}
+
+// put creates or updates a task. No ETag is returned because the stored object differs from the upload,
+// which tells clients to fetch it again (RFC 4791, section 5.3.4).
+func (h *Handler) put(w http.ResponseWriter, r *http.Request, t target) {
+	if t.kind != kindTask {
+		http.Error(w, "only tasks can be written", http.StatusMethodNotAllowed)
+		return
+	}
+
+	task, err := decodeTask(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.calDAVService.PutTask(r.Context(), t.userID, t.goalID, t.chapterID, task, r.Header.Get("If-Match"), r.Header.Get("If-None-Match"))
+	if err != nil {
+		handleErr(w, err)
+		return
+	}
+
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) delete(w http.ResponseWriter, r *http.Request, t target) {
+	if t.kind != kindTask {
+		http.Error(w, "only tasks can be deleted", http.StatusForbidden)
+		return
+	}
+
+	if err := h.calDAVService.DeleteTask(r.Context(), t.userID, t.goalID, t.chapterID, r.Header.Get("If-Match")); err != nil {
+		handleErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handler) findTask(r *http.Request, t target) (*model.Goal, *model.Chapter, error) {
+	goal, chapters, err := h.calDAVService.GetCalendar(r.Context(), t.userID, t.goalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chapter := findChapter(chapters, t.chapterID)
+	if chapter == nil {
+		return nil, nil, storage.ErrChapterNotFound
+	}
+
+	return goal, chapter, nil
+}
+
+func (h *Handler) principalResponse(path, userID string, root bool) response {
+	resourceType := "<d:principal/>"
+	if root {
+		resourceType = "<d:collection/>"
+	}
+
+	return response{
+		href: path,
+		props: map[xml.Name]string{
+			propResourceType:         resourceType,
+			propDisplayName:          "Strive",
+			propCurrentUserPrincipal: href(h.principalPath(userID)),
+			propPrincipalURL:         href(h.principalPath(userID)),
+			propCalendarHomeSet:      href(h.homePath(userID)),
+		},
+	}
+}
+
+func (h *Handler) homeResponse(userID string) response {
+	return response{
+		href: h.homePath(userID),
+		props: map[xml.Name]string{
+			propResourceType:         "<d:collection/>",
+			propDisplayName:          "Strive",
+			propCurrentUserPrincipal: href(h.principalPath(userID)),
+			propOwner:                href(h.principalPath(userID)),
+		},
+	}
+}
+
+func (h *Handler) calendarResponse(userID string, goal *model.Goal, chapters []*model.Chapter) response {
+	ctag := calendarTag(goal, chapters)
+
+	return response{
+		href: h.calendarPath(userID, goal.ID),
+		props: map[xml.Name]string{
+			propResourceType:         "<d:collection/><c:calendar/>",
+			propDisplayName:          escape(goal.Title),
+			propCalendarDescription:  escape(goal.Description),
+			propSupportedComponents:  `<c:comp name="VTODO"/>`,
+			propCTag:                 escape(ctag),
+			propETag:                 escape(ctag),
+			propCurrentUserPrincipal: href(h.principalPath(userID)),
+			propOwner:                href(h.principalPath(userID)),
+			propPrivilegeSet:         "<d:privilege><d:read/></d:privilege><d:privilege><d:write/></d:privilege><d:privilege><d:write-content/></d:privilege><d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>",
+		},
+	}
+}
+
+func (h *Handler) taskResponse(userID string, chapter *model.Chapter, withData bool) response {
+	resp := response{
+		href: h.taskPath(userID, chapter.GoalID, chapter.ID),
+		props: map[xml.Name]string{
+			propResourceType: "",
+			propETag:         escape(chapter.ETag()),
+			propContentType:  taskContentType,
+			propLastModified: chapter.UpdatedAt.UTC().Format(http.TimeFormat),
+		},
+	}
+
+	if withData {
+		var data bytes.Buffer
+		if err := encodeTask(&data, chapter); err == nil {
+			resp.props[propCalendarData] = escape(data.String())
+		}
+	}
+
+	return resp
+}
+
+// calendarTag changes whenever a chapter of the goal is added, changed or removed
+func calendarTag(goal *model.Goal, chapters []*model.Chapter) string {
+	latest := goal.UpdatedAt
+	for _, chapter := range chapters {
+		if chapter.UpdatedAt.After(latest) {
+			latest = chapter.UpdatedAt
+		}
+	}
+
+	return `"` + strconv.FormatInt(latest.UnixMicro(), 36) + "-" + strconv.Itoa(len(chapters)) + `"`
+}
+
+// queriesTodos reports whether the comp-filter of a calendar-query can match VTODO components
+func queriesTodos(body *xmlNode) bool {
+	calendarFilter := body.child(nsCalDAV, "filter").child(nsCalDAV, "comp-filter")
+	if calendarFilter == nil {
+		return true
+	}
+
+	componentFilter := calendarFilter.child(nsCalDAV, "comp-filter")
+	return componentFilter == nil || strings.EqualFold(componentFilter.attr("name"), "VTODO")
+}
+
+func findChapter(chapters []*model.Chapter, id string) *model.Chapter {
+	for _, chapter := range chapters {
+		if chapter.ID == id {
+			return chapter
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package caldav
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/pkg/ical"
+)
+
+const taskContentType = "text/calendar; charset=utf-8; component=vtodo"
+
+var errNoTodo = errors.New("calendar object contains no VTODO")
+
+// encodeTask writes the chapter as a VCALENDAR with a single VTODO. DTSTAMP is derived from
+// updated_at, so the body only changes together with the ETag.
+func encodeTask(w io.Writer, chapter *model.Chapter) error {
+	writer := ical.NewWriter(w)
+
+	writer.Begin("VCALENDAR")
+	writer.Property("VERSION", "2.0")
+	writer.Property("PRODID", "-//Strive//Strive CalDAV//EN")
+	writer.Begin("VTODO")
+	writer.Property("UID", chapter.ID)
+	writer.DateTime("DTSTAMP", chapter.UpdatedAt)
+	writer.DateTime("CREATED", chapter.CreatedAt)
+	writer.DateTime("LAST-MODIFIED", chapter.UpdatedAt)
+	writer.Text("SUMMARY", chapter.Title)
+	if chapter.Description != "" {
+		writer.Text("DESCRIPTION", chapter.Description)
+	}
+	if !chapter.Deadline.IsZero() {
+		writer.DateTime("DUE", chapter.Deadline)
+	}
+	if chapter.IsDone {
+		writer.Property("STATUS", "COMPLETED")
+		writer.DateTime("COMPLETED", chapter.UpdatedAt)
+		writer.Property("PERCENT-COMPLETE", "100")
+	} else {
+		writer.Property("STATUS", "NEEDS-ACTION")
+	}
+	writer.End("VTODO")
+	writer.End("VCALENDAR")
+
+	return writer.Flush()
+}
+
+// decodeTask reads the first VTODO of an uploaded calendar object
+func decodeTask(r io.Reader) (*dto.CalDAVTaskDTO, error) {
+	calendar, err := ical.Parse(r)
+	if err != nil {
+		return nil, err
+	}
+
+	if calendar.Name != "VCALENDAR" {
+		return nil, fmt.Errorf("%w: expected VCALENDAR, got %s", ical.ErrMalformed, calendar.Name)
+	}
+
+	todo := calendar.Component("VTODO")
+	if todo == nil {
+		return nil, errNoTodo
+	}
+
+	var task dto.CalDAVTaskDTO
+
+	if summary := todo.Property("SUMMARY"); summary != nil {
+		task.Title = strings.TrimSpace(summary.Text())
+	}
+
+	if description := todo.Property("DESCRIPTION"); description != nil {
+		task.Description = strings.TrimSpace(description.Text())
+	}
+
+	if due := todo.Property("DUE"); due != nil {
+		if task.Deadline, err = due.Time(); err != nil {
+			return nil, fmt.Errorf("%w: invalid DUE: %v", ical.ErrMalformed, err)
+		}
+	}
+
+	// STATUS wins over a stale COMPLETED some clients leave behind when reopening a task
+	if status := todo.Property("STATUS"); status != nil {
+		task.IsDone = strings.EqualFold(status.Value, "COMPLETED")
+	} else {
+		task.IsDone = todo.Property("COMPLETED") != nil
+	}
+
+	return &task, nil
+}
@@ -0,0 +1,206 @@
+package caldav
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	nsDAV    = "DAV:"
+	nsCalDAV = "urn:ietf:params:xml:ns:caldav"
+	nsCS     = "http://calendarserver.org/ns/"
+)
+
+var prefixes = map[string]string{
+	nsDAV:    "d",
+	nsCalDAV: "c",
+	nsCS:     "cs",
+}
+
+var (
+	propResourceType         = xml.Name{Space: nsDAV, Local: "resourcetype"}
+	propDisplayName          = xml.Name{Space: nsDAV, Local: "displayname"}
+	propCurrentUserPrincipal = xml.Name{Space: nsDAV, Local: "current-user-principal"}
+	propPrincipalURL         = xml.Name{Space: nsDAV, Local: "principal-URL"}
+	propOwner                = xml.Name{Space: nsDAV, Local: "owner"}
+	propPrivilegeSet         = xml.Name{Space: nsDAV, Local: "current-user-privilege-set"}
+	propETag                 = xml.Name{Space: nsDAV, Local: "getetag"}
+	propContentType          = xml.Name{Space: nsDAV, Local: "getcontenttype"}
+	propLastModified         = xml.Name{Space: nsDAV, Local: "getlastmodified"}
+	propCalendarHomeSet      = xml.Name{Space: nsCalDAV, Local: "calendar-home-set"}
+	propCalendarDescription  = xml.Name{Space: nsCalDAV, Local: "calendar-description"}
+	propSupportedComponents  = xml.Name{Space: nsCalDAV, Local: "supported-calendar-component-set"}
+	propCalendarData         = xml.Name{Space: nsCalDAV, Local: "calendar-data"}
+	propCTag                 = xml.Name{Space: nsCS, Local: "getctag"}
+)
+
+type (
+	// xmlNode is a generic element tree of a request body
+	xmlNode struct {
+		XMLName  xml.Name
+		Attrs    []xml.Attr `xml:",any,attr"`
+		Children []xmlNode  `xml:",any"`
+		Text     string     `xml:",chardata"`
+	}
+
+	// response is a single resource of a multistatus, props hold inner XML.
+	// Responses with a status carry no props, e.g. unknown hrefs of a multiget.
+	response struct {
+		href   string
+		status int
+		props  map[xml.Name]string
+	}
+)
+
+// parseBody decodes the request body, an empty body yields nil
+func parseBody(r *http.Request) (*xmlNode, error) {
+	var node xmlNode
+
+	if err := xml.NewDecoder(r.Body).Decode(&node); err != nil {
+		if err == io.EOF {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &node, nil
+}
+
+func (n *xmlNode) child(space, local string) *xmlNode {
+	if n == nil {
+		return nil
+	}
+
+	for i := range n.Children {
+		if n.Children[i].XMLName.Space == space && n.Children[i].XMLName.Local == local {
+			return &n.Children[i]
+		}
+	}
+
+	return nil
+}
+
+func (n *xmlNode) attr(local string) string {
+	for _, attr := range n.Attrs {
+		if attr.Name.Local == local {
+			return attr.Value
+		}
+	}
+
+	return ""
+}
+
+// requestedProps returns the names listed in DAV:prop, nil stands for all properties
+func requestedProps(root *xmlNode) []xml.Name {
+	prop := root.child(nsDAV, "prop")
+	if prop == nil {
+		return nil
+	}
+
+	names := make([]xml.Name, 0, len(prop.Children))
+	for _, child := range prop.Children {
+		names = append(names, child.XMLName)
+	}
+
+	return names
+}
+
+func writeMultistatus(w http.ResponseWriter, responses []response, requested []xml.Name) {
+	var b strings.Builder
+
+	b.WriteString(`<?xml version="1.0" encoding="utf-8"?>` + "\n")
+	b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav" xmlns:cs="http://calendarserver.org/ns/">`)
+
+	for _, resp := range responses {
+		b.WriteString("<d:response><d:href>")
+		b.WriteString(escape(resp.href))
+		b.WriteString("</d:href>")
+
+		if resp.status != 0 {
+			fmt.Fprintf(&b, "<d:status>HTTP/1.1 %d %s</d:status></d:response>", resp.status, http.StatusText(resp.status))
+			continue
+		}
+
+		names := requested
+		if names == nil {
+			names = allProps(resp.props)
+		}
+
+		var found, missing strings.Builder
+		for i, name := range names {
+			value, ok := resp.props[name]
+			if !ok {
+				writeElement(&missing, name, "", i)
+				continue
+			}
+
+			writeElement(&found, name, value, i)
+		}
+
+		if found.Len() > 0 {
+			fmt.Fprintf(&b, "<d:propstat><d:prop>%s</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>", found.String())
+		}
+		if missing.Len() > 0 {
+			fmt.Fprintf(&b, "<d:propstat><d:prop>%s</d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>", missing.String())
+		}
+
+		b.WriteString("</d:response>")
+	}
+
+	b.WriteString("</d:multistatus>\n")
+
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	io.WriteString(w, b.String())
+}
+
+// allProps answers allprop requests, calendar-data is only returned when asked for explicitly
+func allProps(props map[xml.Name]string) []xml.Name {
+	names := make([]xml.Name, 0, len(props))
+	for name := range props {
+		if name != propCalendarData {
+			names = append(names, name)
+		}
+	}
+
+	sort.Slice(names, func(i, j int) bool {
+		if names[i].Space != names[j].Space {
+			return names[i].Space < names[j].Space
+		}
+		return names[i].Local < names[j].Local
+	})
+
+	return names
+}
+
+// writeElement writes the property with its inner XML, unknown namespaces are declared inline
+func writeElement(b *strings.Builder, name xml.Name, inner string, index int) {
+	prefix, ok := prefixes[name.Space]
+	declaration := ""
+	if !ok {
+		prefix = fmt.Sprintf("x%d", index)
+		declaration = fmt.Sprintf(` xmlns:%s="%s"`, prefix, escape(name.Space))
+	}
+
+	if inner == "" {
+		fmt.Fprintf(b, "<%s:%s%s/>", prefix, name.Local, declaration)
+		return
+	}
+
+	fmt.Fprintf(b, "<%s:%s%s>%s</%s:%s>", prefix, name.Local, declaration, inner, prefix, name.Local)
+}
+
+func escape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
+
+func href(path string) string {
+	return "<d:href>" + escape(path) + "</d:href>"
+}
@@ -3,8 +3,10 @@ package v1
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
 	"log"
 	"strconv"
 )
@@ -40,27 +42,9 @@ func (c *Controller) login(gCtx *gin.Context) {
 func (c *Controller) authorize(gCtx *gin.Context) {
 	internalErr := errors.New("failed to authorize")
 
-	telegramID, ok := gCtx.Get(TelegramIDKey)
-	if !ok {
-		log.Printf("telegram_id not found in context")
-		handleErr(gCtx, 400, internalErr)
-		return
-	}
-
-	var telegramIDInt int64
-	switch v := telegramID.(type) {
-	case int64:
-		telegramIDInt = v
-	case string:
-		var err error
-		telegramIDInt, err = strconv.ParseInt(v, 10, 64)
-		if err != nil {
-			log.Printf("failed to convert telegram_id string to int64: %v", err)
-			handleErr(gCtx, 400, internalErr)
-			return
-		}
-	default:
-		log.Printf("failed to cast telegram_id to int64, unexpected type: %T", telegramID)
+	telegramIDInt, err := telegramIDFromContext(gCtx)
+	if err != nil {
+		log.Println(err)
 		handleErr(gCtx, 400, internalErr)
 		return
 	}
@@ -79,3 +63,34 @@ func (c *Controller) authorize(gCtx *gin.Context) {
 
 	gCtx.JSON(200, gin.H{"message": "authorized"})
 }
+
+// telegramIDFromContext returns the telegram_id stored by TelegramAuthMiddleware
+func telegramIDFromContext(gCtx *gin.Context) (int64, error) {
+	telegramID, ok := gCtx.Get(TelegramIDKey)
+	if !ok {
+		return 0, errors.New("telegram_id not found in context")
+	}
+
+	switch v := telegramID.(type) {
+	case int64:
+		return v, nil
+	case string:
+		telegramIDInt, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed to convert telegram_id string to int64: %w", err)
+		}
+		return telegramIDInt, nil
+	default:
+		return 0, fmt.Errorf("failed to cast telegram_id to int64, unexpected type: %T", telegramID)
+	}
+}
+
+// currentUser resolves the user behind the telegram_id stored by TelegramAuthMiddleware
+func (c *Controller) currentUser(gCtx *gin.Context) (*model.User, error) {
+	telegramID, err := telegramIDFromContext(gCtx)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.userService.GetByTelegramID(gCtx, telegramID)
+}
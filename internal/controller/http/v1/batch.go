@@ -0,0 +1,52 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+)
+
+func (c *Controller) initBatchRoutes() {
+	batchGroup := c.router.Group("/batch")
+	batchGroup.Use(TelegramAuthMiddleware())
+	{
+		batchGroup.POST("", c.runBatch)
+	}
+}
+
+func (c *Controller) runBatch(ctx *gin.Context) {
+	internalErr := errors.New("failed to run batch")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var batchDTO dto.BatchDTO
+	if err := ctx.ShouldBindJSON(&batchDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	results, err := c.goalService.Batch(ctx, user.ID, &batchDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	response := make([]gin.H, 0, len(results))
+	for _, result := range results {
+		resultResponse := gin.H{"index": result.Index, "op": result.Op, "status": result.Status}
+		if result.Err != nil {
+			// failed operations carry the status code they would have got as a single request
+			code, err := goalErrResponse(result.Err, errors.New("operation failed"))
+			resultResponse["error"] = err.Error()
+			resultResponse["code"] = code
+		}
+
+		response = append(response, resultResponse)
+	}
+
+	ctx.JSON(200, gin.H{"results": response})
+}
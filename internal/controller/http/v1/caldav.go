@@ -0,0 +1,105 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/controller/caldav"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+const calDAVPrefix = "/caldav"
+
+func (c *Controller) initCalDAVRoutes() {
+	handler := gin.WrapH(caldav.NewHandler(c.calDAVService, calDAVPrefix))
+	for _, method := range []string{"OPTIONS", "GET", "HEAD", "PUT", "DELETE", "PROPFIND", "REPORT"} {
+		c.router.Handle(method, calDAVPrefix+"/*path", handler)
+	}
+
+	// service discovery, RFC 6764
+	wellKnown := func(ctx *gin.Context) {
+		ctx.Redirect(301, calDAVPrefix+"/")
+	}
+	c.router.GET("/.well-known/caldav", wellKnown)
+	c.router.Handle("PROPFIND", "/.well-known/caldav", wellKnown)
+
+	settingsGroup := c.router.Group("/settings/caldav")
+	settingsGroup.Use(TelegramAuthMiddleware())
+	{
+		settingsGroup.GET("", c.getCalDAVSettings)
+		settingsGroup.POST("/password", c.regenerateCalDAVPassword)
+		settingsGroup.DELETE("/password", c.revokeCalDAVPassword)
+	}
+}
+
+func (c *Controller) getCalDAVSettings(ctx *gin.Context) {
+	internalErr := errors.New("failed to get caldav settings")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	credential, err := c.calDAVService.GetCredential(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCalDAVCredentialNotFound) {
+			handleErr(ctx, 404, storage.ErrCalDAVCredentialNotFound)
+			return
+		}
+
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{
+		"username":   credential.UserID,
+		"path":       calDAVPrefix + "/",
+		"created_at": credential.CreatedAt,
+	})
+}
+
+// regenerateCalDAVPassword returns the new app password, it is not shown again
+func (c *Controller) regenerateCalDAVPassword(ctx *gin.Context) {
+	internalErr := errors.New("failed to regenerate caldav password")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	credential, password, err := c.calDAVService.RegeneratePassword(ctx, user.ID)
+	if err != nil {
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(201, gin.H{
+		"username":   credential.UserID,
+		"password":   password,
+		"path":       calDAVPrefix + "/",
+		"created_at": credential.CreatedAt,
+	})
+}
+
+func (c *Controller) revokeCalDAVPassword(ctx *gin.Context) {
+	internalErr := errors.New("failed to revoke caldav password")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.calDAVService.RevokePassword(ctx, user.ID); err != nil {
+		if errors.Is(err, storage.ErrCalDAVCredentialNotFound) {
+			handleErr(ctx, 404, storage.ErrCalDAVCredentialNotFound)
+			return
+		}
+
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "caldav password revoked"})
+}
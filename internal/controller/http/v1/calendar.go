@@ -0,0 +1,146 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"log"
+	"strings"
+)
+
+func (c *Controller) initCalendarRoutes() {
+	// the token in the URL is the only credential, calendar clients cannot send telegram headers
+	c.router.GET("/calendar/:token", c.getCalendarFeed)
+
+	settingsGroup := c.router.Group("/settings/calendar")
+	settingsGroup.Use(TelegramAuthMiddleware())
+	{
+		settingsGroup.GET("", c.getCalendarSettings)
+		settingsGroup.POST("/token", c.regenerateCalendarToken)
+		settingsGroup.DELETE("/token", c.revokeCalendarToken)
+	}
+}
+
+func (c *Controller) getCalendarFeed(ctx *gin.Context) {
+	internalErr := errors.New("failed to get calendar feed")
+
+	token := strings.TrimSuffix(ctx.Param("token"), ".ics")
+
+	feed, etag, err := c.calendarService.ResolveFeed(ctx, token)
+	if err != nil {
+		if errors.Is(err, storage.ErrCalendarFeedNotFound) {
+			handleErr(ctx, 404, storage.ErrCalendarFeedNotFound)
+			return
+		}
+
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", etag)
+	ctx.Header("Cache-Control", "private, max-age=900")
+
+	if etagMatches(ctx.GetHeader("If-None-Match"), etag) {
+		ctx.Status(304)
+		return
+	}
+
+	ctx.Header("Content-Type", "text/calendar; charset=utf-8")
+	ctx.Status(200)
+
+	if err := c.calendarService.WriteFeed(ctx, feed.UserID, ctx.Writer); err != nil {
+		log.Printf("failed to write calendar feed: %v", err)
+		if !ctx.Writer.Written() {
+			handleErr(ctx, 500, internalErr)
+		}
+	}
+}
+
+func (c *Controller) getCalendarSettings(ctx *gin.Context) {
+	internalErr := errors.New("failed to get calendar settings")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	feed, err := c.calendarService.GetFeed(ctx, user.ID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCalendarFeedNotFound) {
+			handleErr(ctx, 404, storage.ErrCalendarFeedNotFound)
+			return
+		}
+
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, calendarFeedResponse(feed))
+}
+
+func (c *Controller) regenerateCalendarToken(ctx *gin.Context) {
+	internalErr := errors.New("failed to regenerate calendar token")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	feed, err := c.calendarService.RegenerateFeedToken(ctx, user.ID)
+	if err != nil {
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(201, calendarFeedResponse(feed))
+}
+
+func (c *Controller) revokeCalendarToken(ctx *gin.Context) {
+	internalErr := errors.New("failed to revoke calendar token")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.calendarService.RevokeFeedToken(ctx, user.ID); err != nil {
+		if errors.Is(err, storage.ErrCalendarFeedNotFound) {
+			handleErr(ctx, 404, storage.ErrCalendarFeedNotFound)
+			return
+		}
+
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "calendar token revoked"})
+}
+
+func calendarFeedResponse(feed *model.CalendarFeed) gin.H {
+	return gin.H{
+		"token":      feed.Token,
+		"path":       feed.Path(),
+		"created_at": feed.CreatedAt,
+	}
+}
+
+// etagMatches reports whether an If-None-Match header lists the tag, weak tags compare equal to strong ones
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,181 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+)
+
+func (c *Controller) createChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to create chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var chapterDTO dto.CreateChapterDTO
+	if err := ctx.ShouldBindJSON(&chapterDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	chapter, err := c.goalService.CreateChapter(ctx, user.ID, ctx.Param("id"), &chapterDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", chapter.ETag())
+	ctx.JSON(201, chapter)
+}
+
+func (c *Controller) getChapters(ctx *gin.Context) {
+	internalErr := errors.New("failed to get chapters")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	chapters, err := c.goalService.GetChapters(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, chapters)
+}
+
+func (c *Controller) reorderChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to reorder chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var moveDTO dto.MoveChapterDTO
+	if err := ctx.ShouldBindJSON(&moveDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	chapters, err := c.goalService.ReorderChapter(ctx, user.ID, ctx.Param("id"), &moveDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, chapters)
+}
+
+func (c *Controller) setChapterDependencies(ctx *gin.Context) {
+	internalErr := errors.New("failed to set chapter dependencies")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var dependenciesDTO dto.SetChapterDependenciesDTO
+	if err := ctx.ShouldBindJSON(&dependenciesDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	chapter, err := c.goalService.SetChapterDependencies(ctx, user.ID, ctx.Param("id"), ctx.Param("chapterID"), &dependenciesDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", chapter.ETag())
+	ctx.JSON(200, chapter)
+}
+
+// patchChapter applies the JSON merge patch in the body, If-Match makes the update conditional
+func (c *Controller) patchChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to update chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	patchDTO, ok := bindMergePatch(ctx)
+	if !ok {
+		return
+	}
+
+	chapter, err := c.goalService.PatchChapter(ctx, user.ID, ctx.Param("id"), ctx.Param("chapterID"), patchDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", chapter.ETag())
+	ctx.JSON(200, chapter)
+}
+
+// completeChapter completes the chapter, ?force=true skips the dependency check
+func (c *Controller) completeChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to complete chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	force := ctx.Query("force") == "true"
+
+	chapter, err := c.goalService.CompleteChapter(ctx, user.ID, ctx.Param("id"), ctx.Param("chapterID"), force)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", chapter.ETag())
+	ctx.JSON(200, chapter)
+}
+
+func (c *Controller) deleteChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.goalService.DeleteChapter(ctx, user.ID, ctx.Param("id"), ctx.Param("chapterID")); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "chapter moved to trash"})
+}
+
+func (c *Controller) getCriticalPath(ctx *gin.Context) {
+	internalErr := errors.New("failed to get critical path")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	path, err := c.goalService.GetCriticalPath(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, path)
+}
@@ -0,0 +1,48 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+)
+
+func (c *Controller) createComment(ctx *gin.Context) {
+	internalErr := errors.New("failed to create comment")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var commentDTO dto.CreateCommentDTO
+	if err := ctx.ShouldBindJSON(&commentDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	comment, err := c.goalService.CreateComment(ctx, user.ID, ctx.Param("id"), &commentDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, comment)
+}
+
+func (c *Controller) deleteComment(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete comment")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.goalService.DeleteComment(ctx, user.ID, ctx.Param("id"), ctx.Param("commentID")); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "comment moved to trash"})
+}
@@ -9,17 +9,72 @@ import (
 )
 
 type Controller struct {
-	userService service.UserService
-	goalService service.GoalService
-	router      *gin.Engine
+	userService         service.UserService
+	goalService         service.GoalService
+	templateService     service.TemplateService
+	exportService       service.ExportService
+	importService       service.ImportService
+	calendarService     service.CalendarService
+	calDAVService       service.CalDAVService
+	webhookService      service.WebhookService
+	auditService        service.AuditService
+	revisionService     service.RevisionService
+	trashService        service.TrashService
+	idempotencyService  service.IdempotencyService
+	tagService          service.TagService
+	viewService         service.ViewService
+	statsService        service.StatsService
+	gamificationService service.GamificationService
+	friendService       service.FriendService
+	focusService        service.FocusService
+	router              *gin.Engine
 }
 
-func NewController(userService service.UserService) *Controller {
+func NewController(
+	userService service.UserService,
+	goalService service.GoalService,
+	templateService service.TemplateService,
+	exportService service.ExportService,
+	importService service.ImportService,
+	calendarService service.CalendarService,
+	calDAVService service.CalDAVService,
+	webhookService service.WebhookService,
+	auditService service.AuditService,
+	revisionService service.RevisionService,
+	trashService service.TrashService,
+	idempotencyService service.IdempotencyService,
+	tagService service.TagService,
+	viewService service.ViewService,
+	statsService service.StatsService,
+	gamificationService service.GamificationService,
+	friendService service.FriendService,
+	focusService service.FocusService,
+) *Controller {
 	controller := &Controller{
-		userService: userService,
-		router:      gin.New(),
+		userService:         userService,
+		goalService:         goalService,
+		templateService:     templateService,
+		exportService:       exportService,
+		importService:       importService,
+		calendarService:     calendarService,
+		calDAVService:       calDAVService,
+		webhookService:      webhookService,
+		auditService:        auditService,
+		revisionService:     revisionService,
+		trashService:        trashService,
+		idempotencyService:  idempotencyService,
+		tagService:          tagService,
+		viewService:         viewService,
+		statsService:        statsService,
+		gamificationService: gamificationService,
+		friendService:       friendService,
+		focusService:        focusService,
+		router:              gin.New(),
 	}
 
+	// services read the request ID and actor stored in the request context through the gin context
+	controller.router.ContextWithFallback = true
+
 	controller.initRoutes()
 	return controller
 }
@@ -36,7 +91,24 @@ func (c *Controller) Run(httpPort int) {
 
 func (c *Controller) initRoutes() {
 	applyMiddlewares(c.router)
+	c.router.Use(c.auditContext())
+	c.router.Use(c.idempotency())
 	c.initAuthRoutes(c.router)
+	c.initGoalRoutes()
+	c.initTemplateRoutes()
+	c.initExportRoutes()
+	c.initImportRoutes()
+	c.initCalendarRoutes()
+	c.initCalDAVRoutes()
+	c.initWebhookRoutes()
+	c.initTrashRoutes()
+	c.initBatchRoutes()
+	c.initTagRoutes()
+	c.initViewRoutes()
+	c.initStatsRoutes()
+	c.initProfileRoutes()
+	c.initFriendRoutes()
+	c.initFocusRoutes()
 }
 
 func handleErr(ctx *gin.Context, statusCode int, err error) {
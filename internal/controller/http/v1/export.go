@@ -0,0 +1,50 @@
+package v1
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/export"
+	"github.com/nordew/Strive/internal/service"
+	"log"
+)
+
+func (c *Controller) initExportRoutes() {
+	c.router.GET("/export", TelegramAuthMiddleware(), c.exportGoals)
+}
+
+// exportGoals streams the goals of the user as json, csv, md or ics, json is the default
+func (c *Controller) exportGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to export goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	format := export.Format(ctx.DefaultQuery("format", string(export.FormatJSON)))
+	switch format {
+	case export.FormatJSON, export.FormatCSV, export.FormatMarkdown, export.FormatICS:
+	default:
+		handleErr(ctx, 400, export.ErrUnsupportedFormat)
+		return
+	}
+
+	ctx.Header("Content-Type", format.ContentType())
+	ctx.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", format.FileName()))
+	ctx.Status(200)
+
+	if err := c.exportService.Export(ctx, user.ID, format, ctx.Writer); err != nil {
+		// the status line is already sent once the body has started, so the error can only be logged
+		if !ctx.Writer.Written() && errors.Is(err, service.ErrValidation) {
+			handleErr(ctx, 400, err)
+			return
+		}
+
+		log.Printf("failed to export goals: %v", err)
+		if !ctx.Writer.Written() {
+			handleErr(ctx, 500, internalErr)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (c *Controller) initFocusRoutes() {
+	focusGroup := c.router.Group("/focus")
+	focusGroup.Use(TelegramAuthMiddleware())
+	{
+		focusGroup.POST("", c.startFocus)
+		focusGroup.GET("/active", c.getActiveFocus)
+		focusGroup.POST("/:id/pause", c.pauseFocus)
+		focusGroup.POST("/:id/resume", c.resumeFocus)
+		focusGroup.POST("/:id/stop", c.stopFocus)
+	}
+}
+
+func (c *Controller) startFocus(ctx *gin.Context) {
+	internalErr := errors.New("failed to start focus session")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var focusDTO dto.StartFocusDTO
+	if err := ctx.ShouldBindJSON(&focusDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	session, err := c.focusService.Start(ctx, user.ID, &focusDTO)
+	if err != nil {
+		handleFocusErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, session)
+}
+
+func (c *Controller) getActiveFocus(ctx *gin.Context) {
+	internalErr := errors.New("failed to get focus session")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	session, err := c.focusService.Active(ctx, user.ID)
+	if err != nil {
+		handleFocusErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, session)
+}
+
+func (c *Controller) pauseFocus(ctx *gin.Context) {
+	c.changeFocus(ctx, errors.New("failed to pause focus session"), c.focusService.Pause)
+}
+
+func (c *Controller) resumeFocus(ctx *gin.Context) {
+	c.changeFocus(ctx, errors.New("failed to resume focus session"), c.focusService.Resume)
+}
+
+func (c *Controller) stopFocus(ctx *gin.Context) {
+	c.changeFocus(ctx, errors.New("failed to stop focus session"), c.focusService.Stop)
+}
+
+func (c *Controller) changeFocus(ctx *gin.Context, internalErr error, change func(ctx context.Context, userID, id string) (*model.FocusSession, error)) {
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	session, err := change(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleFocusErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, session)
+}
+
+func (c *Controller) getGoalFocus(ctx *gin.Context) {
+	internalErr := errors.New("failed to get focus totals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	totals, err := c.focusService.Totals(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleFocusErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, totals)
+}
+
+func handleFocusErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrFocusSessionNotFound):
+		handleErr(ctx, 404, storage.ErrFocusSessionNotFound)
+	case errors.Is(err, storage.ErrFocusSessionActive):
+		handleErr(ctx, 409, storage.ErrFocusSessionActive)
+	case errors.Is(err, storage.ErrChapterNotFound):
+		handleErr(ctx, 404, storage.ErrChapterNotFound)
+	case errors.Is(err, storage.ErrGoalNotFound):
+		handleErr(ctx, 404, storage.ErrGoalNotFound)
+	case errors.Is(err, service.ErrFocusState):
+		handleErr(ctx, 409, err)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
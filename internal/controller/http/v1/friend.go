@@ -0,0 +1,182 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (c *Controller) initFriendRoutes() {
+	friendGroup := c.router.Group("/friends")
+	friendGroup.Use(TelegramAuthMiddleware())
+	{
+		friendGroup.GET("", c.listFriends)
+		friendGroup.DELETE("/:id", c.removeFriend)
+		friendGroup.POST("/invites", c.createFriendInvite)
+		friendGroup.POST("/invites/:code/accept", c.acceptFriendInvite)
+	}
+
+	leaderboardGroup := c.router.Group("/leaderboard")
+	leaderboardGroup.Use(TelegramAuthMiddleware())
+	{
+		leaderboardGroup.GET("", c.getLeaderboard)
+	}
+
+	privacyGroup := c.router.Group("/privacy")
+	privacyGroup.Use(TelegramAuthMiddleware())
+	{
+		privacyGroup.GET("", c.getPrivacy)
+		privacyGroup.PUT("", c.updatePrivacy)
+	}
+}
+
+func (c *Controller) listFriends(ctx *gin.Context) {
+	internalErr := errors.New("failed to get friends")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	friends, err := c.friendService.List(ctx, user.ID)
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, friends)
+}
+
+func (c *Controller) removeFriend(ctx *gin.Context) {
+	internalErr := errors.New("failed to remove friend")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.friendService.Remove(ctx, user.ID, ctx.Param("id")); err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "friend removed"})
+}
+
+func (c *Controller) createFriendInvite(ctx *gin.Context) {
+	internalErr := errors.New("failed to create invite")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	invite, err := c.friendService.CreateInvite(ctx, user.ID)
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, invite)
+}
+
+func (c *Controller) acceptFriendInvite(ctx *gin.Context) {
+	internalErr := errors.New("failed to accept invite")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	friend, err := c.friendService.AcceptInvite(ctx, user.ID, ctx.Param("code"))
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, friend)
+}
+
+// getLeaderboard accepts period=week|month and metric=xp|chapters, the weekly XP ranking is the default
+func (c *Controller) getLeaderboard(ctx *gin.Context) {
+	internalErr := errors.New("failed to get leaderboard")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	leaderboard, err := c.friendService.Leaderboard(ctx, user.ID, ctx.Query("period"), ctx.Query("metric"))
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, leaderboard)
+}
+
+func (c *Controller) getPrivacy(ctx *gin.Context) {
+	internalErr := errors.New("failed to get privacy settings")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	settings, err := c.friendService.GetPrivacy(ctx, user.ID)
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, settings)
+}
+
+func (c *Controller) updatePrivacy(ctx *gin.Context) {
+	internalErr := errors.New("failed to update privacy settings")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var privacyDTO dto.UpdatePrivacyDTO
+	if err := ctx.ShouldBindJSON(&privacyDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	settings, err := c.friendService.UpdatePrivacy(ctx, user.ID, &privacyDTO)
+	if err != nil {
+		handleFriendErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, settings)
+}
+
+func handleFriendErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrInviteNotFound):
+		handleErr(ctx, 404, storage.ErrInviteNotFound)
+	case errors.Is(err, storage.ErrFriendNotFound):
+		handleErr(ctx, 404, storage.ErrFriendNotFound)
+	case errors.Is(err, storage.ErrAlreadyFriends):
+		handleErr(ctx, 409, storage.ErrAlreadyFriends)
+	case errors.Is(err, service.ErrSelfInvite):
+		handleErr(ctx, 400, service.ErrSelfInvite)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
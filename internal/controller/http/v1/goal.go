@@ -1,29 +1,342 @@
 package v1
 
 import (
+	"encoding/json"
 	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+	"strconv"
+	"strings"
 )
 
 func (c *Controller) initGoalRoutes() {
-	goalGroup := c.router.Group("/goal")
+	goalGroup := c.router.Group("/goals")
 	{
-		goalGroup.POST("", c.createGoal)
+		goalGroup.POST("", TelegramAuthMiddleware(), c.createGoal)
+		goalGroup.GET("", TelegramAuthMiddleware(), c.getGoals)
+		goalGroup.POST("/archive", TelegramAuthMiddleware(), c.archiveGoals)
+		goalGroup.POST("/unarchive", TelegramAuthMiddleware(), c.unarchiveGoals)
+		goalGroup.POST("/from-template/:id", TelegramAuthMiddleware(), c.createGoalFromTemplate)
+		goalGroup.PUT("/:id", TelegramAuthMiddleware(), c.updateGoal)
+		goalGroup.PATCH("/:id", TelegramAuthMiddleware(), c.patchGoal)
+		goalGroup.DELETE("/:id", TelegramAuthMiddleware(), c.deleteGoal)
+		goalGroup.GET("/:id/tree", TelegramAuthMiddleware(), c.getGoalTree)
+		goalGroup.PATCH("/:id/parent", TelegramAuthMiddleware(), c.moveGoal)
+		goalGroup.GET("/:id/critical-path", TelegramAuthMiddleware(), c.getCriticalPath)
+		goalGroup.GET("/:id/history", TelegramAuthMiddleware(), c.getGoalHistory)
+		goalGroup.GET("/:id/revisions", TelegramAuthMiddleware(), c.getRevisions)
+		goalGroup.GET("/:id/revisions/diff", TelegramAuthMiddleware(), c.diffRevisions)
+		goalGroup.GET("/:id/revisions/:number", TelegramAuthMiddleware(), c.getRevision)
+		goalGroup.POST("/:id/revisions/:number/restore", TelegramAuthMiddleware(), c.restoreRevision)
+		goalGroup.GET("/:id/focus", TelegramAuthMiddleware(), c.getGoalFocus)
+
+		goalGroup.POST("/:id/chapters", TelegramAuthMiddleware(), c.createChapter)
+		goalGroup.GET("/:id/chapters", TelegramAuthMiddleware(), c.getChapters)
+		goalGroup.PATCH("/:id/chapters/order", TelegramAuthMiddleware(), c.reorderChapter)
+		goalGroup.PUT("/:id/chapters/:chapterID/dependencies", TelegramAuthMiddleware(), c.setChapterDependencies)
+		goalGroup.POST("/:id/chapters/:chapterID/complete", TelegramAuthMiddleware(), c.completeChapter)
+		goalGroup.PATCH("/:id/chapters/:chapterID", TelegramAuthMiddleware(), c.patchChapter)
+		goalGroup.DELETE("/:id/chapters/:chapterID", TelegramAuthMiddleware(), c.deleteChapter)
+
+		goalGroup.POST("/:id/comments", TelegramAuthMiddleware(), c.createComment)
+		goalGroup.DELETE("/:id/comments/:commentID", TelegramAuthMiddleware(), c.deleteComment)
+
+		goalGroup.POST("/:id/key-results", TelegramAuthMiddleware(), c.createKeyResult)
+		goalGroup.GET("/:id/key-results", TelegramAuthMiddleware(), c.getKeyResults)
+		goalGroup.DELETE("/:id/key-results/:keyResultID", TelegramAuthMiddleware(), c.deleteKeyResult)
+		goalGroup.POST("/:id/key-results/:keyResultID/check-ins", TelegramAuthMiddleware(), c.checkInKeyResult)
+		goalGroup.GET("/:id/key-results/:keyResultID/check-ins", TelegramAuthMiddleware(), c.getCheckIns)
 	}
 }
 
 func (c *Controller) createGoal(ctx *gin.Context) {
 	internalErr := errors.New("failed to create goal")
 
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
 	var goalDTO dto.CreateGoalDTO
 	if err := ctx.ShouldBindJSON(&goalDTO); err != nil {
 		handleErr(ctx, 400, internalErr)
+		return
 	}
 
-	if err := c.goalService.Create(ctx, &goalDTO); err != nil {
-		handleErr(ctx, 500, internalErr)
+	if err := c.goalService.Create(ctx, user.ID, &goalDTO); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
 	}
 
 	ctx.JSON(200, gin.H{"message": "goal created"})
 }
+
+// getGoals lists the goals of the user, ?archived=include|only adds archived goals, ?q= searches them
+// and ?filter= narrows them with a filter expression such as tag:work due<30d
+func (c *Controller) getGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to get goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	filter := model.GoalFilter{Archived: ctx.Query("archived"), Query: ctx.Query("q"), Filter: ctx.Query("filter")}
+
+	goals, err := c.goalService.List(ctx, user.ID, filter)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goals)
+}
+
+func (c *Controller) archiveGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to archive goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var archiveDTO dto.ArchiveGoalsDTO
+	if err := ctx.ShouldBindJSON(&archiveDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	goals, err := c.goalService.Archive(ctx, user.ID, archiveDTO.IDs)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goals)
+}
+
+func (c *Controller) unarchiveGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to unarchive goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var archiveDTO dto.ArchiveGoalsDTO
+	if err := ctx.ShouldBindJSON(&archiveDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	goals, err := c.goalService.Unarchive(ctx, user.ID, archiveDTO.IDs)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goals)
+}
+
+func (c *Controller) updateGoal(ctx *gin.Context) {
+	internalErr := errors.New("failed to update goal")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var updateDTO dto.UpdateGoalDTO
+	if err := ctx.ShouldBindJSON(&updateDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		handleErr(ctx, 412, service.ErrPreconditionFailed)
+		return
+	}
+	updateDTO.Version = version
+
+	goal, err := c.goalService.Update(ctx, user.ID, ctx.Param("id"), &updateDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", goal.ETag())
+	ctx.JSON(200, goal)
+}
+
+func (c *Controller) deleteGoal(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete goal")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.goalService.Delete(ctx, user.ID, ctx.Param("id")); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "goal moved to trash"})
+}
+
+// patchGoal applies the JSON merge patch in the body, If-Match makes the update conditional
+func (c *Controller) patchGoal(ctx *gin.Context) {
+	internalErr := errors.New("failed to update goal")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	patchDTO, ok := bindMergePatch(ctx)
+	if !ok {
+		return
+	}
+
+	goal, err := c.goalService.PatchGoal(ctx, user.ID, ctx.Param("id"), patchDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", goal.ETag())
+	ctx.JSON(200, goal)
+}
+
+func (c *Controller) getGoalTree(ctx *gin.Context) {
+	internalErr := errors.New("failed to get goal tree")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	depth := service.DefaultTreeDepth
+	if rawDepth := ctx.Query("depth"); rawDepth != "" {
+		depth, err = strconv.Atoi(rawDepth)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("depth must be an integer"))
+			return
+		}
+	}
+
+	tree, err := c.goalService.GetTree(ctx, user.ID, ctx.Param("id"), depth)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.Header("ETag", tree.ETag())
+	ctx.JSON(200, tree)
+}
+
+func (c *Controller) moveGoal(ctx *gin.Context) {
+	internalErr := errors.New("failed to move goal")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var moveDTO dto.MoveGoalDTO
+	if err := ctx.ShouldBindJSON(&moveDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	if err := c.goalService.Move(ctx, user.ID, ctx.Param("id"), &moveDTO); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "goal moved"})
+}
+
+// handleGoalErr maps goal domain errors to HTTP status codes and hides everything else behind internalErr
+func handleGoalErr(ctx *gin.Context, err, internalErr error) {
+	var fieldErrs service.FieldErrors
+	if errors.As(err, &fieldErrs) {
+		ctx.JSON(400, gin.H{"error": service.ErrValidation.Error(), "fields": fieldErrs})
+		return
+	}
+
+	code, err := goalErrResponse(err, internalErr)
+	handleErr(ctx, code, err)
+}
+
+// goalErrResponse maps a goal service error to the status code and the error shown to the client
+func goalErrResponse(err, internalErr error) (int, error) {
+	switch {
+	case errors.Is(err, storage.ErrGoalNotFound), errors.Is(err, storage.ErrChapterNotFound), errors.Is(err, storage.ErrKeyResultNotFound),
+		errors.Is(err, storage.ErrGoalRevisionNotFound), errors.Is(err, storage.ErrCommentNotFound):
+		return 404, err
+	case errors.Is(err, storage.ErrGoalCycle):
+		return 409, storage.ErrGoalCycle
+	case errors.Is(err, service.ErrPreconditionFailed), errors.Is(err, storage.ErrVersionConflict):
+		return 412, service.ErrPreconditionFailed
+	case errors.Is(err, service.ErrParentTrashed):
+		return 409, service.ErrParentTrashed
+	case errors.Is(err, service.ErrUnmetDependencies):
+		return 409, service.ErrUnmetDependencies
+	case errors.Is(err, service.ErrForeignParentGoal):
+		return 403, service.ErrForeignParentGoal
+	case errors.Is(err, service.ErrGoalNotOKR), errors.Is(err, service.ErrValidation):
+		return 400, err
+	default:
+		return 500, internalErr
+	}
+}
+
+// ifMatchVersion returns the version named by the If-Match header, 0 when the header is missing or "*".
+// ok is false for anything else, such as weak or several tags, which can never match.
+func ifMatchVersion(ctx *gin.Context) (version int, ok bool) {
+	header := strings.TrimSpace(ctx.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return 0, true
+	}
+
+	return model.ParseVersionETag(header)
+}
+
+// bindMergePatch reads an RFC 7396 merge patch and the If-Match version, writing the error response on failure.
+// Patches must be JSON objects, replacing a whole goal or chapter is not supported.
+func bindMergePatch(ctx *gin.Context) (*dto.PatchDTO, bool) {
+	switch ctx.ContentType() {
+	case "application/merge-patch+json", "application/json":
+	default:
+		handleErr(ctx, 415, errors.New("content type must be application/merge-patch+json"))
+		return nil, false
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.NewDecoder(ctx.Request.Body).Decode(&fields); err != nil || fields == nil {
+		handleErr(ctx, 400, errors.New("patch must be a JSON object"))
+		return nil, false
+	}
+
+	version, ok := ifMatchVersion(ctx)
+	if !ok {
+		handleErr(ctx, 412, service.ErrPreconditionFailed)
+		return nil, false
+	}
+
+	return &dto.PatchDTO{Fields: fields, Version: version}, true
+}
@@ -0,0 +1,46 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/service"
+	"strconv"
+	"time"
+)
+
+func (c *Controller) getGoalHistory(ctx *gin.Context) {
+	internalErr := errors.New("failed to get goal history")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	limit := service.DefaultHistoryLimit
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("limit must be an integer"))
+			return
+		}
+	}
+
+	// before pages through the history, clients pass the created_at of the last entry they received
+	var before time.Time
+	if rawBefore := ctx.Query("before"); rawBefore != "" {
+		before, err = time.Parse(time.RFC3339Nano, rawBefore)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("before must be an RFC 3339 timestamp"))
+			return
+		}
+	}
+
+	entries, err := c.auditService.GetGoalHistory(ctx, user.ID, ctx.Param("id"), before, limit)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, entries)
+}
@@ -0,0 +1,96 @@
+package v1
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"io"
+	"net/http"
+)
+
+const (
+	// IdempotencyKeyHeader lets clients retry a mutating request without applying it twice
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed for a retried request
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// idempotencyRecorder keeps a copy of the response body so it can be stored for replay
+type idempotencyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *idempotencyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotencyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// idempotency replays the stored response when a mutating request is retried with the same Idempotency-Key.
+// Keys are scoped to the Authorization header and bound to the method, URL and body of the first request.
+func (c *Controller) idempotency() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		key := ctx.GetHeader(IdempotencyKeyHeader)
+		if key == "" {
+			ctx.Next()
+			return
+		}
+
+		switch ctx.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		default:
+			ctx.Next()
+			return
+		}
+
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, gin.H{"error": "request body is too large"})
+			return
+		}
+		ctx.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := model.IdempotencyHash([]byte(ctx.GetHeader("Authorization")))
+		fingerprint := model.IdempotencyHash([]byte(ctx.Request.Method), []byte(ctx.Request.URL.RequestURI()), body)
+
+		record, err := c.idempotencyService.Begin(ctx, scope, key, fingerprint)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrIdempotencyKeyReused):
+				handleErr(ctx, http.StatusUnprocessableEntity, err)
+			case errors.Is(err, service.ErrIdempotencyKeyInProgress):
+				handleErr(ctx, http.StatusConflict, err)
+			case errors.Is(err, service.ErrValidation):
+				handleErr(ctx, http.StatusBadRequest, err)
+			default:
+				handleErr(ctx, http.StatusInternalServerError, errors.New("failed to check idempotency key"))
+			}
+			ctx.Abort()
+			return
+		}
+
+		if record != nil {
+			ctx.Header(IdempotentReplayedHeader, "true")
+			ctx.Data(record.StatusCode, record.ContentType, record.Body)
+			ctx.Abort()
+			return
+		}
+
+		recorder := &idempotencyRecorder{ResponseWriter: ctx.Writer}
+		ctx.Writer = recorder
+		ctx.Next()
+
+		// the response is stored even if the client has gone away, that is when it retries. A failure is
+		// logged by the service and only means a retry is rejected as in progress until the key expires.
+		storeCtx := context.WithoutCancel(ctx.Request.Context())
+		c.idempotencyService.Complete(storeCtx, scope, key, recorder.Status(), recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	}
+}
@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/service"
+	"net/http"
+)
+
+// maxImportSize limits the size of an uploaded import file
+const maxImportSize = 10 << 20
+
+func (c *Controller) initImportRoutes() {
+	c.router.POST("/import", TelegramAuthMiddleware(), c.importGoals)
+}
+
+// importGoals reads the file from the request body, ?source= selects strive, markdown, trello or todoist
+func (c *Controller) importGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to import goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var importDTO dto.ImportDTO
+	if err := ctx.ShouldBindQuery(&importDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	body := http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxImportSize)
+
+	report, err := c.importService.Import(ctx, user.ID, &importDTO, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidImport):
+			ctx.JSON(422, report)
+		case errors.Is(err, service.ErrValidation):
+			handleErr(ctx, 400, err)
+		default:
+			handleErr(ctx, 500, internalErr)
+		}
+		return
+	}
+
+	if report.Committed {
+		ctx.JSON(201, report)
+		return
+	}
+
+	ctx.JSON(200, report)
+}
@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+)
+
+func (c *Controller) createKeyResult(ctx *gin.Context) {
+	internalErr := errors.New("failed to create key result")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var keyResultDTO dto.CreateKeyResultDTO
+	if err := ctx.ShouldBindJSON(&keyResultDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	keyResult, err := c.goalService.CreateKeyResult(ctx, user.ID, ctx.Param("id"), &keyResultDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, keyResult)
+}
+
+func (c *Controller) getKeyResults(ctx *gin.Context) {
+	internalErr := errors.New("failed to get key results")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	keyResults, err := c.goalService.GetKeyResults(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, keyResults)
+}
+
+func (c *Controller) deleteKeyResult(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete key result")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.goalService.DeleteKeyResult(ctx, user.ID, ctx.Param("id"), ctx.Param("keyResultID")); err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "key result deleted"})
+}
+
+func (c *Controller) checkInKeyResult(ctx *gin.Context) {
+	internalErr := errors.New("failed to check in key result")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var checkInDTO dto.CheckInKeyResultDTO
+	if err := ctx.ShouldBindJSON(&checkInDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	keyResult, err := c.goalService.CheckIn(ctx, user.ID, ctx.Param("id"), ctx.Param("keyResultID"), &checkInDTO)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, keyResult)
+}
+
+func (c *Controller) getCheckIns(ctx *gin.Context) {
+	internalErr := errors.New("failed to get check-ins")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	checkIns, err := c.goalService.GetCheckIns(ctx, user.ID, ctx.Param("id"), ctx.Param("keyResultID"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, checkIns)
+}
@@ -3,14 +3,23 @@ package v1
 import (
 	"errors"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
 	"golang.org/x/time/rate"
 	"net/http"
+	"strconv"
 	"strings"
 )
 
 // TelegramIDKey is a key to store telegram_id in the context
 const TelegramIDKey = "telegram_id"
 
+// RequestIDHeader carries the request ID, a new one is generated when the client does not send it
+const RequestIDHeader = "X-Request-ID"
+
+const maxRequestIDLength = 128
+
 var (
 	ErrMissingAuthHeader = errors.New("authorization header is required")
 	ErrInvalidAuthHeader = errors.New("invalid authorization header format")
@@ -62,10 +71,12 @@ func RateLimiter(rps int, burst int) gin.HandlerFunc {
 func CORSProtection() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
 		ctx.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		ctx.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		ctx.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-Request-ID, Idempotency-Key")
+		ctx.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-ID, Idempotent-Replayed")
 
-		if ctx.Request.Method == http.MethodOptions {
+		// CalDAV clients use OPTIONS to discover DAV capabilities, so it is left to the CalDAV handler
+		if ctx.Request.Method == http.MethodOptions && !strings.HasPrefix(ctx.Request.URL.Path, calDAVPrefix+"/") {
 			ctx.AbortWithStatus(http.StatusNoContent)
 			return
 		}
@@ -89,6 +100,43 @@ func RequestSizeLimiter(maxBytes int64) gin.HandlerFunc {
 	}
 }
 
+// auditContext stores the request ID and the acting user in the request context for the audit log.
+// The actor is resolved only for mutating requests that carry an Authorization header.
+func (c *Controller) auditContext() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		requestID := ctx.GetHeader(RequestIDHeader)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+		ctx.Header(RequestIDHeader, requestID)
+
+		requestCtx := service.WithRequestID(ctx.Request.Context(), requestID)
+		requestCtx = service.WithActor(requestCtx, c.actorID(ctx))
+
+		ctx.Request = ctx.Request.WithContext(requestCtx)
+		ctx.Next()
+	}
+}
+
+func (c *Controller) actorID(ctx *gin.Context) string {
+	switch ctx.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.ActorAnonymous
+	}
+
+	telegramID, err := strconv.ParseInt(strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer "), 10, 64)
+	if err != nil {
+		return model.ActorAnonymous
+	}
+
+	user, err := c.userService.GetByTelegramID(ctx.Request.Context(), telegramID)
+	if err != nil {
+		return model.ActorAnonymous
+	}
+
+	return user.ID
+}
+
 func applyMiddlewares(router *gin.Engine) {
 	router.Use(RateLimiter(10, 20)) // 10 requests per second with a burst of 20
 	router.Use(CORSProtection())
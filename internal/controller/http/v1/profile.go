@@ -0,0 +1,32 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Controller) initProfileRoutes() {
+	profileGroup := c.router.Group("/profile")
+	profileGroup.Use(TelegramAuthMiddleware())
+	{
+		profileGroup.GET("", c.getProfile)
+	}
+}
+
+func (c *Controller) getProfile(ctx *gin.Context) {
+	internalErr := errors.New("failed to get profile")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	profile, err := c.gamificationService.Profile(ctx, user.ID)
+	if err != nil {
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, profile)
+}
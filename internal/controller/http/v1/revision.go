@@ -0,0 +1,127 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/service"
+	"strconv"
+)
+
+func (c *Controller) getRevisions(ctx *gin.Context) {
+	internalErr := errors.New("failed to get goal revisions")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	limit := service.DefaultHistoryLimit
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("limit must be an integer"))
+			return
+		}
+	}
+
+	// before pages through the revisions, clients pass the number of the last revision they received
+	var before int
+	if rawBefore := ctx.Query("before"); rawBefore != "" {
+		before, err = strconv.Atoi(rawBefore)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("before must be an integer"))
+			return
+		}
+	}
+
+	revisions, err := c.revisionService.List(ctx, user.ID, ctx.Param("id"), before, limit)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, revisions)
+}
+
+func (c *Controller) getRevision(ctx *gin.Context) {
+	internalErr := errors.New("failed to get goal revision")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	number, err := strconv.Atoi(ctx.Param("number"))
+	if err != nil {
+		handleErr(ctx, 400, errors.New("revision number must be an integer"))
+		return
+	}
+
+	revision, err := c.revisionService.Get(ctx, user.ID, ctx.Param("id"), number)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, revision)
+}
+
+// diffRevisions compares the revisions ?from= and ?to= field by field
+func (c *Controller) diffRevisions(ctx *gin.Context) {
+	internalErr := errors.New("failed to diff goal revisions")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	from, err := strconv.Atoi(ctx.Query("from"))
+	if err != nil {
+		handleErr(ctx, 400, errors.New("from must be a revision number"))
+		return
+	}
+
+	to, err := strconv.Atoi(ctx.Query("to"))
+	if err != nil {
+		handleErr(ctx, 400, errors.New("to must be a revision number"))
+		return
+	}
+
+	diff, err := c.revisionService.Diff(ctx, user.ID, ctx.Param("id"), from, to)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, diff)
+}
+
+// restoreRevision restores the goal fields of the revision, ?chapters=true restores its chapters too
+func (c *Controller) restoreRevision(ctx *gin.Context) {
+	internalErr := errors.New("failed to restore goal revision")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	number, err := strconv.Atoi(ctx.Param("number"))
+	if err != nil {
+		handleErr(ctx, 400, errors.New("revision number must be an integer"))
+		return
+	}
+
+	withChapters := ctx.Query("chapters") == "true"
+
+	goal, err := c.goalService.RestoreRevision(ctx, user.ID, ctx.Param("id"), number, withChapters)
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goal)
+}
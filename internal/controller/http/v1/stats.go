@@ -0,0 +1,49 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/service"
+	"strconv"
+)
+
+func (c *Controller) initStatsRoutes() {
+	statsGroup := c.router.Group("/stats")
+	statsGroup.Use(TelegramAuthMiddleware())
+	{
+		statsGroup.GET("", c.getStats)
+	}
+}
+
+func (c *Controller) getStats(ctx *gin.Context) {
+	internalErr := errors.New("failed to get stats")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var weeks int
+	if rawWeeks := ctx.Query("weeks"); rawWeeks != "" {
+		weeks, err = strconv.Atoi(rawWeeks)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("weeks must be an integer"))
+			return
+		}
+	}
+
+	// tz is an IANA time zone such as Europe/Berlin, weekdays and hours are counted in it
+	stats, err := c.statsService.Get(ctx, user.ID, weeks, ctx.Query("tz"))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrValidation):
+			handleErr(ctx, 400, err)
+		default:
+			handleErr(ctx, 500, internalErr)
+		}
+		return
+	}
+
+	ctx.JSON(200, stats)
+}
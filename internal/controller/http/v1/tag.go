@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+	"strconv"
+)
+
+func (c *Controller) initTagRoutes() {
+	tagGroup := c.router.Group("/tags")
+	tagGroup.Use(TelegramAuthMiddleware())
+	{
+		tagGroup.GET("", c.listTags)
+		tagGroup.POST("", c.createTag)
+		tagGroup.PUT("/:id", c.updateTag)
+		tagGroup.POST("/:id/merge", c.mergeTag)
+	}
+}
+
+// listTags returns every tag of the user, or autocompletes ?prefix with up to ?limit suggestions
+func (c *Controller) listTags(ctx *gin.Context) {
+	internalErr := errors.New("failed to get tags")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	limit := model.DefaultTagSuggestions
+	if rawLimit := ctx.Query("limit"); rawLimit != "" {
+		limit, err = strconv.Atoi(rawLimit)
+		if err != nil {
+			handleErr(ctx, 400, errors.New("limit must be an integer"))
+			return
+		}
+	}
+
+	tags, err := c.tagService.List(ctx, user.ID, ctx.Query("prefix"), limit)
+	if err != nil {
+		handleTagErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, tags)
+}
+
+func (c *Controller) createTag(ctx *gin.Context) {
+	internalErr := errors.New("failed to create tag")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var tagDTO dto.CreateTagDTO
+	if err := ctx.ShouldBindJSON(&tagDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	tag, err := c.tagService.Create(ctx, user.ID, &tagDTO)
+	if err != nil {
+		handleTagErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, tag)
+}
+
+func (c *Controller) updateTag(ctx *gin.Context) {
+	internalErr := errors.New("failed to update tag")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var tagDTO dto.UpdateTagDTO
+	if err := ctx.ShouldBindJSON(&tagDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	tag, err := c.tagService.Update(ctx, user.ID, ctx.Param("id"), &tagDTO)
+	if err != nil {
+		handleTagErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, tag)
+}
+
+func (c *Controller) mergeTag(ctx *gin.Context) {
+	internalErr := errors.New("failed to merge tag")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var mergeDTO dto.MergeTagDTO
+	if err := ctx.ShouldBindJSON(&mergeDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	tag, err := c.tagService.Merge(ctx, user.ID, ctx.Param("id"), &mergeDTO)
+	if err != nil {
+		handleTagErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, tag)
+}
+
+func handleTagErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrTagNotFound):
+		handleErr(ctx, 404, storage.ErrTagNotFound)
+	case errors.Is(err, storage.ErrTagExists):
+		handleErr(ctx, 409, storage.ErrTagExists)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
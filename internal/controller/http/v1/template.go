@@ -0,0 +1,136 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (c *Controller) initTemplateRoutes() {
+	templateGroup := c.router.Group("/templates")
+	templateGroup.Use(TelegramAuthMiddleware())
+	{
+		templateGroup.GET("", c.listTemplates)
+		templateGroup.GET("/:id", c.getTemplate)
+		templateGroup.POST("", c.createTemplate)
+		templateGroup.DELETE("/:id", c.deleteTemplate)
+	}
+}
+
+func (c *Controller) listTemplates(ctx *gin.Context) {
+	internalErr := errors.New("failed to get templates")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	templates, err := c.templateService.List(ctx, user.ID)
+	if err != nil {
+		handleTemplateErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, templates)
+}
+
+func (c *Controller) getTemplate(ctx *gin.Context) {
+	internalErr := errors.New("failed to get template")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	template, err := c.templateService.Get(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleTemplateErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, template)
+}
+
+func (c *Controller) createTemplate(ctx *gin.Context) {
+	internalErr := errors.New("failed to create template")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var templateDTO dto.CreateTemplateDTO
+	if err := ctx.ShouldBindJSON(&templateDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	template, err := c.templateService.Create(ctx, user.ID, &templateDTO)
+	if err != nil {
+		handleTemplateErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, template)
+}
+
+func (c *Controller) deleteTemplate(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete template")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.templateService.Delete(ctx, user.ID, ctx.Param("id")); err != nil {
+		handleTemplateErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "template deleted"})
+}
+
+func (c *Controller) createGoalFromTemplate(ctx *gin.Context) {
+	internalErr := errors.New("failed to create goal from template")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var instantiateDTO dto.InstantiateTemplateDTO
+	if ctx.Request.ContentLength != 0 {
+		if err := ctx.ShouldBindJSON(&instantiateDTO); err != nil {
+			handleErr(ctx, 400, internalErr)
+			return
+		}
+	}
+
+	goal, err := c.templateService.Instantiate(ctx, user.ID, ctx.Param("id"), &instantiateDTO)
+	if err != nil {
+		handleTemplateErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, goal)
+}
+
+func handleTemplateErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrTemplateNotFound):
+		handleErr(ctx, 404, storage.ErrTemplateNotFound)
+	case errors.Is(err, service.ErrForbidden):
+		handleErr(ctx, 403, service.ErrForbidden)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
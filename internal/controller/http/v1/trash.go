@@ -0,0 +1,89 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+)
+
+func (c *Controller) initTrashRoutes() {
+	trashGroup := c.router.Group("/trash")
+	trashGroup.Use(TelegramAuthMiddleware())
+	{
+		trashGroup.GET("", c.getTrash)
+		trashGroup.POST("/goals/:id/restore", c.restoreGoal)
+		trashGroup.POST("/chapters/:id/restore", c.restoreChapter)
+		trashGroup.POST("/comments/:id/restore", c.restoreComment)
+	}
+}
+
+func (c *Controller) getTrash(ctx *gin.Context) {
+	internalErr := errors.New("failed to get trash")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	items, err := c.trashService.List(ctx, user.ID)
+	if err != nil {
+		handleErr(ctx, 500, internalErr)
+		return
+	}
+
+	ctx.JSON(200, items)
+}
+
+func (c *Controller) restoreGoal(ctx *gin.Context) {
+	internalErr := errors.New("failed to restore goal")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	goal, err := c.goalService.RestoreGoal(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goal)
+}
+
+func (c *Controller) restoreChapter(ctx *gin.Context) {
+	internalErr := errors.New("failed to restore chapter")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	chapter, err := c.goalService.RestoreChapter(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, chapter)
+}
+
+func (c *Controller) restoreComment(ctx *gin.Context) {
+	internalErr := errors.New("failed to restore comment")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	comment, err := c.goalService.RestoreComment(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleGoalErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, comment)
+}
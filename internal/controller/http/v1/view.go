@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (c *Controller) initViewRoutes() {
+	viewGroup := c.router.Group("/views")
+	viewGroup.Use(TelegramAuthMiddleware())
+	{
+		viewGroup.GET("", c.listViews)
+		viewGroup.POST("", c.createView)
+		viewGroup.PUT("/:id", c.updateView)
+		viewGroup.DELETE("/:id", c.deleteView)
+		viewGroup.GET("/:id/goals", c.getViewGoals)
+	}
+}
+
+func (c *Controller) listViews(ctx *gin.Context) {
+	internalErr := errors.New("failed to get views")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	views, err := c.viewService.List(ctx, user.ID)
+	if err != nil {
+		handleViewErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, views)
+}
+
+func (c *Controller) createView(ctx *gin.Context) {
+	internalErr := errors.New("failed to create view")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var viewDTO dto.SaveViewDTO
+	if err := ctx.ShouldBindJSON(&viewDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	view, err := c.viewService.Create(ctx, user.ID, &viewDTO)
+	if err != nil {
+		handleViewErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(201, view)
+}
+
+func (c *Controller) updateView(ctx *gin.Context) {
+	internalErr := errors.New("failed to update view")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var viewDTO dto.SaveViewDTO
+	if err := ctx.ShouldBindJSON(&viewDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	view, err := c.viewService.Update(ctx, user.ID, ctx.Param("id"), &viewDTO)
+	if err != nil {
+		handleViewErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, view)
+}
+
+func (c *Controller) deleteView(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete view")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.viewService.Delete(ctx, user.ID, ctx.Param("id")); err != nil {
+		handleViewErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "view deleted"})
+}
+
+func (c *Controller) getViewGoals(ctx *gin.Context) {
+	internalErr := errors.New("failed to get view goals")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	goals, err := c.viewService.Goals(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleViewErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, goals)
+}
+
+func handleViewErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrViewNotFound):
+		handleErr(ctx, 404, storage.ErrViewNotFound)
+	case errors.Is(err, storage.ErrViewExists):
+		handleErr(ctx, 409, storage.ErrViewExists)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/service"
+	"github.com/nordew/Strive/internal/storage"
+)
+
+func (c *Controller) initWebhookRoutes() {
+	webhookGroup := c.router.Group("/webhooks")
+	webhookGroup.Use(TelegramAuthMiddleware())
+	{
+		webhookGroup.POST("", c.createWebhook)
+		webhookGroup.GET("", c.getWebhooks)
+		webhookGroup.DELETE("/:id", c.deleteWebhook)
+		webhookGroup.POST("/:id/enable", c.enableWebhook)
+		webhookGroup.GET("/:id/deliveries", c.getWebhookDeliveries)
+		webhookGroup.GET("/:id/deliveries/:deliveryID", c.getWebhookDelivery)
+		webhookGroup.POST("/:id/deliveries/:deliveryID/replay", c.replayWebhookDelivery)
+	}
+}
+
+func (c *Controller) createWebhook(ctx *gin.Context) {
+	internalErr := errors.New("failed to create webhook")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	var webhookDTO dto.CreateWebhookDTO
+	if err := ctx.ShouldBindJSON(&webhookDTO); err != nil {
+		handleErr(ctx, 400, internalErr)
+		return
+	}
+
+	webhook, err := c.webhookService.Create(ctx, user.ID, &webhookDTO)
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	// the secret is shown only once, it is needed to verify the signatures
+	ctx.JSON(201, struct {
+		*model.Webhook
+		Secret string `json:"secret"`
+	}{webhook, webhook.Secret})
+}
+
+func (c *Controller) getWebhooks(ctx *gin.Context) {
+	internalErr := errors.New("failed to get webhooks")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	webhooks, err := c.webhookService.List(ctx, user.ID)
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, webhooks)
+}
+
+func (c *Controller) deleteWebhook(ctx *gin.Context) {
+	internalErr := errors.New("failed to delete webhook")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	if err := c.webhookService.Delete(ctx, user.ID, ctx.Param("id")); err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, gin.H{"message": "webhook deleted"})
+}
+
+func (c *Controller) enableWebhook(ctx *gin.Context) {
+	internalErr := errors.New("failed to enable webhook")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	webhook, err := c.webhookService.Enable(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, webhook)
+}
+
+func (c *Controller) getWebhookDeliveries(ctx *gin.Context) {
+	internalErr := errors.New("failed to get webhook deliveries")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	deliveries, err := c.webhookService.ListDeliveries(ctx, user.ID, ctx.Param("id"))
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, deliveries)
+}
+
+func (c *Controller) getWebhookDelivery(ctx *gin.Context) {
+	internalErr := errors.New("failed to get webhook delivery")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	delivery, err := c.webhookService.GetDelivery(ctx, user.ID, ctx.Param("id"), ctx.Param("deliveryID"))
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(200, delivery)
+}
+
+func (c *Controller) replayWebhookDelivery(ctx *gin.Context) {
+	internalErr := errors.New("failed to replay webhook delivery")
+
+	user, err := c.currentUser(ctx)
+	if err != nil {
+		handleErr(ctx, 401, internalErr)
+		return
+	}
+
+	delivery, err := c.webhookService.Replay(ctx, user.ID, ctx.Param("id"), ctx.Param("deliveryID"))
+	if err != nil {
+		handleWebhookErr(ctx, err, internalErr)
+		return
+	}
+
+	ctx.JSON(202, delivery)
+}
+
+func handleWebhookErr(ctx *gin.Context, err, internalErr error) {
+	switch {
+	case errors.Is(err, storage.ErrWebhookNotFound), errors.Is(err, storage.ErrWebhookDeliveryNotFound):
+		handleErr(ctx, 404, err)
+	case errors.Is(err, service.ErrWebhookDisabled), errors.Is(err, service.ErrDeliveryNotReplayable):
+		handleErr(ctx, 409, err)
+	case errors.Is(err, service.ErrValidation):
+		handleErr(ctx, 400, err)
+	default:
+		handleErr(ctx, 500, internalErr)
+	}
+}
@@ -0,0 +1,22 @@
+package dto
+
+type (
+	// BatchDTO runs up to model.MaxBatchOperations operations in the given mode, best effort by default
+	BatchDTO struct {
+		Mode       string              `json:"mode"`
+		Operations []BatchOperationDTO `json:"operations" binding:"required"`
+	}
+
+	// BatchOperationDTO targets the chapter when ChapterID is set and the goal otherwise.
+	// complete and reopen only apply to chapters, retag only to goals.
+	BatchOperationDTO struct {
+		Op         string   `json:"op" binding:"required"`
+		GoalID     string   `json:"goal_id" binding:"required"`
+		ChapterID  string   `json:"chapter_id"`
+		Force      bool     `json:"force"`       // Force completes a chapter with open dependencies
+		AddTags    []string `json:"add_tags"`    // AddTags are added to the goal by retag
+		RemoveTags []string `json:"remove_tags"` // RemoveTags are removed from the goal by retag
+		ToGoalID   string   `json:"to_goal_id"`  // ToGoalID is the goal move_chapter moves the chapter to
+		Shift      string   `json:"shift"`       // Shift is the duration shift_deadline moves the deadline by, e.g. 3d or -12h
+	}
+)
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// CalDAVTaskDTO holds the fields of a VTODO a CalDAV client uploaded
+type CalDAVTaskDTO struct {
+	Title       string
+	Description string
+	Deadline    time.Time // Deadline is zero when the VTODO has no DUE
+	IsDone      bool
+}
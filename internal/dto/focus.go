@@ -0,0 +1,10 @@
+package dto
+
+type (
+	// StartFocusDTO starts a free session, or a pomodoro of Minutes when Mode is pomodoro
+	StartFocusDTO struct {
+		ChapterID string `json:"chapter_id" binding:"required"`
+		Mode      string `json:"mode"`
+		Minutes   int    `json:"minutes"`
+	}
+)
@@ -0,0 +1,7 @@
+package dto
+
+type (
+	UpdatePrivacyDTO struct {
+		HideFromLeaderboards *bool `json:"hide_from_leaderboards" binding:"required"`
+	}
+)
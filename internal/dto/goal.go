@@ -1,13 +1,92 @@
 package dto
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type (
 	CreateGoalDTO struct {
-		UserID      string    `json:"user_id"`
+		ParentID    string    `json:"parent_id"`
+		Type        string    `json:"type"`
 		Title       string    `json:"title"`
-		Description string    `json:"description omitempty"`
+		Description string    `json:"description"`
 		Tags        []string  `json:"tags"`
 		Deadline    time.Time `json:"deadline"`
 	}
+
+	// UpdateGoalDTO replaces the editable fields of a goal
+	UpdateGoalDTO struct {
+		Title       string    `json:"title" binding:"required"`
+		Description string    `json:"description" binding:"required"`
+		Deadline    time.Time `json:"deadline" binding:"required"`
+		Priority    int       `json:"priority"`
+		Tags        []string  `json:"tags"`
+		Version     int       `json:"-"` // Version is the version the client expects from If-Match, 0 updates any version
+	}
+
+	// CreateCommentDTO comments on the goal, or on one of its chapters when ChapterID is set
+	CreateCommentDTO struct {
+		ChapterID string `json:"chapter_id"`
+		Content   string `json:"content" binding:"required"`
+	}
+
+	// PatchDTO is an RFC 7396 merge patch keyed by JSON field name, fields set to null are kept as "null"
+	PatchDTO struct {
+		Fields  map[string]json.RawMessage
+		Version int // Version is the version the client expects from If-Match, 0 updates any version
+	}
+
+	// ArchiveGoalsDTO lists the goals to archive or unarchive
+	ArchiveGoalsDTO struct {
+		IDs []string `json:"ids" binding:"required"`
+	}
+
+	MoveGoalDTO struct {
+		ParentID string `json:"parent_id"`
+	}
+
+	CreateChapterDTO struct {
+		ID          string    `json:"-"` // ID is set by sync clients that choose the chapter ID, a new one is generated when empty
+		Title       string    `json:"title" binding:"required"`
+		Description string    `json:"description" binding:"required"`
+		Deadline    time.Time `json:"deadline" binding:"required"`
+		Priority    int       `json:"priority"`
+		DependsOn   []string  `json:"depends_on"`
+	}
+
+	// UpdateChapterDTO changes only the fields that are set
+	UpdateChapterDTO struct {
+		Title       *string    `json:"title"`
+		Description *string    `json:"description"`
+		Deadline    *time.Time `json:"deadline"`
+		Priority    *int       `json:"priority"`
+		IsDone      *bool      `json:"is_done"`
+		Version     int        `json:"-"` // Version is the version the client expects from If-Match, 0 updates any version
+	}
+
+	// MoveChapterDTO places a chapter right after AfterID or right before BeforeID.
+	// When both are empty the chapter is moved to the top.
+	MoveChapterDTO struct {
+		ChapterID string `json:"chapter_id" binding:"required"`
+		AfterID   string `json:"after_id"`
+		BeforeID  string `json:"before_id"`
+	}
+
+	SetChapterDependenciesDTO struct {
+		DependsOn []string `json:"depends_on"`
+	}
+
+	CreateKeyResultDTO struct {
+		Title        string  `json:"title" binding:"required"`
+		StartValue   float64 `json:"start_value"`
+		TargetValue  float64 `json:"target_value"`
+		CurrentValue float64 `json:"current_value"`
+		Unit         string  `json:"unit"`
+	}
+
+	CheckInKeyResultDTO struct {
+		Value float64 `json:"value"`
+		Note  string  `json:"note"`
+	}
 )
@@ -0,0 +1,11 @@
+package dto
+
+import "time"
+
+// ImportDTO holds the query options of an import. DefaultDeadline is used for goals and chapters
+// the source has no deadline for.
+type ImportDTO struct {
+	Source          string    `form:"source" binding:"required"`
+	DryRun          bool      `form:"dry_run"`
+	DefaultDeadline time.Time `form:"default_deadline" time_format:"2006-01-02"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+type (
+	CreateTagDTO struct {
+		Name  string `json:"name" binding:"required"`
+		Color string `json:"color"`
+		Emoji string `json:"emoji"`
+	}
+
+	// UpdateTagDTO changes only the fields that are set, an empty color or emoji removes it
+	UpdateTagDTO struct {
+		Name  *string `json:"name"`
+		Color *string `json:"color"`
+		Emoji *string `json:"emoji"`
+	}
+
+	// MergeTagDTO names the tag the merged one is folded into
+	MergeTagDTO struct {
+		IntoID string `json:"into_id" binding:"required"`
+	}
+)
@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type (
+	CreateTemplateDTO struct {
+		Title              string                `json:"title" binding:"required"`
+		Description        string                `json:"description" binding:"required"`
+		Tags               []string              `json:"tags"`
+		IsPublic           bool                  `json:"is_public"`
+		DeadlineOffsetDays int                   `json:"deadline_offset_days"`
+		Chapters           []ChapterBlueprintDTO `json:"chapters"`
+	}
+
+	ChapterBlueprintDTO struct {
+		Title              string `json:"title"`
+		Description        string `json:"description"`
+		DeadlineOffsetDays int    `json:"deadline_offset_days"`
+		Priority           int    `json:"priority"`
+	}
+
+	// InstantiateTemplateDTO holds the date the deadline offsets are counted from, now when empty
+	InstantiateTemplateDTO struct {
+		StartDate time.Time `json:"start_date"`
+	}
+)
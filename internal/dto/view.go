@@ -0,0 +1,7 @@
+package dto
+
+// SaveViewDTO holds the name and filter expression of a saved view, such as tag:work due<30d
+type SaveViewDTO struct {
+	Name   string `json:"name" binding:"required"`
+	Filter string `json:"filter" binding:"required"`
+}
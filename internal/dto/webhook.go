@@ -0,0 +1,6 @@
+package dto
+
+type CreateWebhookDTO struct {
+	URL    string   `json:"url" binding:"required"`
+	Events []string `json:"events" binding:"required"`
+}
@@ -0,0 +1,80 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+var csvHeader = []string{
+	"goal_id", "goal_title", "goal_type", "goal_is_done", "goal_deadline", "goal_priority", "goal_tags", "goal_progress",
+	"chapter_id", "chapter_title", "chapter_description", "chapter_is_done", "chapter_deadline", "chapter_priority", "chapter_depends_on",
+}
+
+// csvEncoder writes one row per chapter. Goals without chapters get a single row with empty chapter columns.
+type csvEncoder struct {
+	w *csv.Writer
+}
+
+func newCSVEncoder(w io.Writer) *csvEncoder {
+	return &csvEncoder{w: csv.NewWriter(w)}
+}
+
+func (e *csvEncoder) Begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvEncoder) Encode(goal *model.Goal) error {
+	goalColumns := []string{
+		goal.ID,
+		goal.Title,
+		goal.Type,
+		strconv.FormatBool(goal.IsDone),
+		formatTime(goal.Deadline),
+		strconv.Itoa(goal.Priority),
+		strings.Join(goal.Tags, ";"),
+		strconv.Itoa(goal.Progress),
+	}
+
+	if len(goal.Chapters) == 0 {
+		if err := e.w.Write(append(goalColumns, make([]string, 7)...)); err != nil {
+			return err
+		}
+	}
+
+	for _, chapter := range goal.Chapters {
+		row := append(append([]string{}, goalColumns...),
+			chapter.ID,
+			chapter.Title,
+			chapter.Description,
+			strconv.FormatBool(chapter.IsDone),
+			formatTime(chapter.Deadline),
+			strconv.Itoa(chapter.Priority),
+			strings.Join(chapter.DependsOn, ";"),
+		)
+
+		if err := e.w.Write(row); err != nil {
+			return err
+		}
+	}
+
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func (e *csvEncoder) End() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.UTC().Format(time.RFC3339)
+}
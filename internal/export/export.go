@@ -0,0 +1,65 @@
+package export
+
+import (
+	"errors"
+	"io"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+type Format string
+
+const (
+	FormatJSON     Format = "json"
+	FormatCSV      Format = "csv"
+	FormatMarkdown Format = "md"
+	FormatICS      Format = "ics"
+)
+
+var ErrUnsupportedFormat = errors.New("unsupported export format")
+
+// Encoder writes goals one at a time, so exports can be streamed without buffering them
+type Encoder interface {
+	// Begin writes the document header
+	Begin() error
+	Encode(goal *model.Goal) error
+	// End writes the document footer and flushes the underlying writer
+	End() error
+}
+
+// NewEncoder returns an encoder for the format. now is written as the export timestamp.
+func NewEncoder(format Format, w io.Writer, now time.Time) (Encoder, error) {
+	switch format {
+	case FormatJSON:
+		return newJSONEncoder(w, now), nil
+	case FormatCSV:
+		return newCSVEncoder(w), nil
+	case FormatMarkdown:
+		return newMarkdownEncoder(w, now), nil
+	case FormatICS:
+		return NewCalendarEncoder(w, now, ComponentTodo), nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
+
+func (f Format) ContentType() string {
+	switch f {
+	case FormatJSON:
+		return "application/json"
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatMarkdown:
+		return "text/markdown; charset=utf-8"
+	case FormatICS:
+		return "text/calendar; charset=utf-8"
+	default:
+		return "application/octet-stream"
+	}
+}
+
+// FileName returns the attachment name of an export in the format
+func (f Format) FileName() string {
+	return "strive-export." + string(f)
+}
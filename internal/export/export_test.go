@@ -0,0 +1,165 @@
+package export
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var exportedAt = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func fixtureGoals() []*model.Goal {
+	created := time.Date(2024, 1, 10, 8, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	return []*model.Goal{
+		{
+			ID:          "11111111-1111-4111-8111-111111111111",
+			UserID:      "99999999-9999-4999-8999-999999999999",
+			Type:        model.GoalTypeChapters,
+			Title:       "Run a marathon",
+			Description: "Build up mileage, stay healthy; finish under 4h, no walking.\nLong runs on Sundays, easy runs in between, one speed session per week and plenty of sleep.",
+			Progress:    50,
+			Deadline:    time.Date(2024, 10, 13, 9, 0, 0, 0, time.UTC),
+			Priority:    2,
+			Tags:        []string{"health", "running"},
+			Chapters: []model.Chapter{
+				{
+					ID:          "22222222-2222-4222-8222-222222222221",
+					GoalID:      "11111111-1111-4111-8111-111111111111",
+					Title:       "Base building",
+					Description: "30 km per week",
+					IsDone:      true,
+					Deadline:    time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+					Priority:    1,
+					Position:    "U",
+					CreatedAt:   created,
+					UpdatedAt:   updated,
+				},
+				{
+					ID:          "22222222-2222-4222-8222-222222222222",
+					GoalID:      "11111111-1111-4111-8111-111111111111",
+					Title:       "Half marathon, race \"rehearsal\"",
+					Description: "",
+					Deadline:    time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC),
+					Position:    "k",
+					DependsOn:   []string{"22222222-2222-4222-8222-222222222221"},
+					CreatedAt:   created,
+					UpdatedAt:   updated,
+				},
+			},
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+		{
+			ID:          "33333333-3333-4333-8333-333333333333",
+			UserID:      "99999999-9999-4999-8999-999999999999",
+			ParentID:    "11111111-1111-4111-8111-111111111111",
+			Type:        model.GoalTypeOKR,
+			Title:       "Grow savings",
+			Description: "Emergency fund",
+			Progress:    25,
+			KeyResults: []model.KeyResult{
+				{
+					ID:           "44444444-4444-4444-8444-444444444444",
+					GoalID:       "33333333-3333-4333-8333-333333333333",
+					Title:        "Savings",
+					StartValue:   1000,
+					TargetValue:  5000,
+					CurrentValue: 2000,
+					Unit:         "USD",
+					CreatedAt:    created,
+					UpdatedAt:    updated,
+				},
+			},
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	}
+}
+
+func TestEncoders(t *testing.T) {
+	for _, format := range []Format{FormatJSON, FormatCSV, FormatMarkdown, FormatICS} {
+		t.Run(string(format), func(t *testing.T) {
+			var buf bytes.Buffer
+
+			encoder, err := NewEncoder(format, &buf, exportedAt)
+			if err != nil {
+				t.Fatalf("NewEncoder: %v", err)
+			}
+
+			if err := encoder.Begin(); err != nil {
+				t.Fatalf("Begin: %v", err)
+			}
+			for _, goal := range fixtureGoals() {
+				if err := encoder.Encode(goal); err != nil {
+					t.Fatalf("Encode: %v", err)
+				}
+			}
+			if err := encoder.End(); err != nil {
+				t.Fatalf("End: %v", err)
+			}
+
+			assertGolden(t, "goals."+string(format)+".golden", buf.Bytes())
+		})
+	}
+}
+
+func TestCalendarEncoderEvents(t *testing.T) {
+	var buf bytes.Buffer
+
+	encoder := NewCalendarEncoder(&buf, exportedAt, ComponentEvent)
+	if err := encoder.Begin(); err != nil {
+		t.Fatalf("Begin: %v", err)
+	}
+	for _, goal := range fixtureGoals() {
+		if err := encoder.Encode(goal); err != nil {
+			t.Fatalf("Encode: %v", err)
+		}
+	}
+	if err := encoder.End(); err != nil {
+		t.Fatalf("End: %v", err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\r\n"), "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("line longer than 75 octets: %q", line)
+		}
+	}
+
+	assertGolden(t, "goals.events.ics.golden", buf.Bytes())
+}
+
+func TestNewEncoderUnsupportedFormat(t *testing.T) {
+	if _, err := NewEncoder("xml", &bytes.Buffer{}, exportedAt); err != ErrUnsupportedFormat {
+		t.Fatalf("expected ErrUnsupportedFormat, got %v", err)
+	}
+}
+
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("failed to update golden file: %v", err)
+		}
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("output does not match %s\n--- got ---\n%s\n--- want ---\n%s", path, got, want)
+	}
+}
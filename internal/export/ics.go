@@ -0,0 +1,112 @@
+package export
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/pkg/ical"
+)
+
+// Calendar components a deadline can be exported as. Most task apps read VTODO,
+// while calendar apps such as Google Calendar only show VEVENT.
+const (
+	ComponentTodo  = "VTODO"
+	ComponentEvent = "VEVENT"
+)
+
+const icsProductID = "-//Strive//Strive Goals//EN"
+
+// calendarEncoder writes a VCALENDAR with one component for every goal and chapter deadline
+type calendarEncoder struct {
+	w         *ical.Writer
+	now       time.Time
+	component string
+}
+
+// NewCalendarEncoder returns an iCalendar encoder writing deadlines as component (VTODO or VEVENT).
+// Goals and chapters without a deadline are skipped.
+func NewCalendarEncoder(w io.Writer, now time.Time, component string) Encoder {
+	return &calendarEncoder{w: ical.NewWriter(w), now: now, component: component}
+}
+
+func (e *calendarEncoder) Begin() error {
+	e.w.Begin("VCALENDAR")
+	e.w.Property("VERSION", "2.0")
+	e.w.Property("PRODID", icsProductID)
+	e.w.Property("CALSCALE", "GREGORIAN")
+	e.w.Text("X-WR-CALNAME", "Strive")
+	return e.w.Flush()
+}
+
+func (e *calendarEncoder) Encode(goal *model.Goal) error {
+	if !goal.Deadline.IsZero() {
+		e.item(GoalUID(goal.ID), "", goal.Title, goal.Description, goal.Deadline, goal.IsDone, goal.Tags, goal.UpdatedAt)
+	}
+
+	for _, chapter := range goal.Chapters {
+		if chapter.Deadline.IsZero() {
+			continue
+		}
+
+		e.item(ChapterUID(chapter.ID), GoalUID(goal.ID), goal.Title+": "+chapter.Title, chapter.Description, chapter.Deadline, chapter.IsDone, nil, chapter.UpdatedAt)
+	}
+
+	return e.w.Flush()
+}
+
+func (e *calendarEncoder) End() error {
+	e.w.End("VCALENDAR")
+	return e.w.Flush()
+}
+
+func (e *calendarEncoder) item(uid, relatedTo, summary, description string, deadline time.Time, done bool, tags []string, updatedAt time.Time) {
+	e.w.Begin(e.component)
+	e.w.Property("UID", uid)
+	e.w.DateTime("DTSTAMP", e.now)
+	if !updatedAt.IsZero() {
+		e.w.DateTime("LAST-MODIFIED", updatedAt)
+	}
+	e.w.Text("SUMMARY", summary)
+	if description != "" {
+		e.w.Text("DESCRIPTION", description)
+	}
+	if len(tags) > 0 {
+		escaped := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			escaped = append(escaped, ical.EscapeText(tag))
+		}
+		e.w.Property("CATEGORIES", strings.Join(escaped, ","))
+	}
+	if relatedTo != "" {
+		e.w.Property("RELATED-TO", relatedTo)
+	}
+
+	switch e.component {
+	case ComponentEvent:
+		// deadlines are shown as all-day events on the deadline date
+		day := deadline.UTC()
+		e.w.Date("DTSTART", day)
+		e.w.Date("DTEND", day.AddDate(0, 0, 1))
+		e.w.Property("TRANSP", "TRANSPARENT")
+	default:
+		e.w.DateTime("DUE", deadline)
+		if done {
+			e.w.Property("STATUS", "COMPLETED")
+			e.w.Property("PERCENT-COMPLETE", "100")
+		} else {
+			e.w.Property("STATUS", "NEEDS-ACTION")
+		}
+	}
+
+	e.w.End(e.component)
+}
+
+func GoalUID(goalID string) string {
+	return "goal-" + goalID + "@strive"
+}
+
+func ChapterUID(chapterID string) string {
+	return "chapter-" + chapterID + "@strive"
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+// SchemaVersion is bumped on every incompatible change of the JSON export schema
+const SchemaVersion = 1
+
+type (
+	// Document is the root of a JSON export
+	Document struct {
+		Version    int       `json:"version"`
+		ExportedAt time.Time `json:"exported_at"`
+		Goals      []Goal    `json:"goals"`
+	}
+
+	Goal struct {
+		ID          string      `json:"id"`
+		ParentID    string      `json:"parent_id,omitempty"`
+		Type        string      `json:"type"`
+		Title       string      `json:"title"`
+		Description string      `json:"description"`
+		Progress    int         `json:"progress"`
+		IsDone      bool        `json:"is_done"`
+		Deadline    *time.Time  `json:"deadline,omitempty"`
+		Priority    int         `json:"priority"`
+		Tags        []string    `json:"tags"`
+		Chapters    []Chapter   `json:"chapters"`
+		KeyResults  []KeyResult `json:"key_results,omitempty"`
+		CreatedAt   time.Time   `json:"created_at"`
+		UpdatedAt   time.Time   `json:"updated_at"`
+	}
+
+	Chapter struct {
+		ID          string     `json:"id"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		IsDone      bool       `json:"is_done"`
+		Deadline    *time.Time `json:"deadline,omitempty"`
+		Priority    int        `json:"priority"`
+		Position    string     `json:"position"`
+		DependsOn   []string   `json:"depends_on"`
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+	}
+
+	KeyResult struct {
+		ID           string    `json:"id"`
+		Title        string    `json:"title"`
+		StartValue   float64   `json:"start_value"`
+		TargetValue  float64   `json:"target_value"`
+		CurrentValue float64   `json:"current_value"`
+		Unit         string    `json:"unit"`
+		CreatedAt    time.Time `json:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+)
+
+type jsonEncoder struct {
+	w     *bufio.Writer
+	now   time.Time
+	count int
+}
+
+func newJSONEncoder(w io.Writer, now time.Time) *jsonEncoder {
+	return &jsonEncoder{w: bufio.NewWriter(w), now: now}
+}
+
+func (e *jsonEncoder) Begin() error {
+	exportedAt, err := json.Marshal(e.now.UTC())
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(e.w, "{\"version\":%d,\"exported_at\":%s,\"goals\":[", SchemaVersion, exportedAt)
+	return err
+}
+
+func (e *jsonEncoder) Encode(goal *model.Goal) error {
+	data, err := json.Marshal(NewGoal(goal))
+	if err != nil {
+		return fmt.Errorf("failed to encode goal: %w", err)
+	}
+
+	separator := "\n"
+	if e.count > 0 {
+		separator = ",\n"
+	}
+	e.count++
+
+	if _, err := e.w.WriteString(separator); err != nil {
+		return err
+	}
+
+	if _, err := e.w.Write(data); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+func (e *jsonEncoder) End() error {
+	if _, err := e.w.WriteString("\n]}\n"); err != nil {
+		return err
+	}
+
+	return e.w.Flush()
+}
+
+// NewGoal converts the goal to the export schema
+func NewGoal(goal *model.Goal) Goal {
+	exported := Goal{
+		ID:          goal.ID,
+		ParentID:    goal.ParentID,
+		Type:        goal.Type,
+		Title:       goal.Title,
+		Description: goal.Description,
+		Progress:    goal.Progress,
+		IsDone:      goal.IsDone,
+		Deadline:    optionalTime(goal.Deadline),
+		Priority:    goal.Priority,
+		Tags:        nonNil(goal.Tags),
+		Chapters:    make([]Chapter, 0, len(goal.Chapters)),
+		CreatedAt:   goal.CreatedAt.UTC(),
+		UpdatedAt:   goal.UpdatedAt.UTC(),
+	}
+
+	for _, chapter := range goal.Chapters {
+		exported.Chapters = append(exported.Chapters, Chapter{
+			ID:          chapter.ID,
+			Title:       chapter.Title,
+			Description: chapter.Description,
+			IsDone:      chapter.IsDone,
+			Deadline:    optionalTime(chapter.Deadline),
+			Priority:    chapter.Priority,
+			Position:    chapter.Position,
+			DependsOn:   nonNil(chapter.DependsOn),
+			CreatedAt:   chapter.CreatedAt.UTC(),
+			UpdatedAt:   chapter.UpdatedAt.UTC(),
+		})
+	}
+
+	for _, keyResult := range goal.KeyResults {
+		exported.KeyResults = append(exported.KeyResults, KeyResult{
+			ID:           keyResult.ID,
+			Title:        keyResult.Title,
+			StartValue:   keyResult.StartValue,
+			TargetValue:  keyResult.TargetValue,
+			CurrentValue: keyResult.CurrentValue,
+			Unit:         keyResult.Unit,
+			CreatedAt:    keyResult.CreatedAt.UTC(),
+			UpdatedAt:    keyResult.UpdatedAt.UTC(),
+		})
+	}
+
+	return exported
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+
+	utc := t.UTC()
+	return &utc
+}
+
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+
+	return values
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+const markdownDateLayout = "2006-01-02"
+
+// markdownEncoder writes a human-readable outline, goals are headings and chapters are checkboxes
+type markdownEncoder struct {
+	w   *bufio.Writer
+	now time.Time
+}
+
+func newMarkdownEncoder(w io.Writer, now time.Time) *markdownEncoder {
+	return &markdownEncoder{w: bufio.NewWriter(w), now: now}
+}
+
+func (e *markdownEncoder) Begin() error {
+	_, err := fmt.Fprintf(e.w, "# Strive goals\n\n_Exported on %s_\n", e.now.UTC().Format(markdownDateLayout))
+	return err
+}
+
+func (e *markdownEncoder) Encode(goal *model.Goal) error {
+	fmt.Fprintf(e.w, "\n## %s %s\n\n", checkbox(goal.IsDone), goal.Title)
+
+	if goal.Description != "" {
+		fmt.Fprintf(e.w, "%s\n\n", goal.Description)
+	}
+
+	if !goal.Deadline.IsZero() {
+		fmt.Fprintf(e.w, "- Deadline: %s\n", goal.Deadline.UTC().Format(markdownDateLayout))
+	}
+	fmt.Fprintf(e.w, "- Progress: %d%%\n", goal.Progress)
+	if goal.Priority > 0 {
+		fmt.Fprintf(e.w, "- Priority: %d\n", goal.Priority)
+	}
+	if len(goal.Tags) > 0 {
+		fmt.Fprintf(e.w, "- Tags: %s\n", strings.Join(goal.Tags, ", "))
+	}
+
+	if len(goal.Chapters) > 0 {
+		fmt.Fprint(e.w, "\n### Chapters\n\n")
+		for _, chapter := range goal.Chapters {
+			fmt.Fprintf(e.w, "- %s %s", checkbox(chapter.IsDone), chapter.Title)
+			if !chapter.Deadline.IsZero() {
+				fmt.Fprintf(e.w, " (due %s)", chapter.Deadline.UTC().Format(markdownDateLayout))
+			}
+			fmt.Fprint(e.w, "\n")
+		}
+	}
+
+	if len(goal.KeyResults) > 0 {
+		fmt.Fprint(e.w, "\n### Key results\n\n")
+		for _, keyResult := range goal.KeyResults {
+			fmt.Fprintf(e.w, "- %s %s: %g → %g %s (now %g)\n",
+				checkbox(keyResult.Progress() == 100), keyResult.Title,
+				keyResult.StartValue, keyResult.TargetValue, keyResult.Unit, keyResult.CurrentValue)
+		}
+	}
+
+	return e.w.Flush()
+}
+
+func (e *markdownEncoder) End() error {
+	return e.w.Flush()
+}
+
+func checkbox(done bool) string {
+	if done {
+		return "[x]"
+	}
+
+	return "[ ]"
+}
@@ -0,0 +1,101 @@
+package importer
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+type Source string
+
+const (
+	SourceStrive   Source = "strive"
+	SourceMarkdown Source = "markdown"
+	SourceTrello   Source = "trello"
+	SourceTodoist  Source = "todoist"
+)
+
+var (
+	ErrUnsupportedSource  = errors.New("unsupported import source")
+	ErrUnsupportedVersion = errors.New("unsupported export version")
+	ErrMalformedInput     = errors.New("malformed import file")
+	// ErrSectionOutsideGoal is the Err of chapters or metadata found where no goal is open
+	ErrSectionOutsideGoal = errors.New("section outside a goal")
+)
+
+type (
+	// Goal is a goal read from an import source. Refs identify goals and chapters inside
+	// the import only, the service assigns new IDs when the import is committed.
+	Goal struct {
+		Ref         string
+		ParentRef   string
+		Type        string
+		Title       string
+		Description string
+		IsDone      bool
+		Deadline    time.Time // Deadline is zero when the source has none
+		Priority    int
+		Tags        []string
+		Chapters    []Chapter
+		KeyResults  []KeyResult
+		Err         error // Err is set when the item could not be read as a goal, it is reported as invalid
+	}
+
+	Chapter struct {
+		Ref         string
+		Title       string
+		Description string
+		IsDone      bool
+		Deadline    time.Time
+		Priority    int
+		DependsOn   []string // DependsOn holds refs of chapters of the same goal
+	}
+
+	KeyResult struct {
+		Title        string
+		StartValue   float64
+		TargetValue  float64
+		CurrentValue float64
+		Unit         string
+	}
+)
+
+// Parse reads the goals of an import file. It only fails when the file cannot be read as the source,
+// validation of single goals and chapters is left to the caller.
+func Parse(source Source, r io.Reader) ([]Goal, error) {
+	switch source {
+	case SourceStrive:
+		return parseStrive(r)
+	case SourceMarkdown:
+		return parseMarkdown(r)
+	case SourceTrello:
+		return parseTrello(r)
+	case SourceTodoist:
+		return parseTodoist(r)
+	default:
+		return nil, ErrUnsupportedSource
+	}
+}
+
+// latestDeadline returns the latest chapter deadline, used for goals of sources without goal deadlines
+func latestDeadline(chapters []Chapter) time.Time {
+	var latest time.Time
+	for _, chapter := range chapters {
+		if chapter.Deadline.After(latest) {
+			latest = chapter.Deadline
+		}
+	}
+
+	return latest
+}
+
+// parseDate accepts the date and date-time layouts used by the supported sources
+func parseDate(value string) time.Time {
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+
+	return time.Time{}
+}
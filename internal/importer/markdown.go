@@ -0,0 +1,192 @@
+package importer
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	headingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	checkboxPattern = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.*)$`)
+	metadataPattern = regexp.MustCompile(`^[-*+]\s+(Deadline|Priority|Tags):\s*(.*)$`)
+	duePattern      = regexp.MustCompile(`\s*\(due (\d{4}-\d{2}-\d{2})\)$`)
+)
+
+type markdownSection struct {
+	level       int
+	title       string
+	isDone      bool
+	hasCheckbox bool
+	lines       []string
+}
+
+// parseMarkdown reads a checkbox outline. Headings of the goal level become goals, checkbox items
+// below them become chapters and other text becomes the goal description. The goal level is the level
+// of the first heading marked with a checkbox or directly followed by checkbox items, shallower headings
+// are document titles and deeper headings are sections of the goal above them.
+// "- Deadline:", "- Priority:" and "- Tags:" items and a "(due 2006-01-02)" chapter suffix set metadata.
+// Checkbox items or metadata in a section that belongs to no goal are returned as a goal with ErrSectionOutsideGoal.
+func parseMarkdown(r io.Reader) ([]Goal, error) {
+	var sections []*markdownSection
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \t\r")
+
+		if match := headingPattern.FindStringSubmatch(line); match != nil {
+			section := &markdownSection{level: len(match[1]), title: match[2]}
+			if box := checkboxPattern.FindStringSubmatch("- " + section.title); box != nil {
+				section.title = box[2]
+				section.isDone = box[1] != " "
+				section.hasCheckbox = true
+			}
+
+			sections = append(sections, section)
+			continue
+		}
+
+		if len(sections) == 0 {
+			sections = append(sections, &markdownSection{})
+		}
+
+		current := sections[len(sections)-1]
+		current.lines = append(current.lines, line)
+		if checkboxPattern.MatchString(line) {
+			current.hasCheckbox = true
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedInput, err)
+	}
+
+	goalLevel := 0
+	for _, section := range sections {
+		if section.level > 0 && section.hasCheckbox {
+			goalLevel = section.level
+			break
+		}
+	}
+	if goalLevel == 0 {
+		for _, section := range sections {
+			if section.level > 0 && (goalLevel == 0 || section.level < goalLevel) {
+				goalLevel = section.level
+			}
+		}
+	}
+
+	var (
+		goals       []Goal
+		current     *Goal
+		description []string
+	)
+
+	flush := func() {
+		if current == nil {
+			return
+		}
+
+		current.Description = strings.TrimSpace(strings.Join(description, "\n"))
+		goals = append(goals, *current)
+		current, description = nil, nil
+	}
+
+	outside := func(section *markdownSection) {
+		if section.hasGoalContent() {
+			goals = append(goals, Goal{Ref: strconv.Itoa(len(goals)), Title: section.title, Err: ErrSectionOutsideGoal})
+		}
+	}
+
+	for _, section := range sections {
+		switch {
+		case section.level == 0 || section.level < goalLevel:
+			flush()
+			outside(section)
+			continue
+		case section.level == goalLevel:
+			flush()
+			current = &Goal{
+				Ref:    strconv.Itoa(len(goals)),
+				Title:  section.title,
+				IsDone: section.isDone,
+			}
+		case current == nil:
+			// a deeper section before the first goal or after a shallower heading
+			outside(section)
+			continue
+		}
+
+		for _, line := range section.lines {
+			if box := checkboxPattern.FindStringSubmatch(line); box != nil {
+				chapter := Chapter{
+					Ref:    strconv.Itoa(len(current.Chapters)),
+					Title:  box[2],
+					IsDone: box[1] != " ",
+				}
+				if due := duePattern.FindStringSubmatch(chapter.Title); due != nil {
+					chapter.Deadline = parseDate(due[1])
+					chapter.Title = strings.TrimSuffix(chapter.Title, due[0])
+				}
+
+				current.Chapters = append(current.Chapters, chapter)
+				continue
+			}
+
+			if meta := metadataPattern.FindStringSubmatch(line); meta != nil {
+				applyMarkdownMetadata(current, meta[1], meta[2])
+				continue
+			}
+
+			if strings.HasPrefix(strings.TrimSpace(line), "- Progress:") {
+				continue
+			}
+
+			description = append(description, line)
+		}
+	}
+	flush()
+
+	for i := range goals {
+		if goals[i].Deadline.IsZero() {
+			goals[i].Deadline = latestDeadline(goals[i].Chapters)
+		}
+	}
+
+	return goals, nil
+}
+
+// hasGoalContent reports whether the section has a checkbox, in its heading or items, or metadata.
+// Plain text outside of goals is skipped.
+func (s *markdownSection) hasGoalContent() bool {
+	if s.hasCheckbox {
+		return true
+	}
+
+	for _, line := range s.lines {
+		if metadataPattern.MatchString(line) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func applyMarkdownMetadata(goal *Goal, key, value string) {
+	switch key {
+	case "Deadline":
+		goal.Deadline = parseDate(value)
+	case "Priority":
+		goal.Priority, _ = strconv.Atoi(value)
+	case "Tags":
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				goal.Tags = append(goal.Tags, tag)
+			}
+		}
+	}
+}
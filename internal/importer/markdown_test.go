@@ -0,0 +1,89 @@
+package importer
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseMarkdownSectionsOutsideGoals(t *testing.T) {
+	type draft struct {
+		title    string
+		chapters int
+		err      error
+	}
+
+	tests := []struct {
+		name  string
+		input string
+		want  []draft
+	}{
+		{
+			name:  "metadata section before the first goal",
+			input: "### Notes\n- Deadline: 2024-01-01\n## [ ] Goal\n- [ ] ch\n",
+			want: []draft{
+				{title: "Notes", err: ErrSectionOutsideGoal},
+				{title: "Goal", chapters: 1},
+			},
+		},
+		{
+			name:  "deeper section after a shallower heading",
+			input: "## A\n- [ ] x\n# B\n### C\n- [ ] y",
+			want: []draft{
+				{title: "A", chapters: 1},
+				{title: "C", err: ErrSectionOutsideGoal},
+			},
+		},
+		{
+			name:  "shallower heading with chapters",
+			input: "## A\n- [ ] x\n# B\n- [ ] y\n",
+			want: []draft{
+				{title: "A", chapters: 1},
+				{title: "B", err: ErrSectionOutsideGoal},
+			},
+		},
+		{
+			name:  "chapters before any heading",
+			input: "- [ ] x\n## A\n- [ ] y\n",
+			want: []draft{
+				{err: ErrSectionOutsideGoal},
+				{title: "A", chapters: 1},
+			},
+		},
+		{
+			name:  "document title and plain sections are skipped",
+			input: "# Plans\nSome intro\n## A\n- [ ] x\n### Notes\nmore text\n",
+			want: []draft{
+				{title: "A", chapters: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			goals, err := parseMarkdown(strings.NewReader(tt.input))
+			if err != nil {
+				t.Fatalf("parseMarkdown: %v", err)
+			}
+
+			if len(goals) != len(tt.want) {
+				t.Fatalf("got %d goals, want %d: %+v", len(goals), len(tt.want), goals)
+			}
+
+			for i, want := range tt.want {
+				goal := goals[i]
+				if goal.Title != want.title {
+					t.Errorf("goals[%d].Title = %q, want %q", i, goal.Title, want.title)
+				}
+
+				if len(goal.Chapters) != want.chapters {
+					t.Errorf("goals[%d] has %d chapters, want %d", i, len(goal.Chapters), want.chapters)
+				}
+
+				if !errors.Is(goal.Err, want.err) {
+					t.Errorf("goals[%d].Err = %v, want %v", i, goal.Err, want.err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/nordew/Strive/internal/export"
+)
+
+// parseStrive reads a JSON export of Strive, see export.Document
+func parseStrive(r io.Reader) ([]Goal, error) {
+	var document export.Document
+	if err := json.NewDecoder(r).Decode(&document); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedInput, err)
+	}
+
+	if document.Version != export.SchemaVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, document.Version)
+	}
+
+	goals := make([]Goal, 0, len(document.Goals))
+	for _, exported := range document.Goals {
+		goal := Goal{
+			Ref:         exported.ID,
+			ParentRef:   exported.ParentID,
+			Type:        exported.Type,
+			Title:       exported.Title,
+			Description: exported.Description,
+			IsDone:      exported.IsDone,
+			Priority:    exported.Priority,
+			Tags:        exported.Tags,
+		}
+		if exported.Deadline != nil {
+			goal.Deadline = *exported.Deadline
+		}
+
+		for _, exportedChapter := range exported.Chapters {
+			chapter := Chapter{
+				Ref:         exportedChapter.ID,
+				Title:       exportedChapter.Title,
+				Description: exportedChapter.Description,
+				IsDone:      exportedChapter.IsDone,
+				Priority:    exportedChapter.Priority,
+				DependsOn:   exportedChapter.DependsOn,
+			}
+			if exportedChapter.Deadline != nil {
+				chapter.Deadline = *exportedChapter.Deadline
+			}
+
+			goal.Chapters = append(goal.Chapters, chapter)
+		}
+
+		for _, exportedKeyResult := range exported.KeyResults {
+			goal.KeyResults = append(goal.KeyResults, KeyResult{
+				Title:        exportedKeyResult.Title,
+				StartValue:   exportedKeyResult.StartValue,
+				TargetValue:  exportedKeyResult.TargetValue,
+				CurrentValue: exportedKeyResult.CurrentValue,
+				Unit:         exportedKeyResult.Unit,
+			})
+		}
+
+		goals = append(goals, goal)
+	}
+
+	return goals, nil
+}
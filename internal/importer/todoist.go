@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+type todoistProject struct {
+	Project struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"project"`
+	Items []struct {
+		ID          string   `json:"id"`
+		ParentID    string   `json:"parent_id"`
+		Content     string   `json:"content"`
+		Description string   `json:"description"`
+		Checked     bool     `json:"checked"`
+		Priority    int      `json:"priority"`
+		Labels      []string `json:"labels"`
+		Due         *struct {
+			Date string `json:"date"`
+		} `json:"due"`
+	} `json:"items"`
+}
+
+// parseTodoist reads the project data of the Todoist API. The project becomes a goal and its top-level
+// tasks become chapters, sub-tasks are appended to the description of their parent task.
+// Task labels become goal tags and Todoist priorities 1 (normal) to 4 (urgent) map to 0 to 3.
+func parseTodoist(r io.Reader) ([]Goal, error) {
+	var project todoistProject
+	if err := json.NewDecoder(r).Decode(&project); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedInput, err)
+	}
+
+	if project.Project.Name == "" {
+		return nil, fmt.Errorf("%w: not a todoist project export", ErrMalformedInput)
+	}
+
+	goal := Goal{
+		Ref:   project.Project.ID,
+		Title: project.Project.Name,
+	}
+
+	parents := make(map[string]string, len(project.Items))
+	for _, item := range project.Items {
+		parents[item.ID] = item.ParentID
+	}
+
+	subTasks := make(map[string][]string)
+	seenTags := make(map[string]bool)
+	for _, item := range project.Items {
+		if item.ParentID != "" {
+			// nested sub-tasks are flattened into the top-level task
+			root := item.ParentID
+			for depth := 0; parents[root] != "" && depth < len(project.Items); depth++ {
+				root = parents[root]
+			}
+
+			subTasks[root] = append(subTasks[root], checkboxLine(item.Checked, item.Content))
+		}
+
+		for _, label := range item.Labels {
+			if !seenTags[label] {
+				seenTags[label] = true
+				goal.Tags = append(goal.Tags, label)
+			}
+		}
+	}
+
+	for _, item := range project.Items {
+		if item.ParentID != "" {
+			continue
+		}
+
+		chapter := Chapter{
+			Ref:         item.ID,
+			Title:       item.Content,
+			Description: joinDescription(item.Description, subTasks[item.ID]),
+			IsDone:      item.Checked,
+		}
+		if item.Priority > 1 {
+			chapter.Priority = item.Priority - 1
+		}
+		if item.Due != nil {
+			chapter.Deadline = parseDate(item.Due.Date)
+		}
+
+		goal.Chapters = append(goal.Chapters, chapter)
+	}
+
+	goal.Deadline = latestDeadline(goal.Chapters)
+
+	return []Goal{goal}, nil
+}
@@ -0,0 +1,123 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type (
+	trelloBoard struct {
+		ID     string        `json:"id"`
+		Name   string        `json:"name"`
+		Desc   string        `json:"desc"`
+		Closed bool          `json:"closed"`
+		Labels []trelloLabel `json:"labels"`
+		Lists  []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Closed bool   `json:"closed"`
+		} `json:"lists"`
+		Cards []struct {
+			ID          string `json:"id"`
+			Name        string `json:"name"`
+			Desc        string `json:"desc"`
+			Closed      bool   `json:"closed"`
+			Due         string `json:"due"`
+			DueComplete bool   `json:"dueComplete"`
+			IDList      string `json:"idList"`
+		} `json:"cards"`
+		Checklists []struct {
+			IDCard     string `json:"idCard"`
+			CheckItems []struct {
+				Name  string `json:"name"`
+				State string `json:"state"`
+			} `json:"checkItems"`
+		} `json:"checklists"`
+	}
+
+	trelloLabel struct {
+		Name string `json:"name"`
+	}
+)
+
+// parseTrello reads a Trello board export. The board becomes a goal and its open cards become chapters,
+// a card is done when its due date is complete or it sits in a list called "Done".
+// Checklist items are appended to the chapter description.
+func parseTrello(r io.Reader) ([]Goal, error) {
+	var board trelloBoard
+	if err := json.NewDecoder(r).Decode(&board); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformedInput, err)
+	}
+
+	if board.Name == "" {
+		return nil, fmt.Errorf("%w: not a trello board export", ErrMalformedInput)
+	}
+
+	openLists := make(map[string]string, len(board.Lists))
+	for _, list := range board.Lists {
+		if !list.Closed {
+			openLists[list.ID] = list.Name
+		}
+	}
+
+	checkItems := make(map[string][]string)
+	for _, checklist := range board.Checklists {
+		for _, item := range checklist.CheckItems {
+			checkItems[checklist.IDCard] = append(checkItems[checklist.IDCard], checkboxLine(item.State == "complete", item.Name))
+		}
+	}
+
+	goal := Goal{
+		Ref:         board.ID,
+		Title:       board.Name,
+		Description: board.Desc,
+		IsDone:      board.Closed,
+	}
+
+	for _, label := range board.Labels {
+		if label.Name != "" {
+			goal.Tags = append(goal.Tags, label.Name)
+		}
+	}
+
+	for _, card := range board.Cards {
+		listName, ok := openLists[card.IDList]
+		if card.Closed || !ok {
+			continue
+		}
+
+		goal.Chapters = append(goal.Chapters, Chapter{
+			Ref:         card.ID,
+			Title:       card.Name,
+			Description: joinDescription(card.Desc, checkItems[card.ID]),
+			IsDone:      card.DueComplete || strings.EqualFold(strings.TrimSpace(listName), "done"),
+			Deadline:    parseDate(card.Due),
+		})
+	}
+
+	goal.Deadline = latestDeadline(goal.Chapters)
+
+	return []Goal{goal}, nil
+}
+
+func checkboxLine(done bool, text string) string {
+	if done {
+		return "- [x] " + text
+	}
+
+	return "- [ ] " + text
+}
+
+func joinDescription(description string, lines []string) string {
+	if len(lines) == 0 {
+		return description
+	}
+
+	if description == "" {
+		return strings.Join(lines, "\n")
+	}
+
+	return description + "\n\n" + strings.Join(lines, "\n")
+}
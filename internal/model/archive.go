@@ -0,0 +1,50 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+// Archive filters of goal listings
+const (
+	ArchiveExclude = "exclude"
+	ArchiveInclude = "include"
+	ArchiveOnly    = "only"
+)
+
+// MaxArchiveBatch is the number of goals that can be archived or unarchived at once
+const MaxArchiveBatch = 100
+
+// GoalFilter narrows goal listings. Query matches the title, description and tags case-insensitively,
+// Filter is a filter expression parsed into Expr by NewGoalFilter.
+type GoalFilter struct {
+	Archived string
+	Query    string
+	Filter   string
+	Expr     *FilterExpr
+}
+
+// NewGoalFilter returns a filter that leaves archived goals out unless archived or the filter expression
+// says otherwise
+func NewGoalFilter(archived, query, filter string) (GoalFilter, error) {
+	var expr *FilterExpr
+	if strings.TrimSpace(filter) != "" {
+		var err error
+		if expr, err = ParseFilter(filter); err != nil {
+			return GoalFilter{}, err
+		}
+	}
+
+	switch archived {
+	case "":
+		archived = ArchiveExclude
+		if expr != nil && expr.MentionsArchived() {
+			archived = ArchiveInclude
+		}
+	case ArchiveExclude, ArchiveInclude, ArchiveOnly:
+	default:
+		return GoalFilter{}, errors.New("archived must be one of exclude, include or only")
+	}
+
+	return GoalFilter{Archived: archived, Query: query, Filter: filter, Expr: expr}, nil
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"reflect"
+	"time"
+)
+
+// Audited entity types
+const (
+	AuditEntityGoal    = "goal"
+	AuditEntityChapter = "chapter"
+	AuditEntityComment = "comment"
+	AuditEntityUser    = "user"
+)
+
+// Audited actions
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+	// AuditActionRestore takes an entity out of the trash
+	AuditActionRestore = "restore"
+)
+
+const (
+	// ActorSystem is the actor of changes made by background jobs
+	ActorSystem = "system"
+	// ActorAnonymous is the actor of changes made by unauthenticated requests
+	ActorAnonymous = "anonymous"
+)
+
+// auditIgnoredFields are nested collections audited as entities of their own, or derived values
+var auditIgnoredFields = map[string]bool{
+	"chapters":    true,
+	"key_results": true,
+	"comments":    true,
+	"children":    true,
+	"check_ins":   true,
+	"progress":    true,
+	"updated_at":  true,
+}
+
+type (
+	// AuditEntry records a single mutation of an entity
+	AuditEntry struct {
+		ID         string                 `json:"id"`
+		ActorID    string                 `json:"actor_id"`             // ActorID is a user ID, ActorSystem or ActorAnonymous
+		RequestID  string                 `json:"request_id,omitempty"` // RequestID is empty for background changes
+		EntityType string                 `json:"entity_type"`
+		EntityID   string                 `json:"entity_id"`
+		GoalID     string                 `json:"goal_id,omitempty"` // GoalID groups chapter and comment entries under their goal
+		Action     string                 `json:"action"`
+		Changes    map[string]FieldChange `json:"changes"`
+		CreatedAt  time.Time              `json:"created_at"`
+	}
+
+	// FieldChange holds the JSON values of a field before and after a mutation
+	FieldChange struct {
+		Before json.RawMessage `json:"before,omitempty"`
+		After  json.RawMessage `json:"after,omitempty"`
+	}
+)
+
+func NewAuditEntry(
+	id string,
+	actorID string,
+	requestID string,
+	entityType string,
+	entityID string,
+	goalID string,
+	action string,
+	changes map[string]FieldChange,
+	createdAt time.Time) (*AuditEntry, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if actorID == "" {
+		return nil, errors.New("actor_id cannot be empty")
+	}
+
+	switch entityType {
+	case AuditEntityGoal, AuditEntityChapter, AuditEntityComment, AuditEntityUser:
+	default:
+		return nil, errors.New("unknown entity type " + entityType)
+	}
+
+	if entityID == "" {
+		return nil, errors.New("entity_id cannot be empty")
+	}
+
+	switch action {
+	case AuditActionCreate, AuditActionUpdate, AuditActionDelete, AuditActionRestore:
+	default:
+		return nil, errors.New("unknown action " + action)
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	return &AuditEntry{
+		ID:         id,
+		ActorID:    actorID,
+		RequestID:  requestID,
+		EntityType: entityType,
+		EntityID:   entityID,
+		GoalID:     goalID,
+		Action:     action,
+		Changes:    changes,
+		CreatedAt:  createdAt,
+	}, nil
+}
+
+// Diff compares the JSON encodings of before and after field by field and returns the changed fields.
+// A nil before describes a create, a nil after a delete.
+func Diff(before, after any) (map[string]FieldChange, error) {
+	beforeFields, err := jsonFields(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterFields, err := jsonFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]FieldChange)
+	for name, value := range beforeFields {
+		if !jsonEqual(value, afterFields[name]) {
+			changes[name] = FieldChange{Before: value, After: afterFields[name]}
+		}
+	}
+
+	for name, value := range afterFields {
+		if _, ok := beforeFields[name]; !ok && !jsonEqual(nil, value) {
+			changes[name] = FieldChange{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+func jsonFields(v any) (map[string]json.RawMessage, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	for name := range auditIgnoredFields {
+		delete(fields, name)
+	}
+
+	return fields, nil
+}
+
+func jsonEqual(a, b json.RawMessage) bool {
+	var left, right any
+	if len(a) > 0 {
+		if err := json.Unmarshal(a, &left); err != nil {
+			return false
+		}
+	}
+
+	if len(b) > 0 {
+		if err := json.Unmarshal(b, &right); err != nil {
+			return false
+		}
+	}
+
+	return reflect.DeepEqual(left, right)
+}
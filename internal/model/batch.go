@@ -0,0 +1,71 @@
+package model
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Batch operations
+const (
+	BatchComplete      = "complete"
+	BatchReopen        = "reopen"
+	BatchDelete        = "delete"
+	BatchRetag         = "retag"
+	BatchMoveChapter   = "move_chapter"
+	BatchShiftDeadline = "shift_deadline"
+)
+
+// Batch modes. All-or-nothing batches run in one transaction and stop at the first failure,
+// best-effort batches run every operation on its own.
+const (
+	BatchAllOrNothing = "all_or_nothing"
+	BatchBestEffort   = "best_effort"
+)
+
+// Statuses of batch operations
+const (
+	BatchStatusOK         = "ok"
+	BatchStatusFailed     = "failed"
+	BatchStatusRolledBack = "rolled_back" // RolledBack operations succeeded but were undone by a later failure
+	BatchStatusSkipped    = "skipped"     // Skipped operations were not run because an earlier one failed
+)
+
+// MaxBatchOperations is the number of operations a batch may hold
+const MaxBatchOperations = 100
+
+// BatchResult is the outcome of a single batch operation
+type BatchResult struct {
+	Index  int    `json:"index"`
+	Op     string `json:"op"`
+	Status string `json:"status"`
+	Err    error  `json:"-"`
+}
+
+// ParseShift parses the duration deadlines are shifted by. Besides Go durations such as "-36h" it accepts
+// whole days such as "3d" or "-1d".
+func ParseShift(shift string) (time.Duration, error) {
+	invalid := errors.New("shift must be a duration such as 3d or -12h")
+
+	var duration time.Duration
+	if days, ok := strings.CutSuffix(shift, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, invalid
+		}
+
+		duration = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if duration, err = time.ParseDuration(shift); err != nil {
+			return 0, invalid
+		}
+	}
+
+	if duration == 0 {
+		return 0, errors.New("shift cannot be zero")
+	}
+
+	return duration, nil
+}
@@ -0,0 +1,56 @@
+package model
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+const calDAVPasswordBytes = 24
+
+// CalDAVCredential is the app password CalDAV clients authenticate with, only its hash is stored
+type CalDAVCredential struct {
+	UserID       string    `json:"user_id"`
+	PasswordHash string    `json:"-"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// NewCalDAVCredential generates a random app password and returns the credential with the plain password,
+// which cannot be recovered later
+func NewCalDAVCredential(userID string, createdAt time.Time) (*CalDAVCredential, string, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, "", errors.New("user_id must be a valid UUID")
+	}
+
+	if createdAt.IsZero() {
+		return nil, "", errors.New("created_at cannot be zero")
+	}
+
+	secret := make([]byte, calDAVPasswordBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, "", err
+	}
+	password := base64.RawURLEncoding.EncodeToString(secret)
+
+	return &CalDAVCredential{
+		UserID:       userID,
+		PasswordHash: hashCalDAVPassword(password),
+		CreatedAt:    createdAt,
+	}, password, nil
+}
+
+// Verify compares the password with the stored hash in constant time
+func (c *CalDAVCredential) Verify(password string) bool {
+	return subtle.ConstantTimeCompare([]byte(c.PasswordHash), []byte(hashCalDAVPassword(password))) == 1
+}
+
+// hashCalDAVPassword uses a plain SHA-256, the passwords are random and long enough not to need a slow hash
+func hashCalDAVPassword(password string) string {
+	sum := sha256.Sum256([]byte(password))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// calendarTokenBytes is the entropy of a feed token, it is the only credential of the feed URL
+const calendarTokenBytes = 32
+
+// CalendarFeed is the secret token of a user's subscribable deadline calendar
+type CalendarFeed struct {
+	UserID    string    `json:"user_id"`
+	Token     string    `json:"token"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// NewCalendarFeed creates a feed with a fresh random token
+func NewCalendarFeed(userID string, createdAt time.Time) (*CalendarFeed, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	token := make([]byte, calendarTokenBytes)
+	if _, err := rand.Read(token); err != nil {
+		return nil, err
+	}
+
+	return &CalendarFeed{
+		UserID:    userID,
+		Token:     base64.RawURLEncoding.EncodeToString(token),
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// Path returns the URL path the feed is served at
+func (f *CalendarFeed) Path() string {
+	return "/calendar/" + f.Token + ".ics"
+}
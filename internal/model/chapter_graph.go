@@ -0,0 +1,239 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	ErrChapterCycle             = errors.New("chapter dependencies contain a cycle")
+	ErrUnknownChapterDependency = errors.New("chapter depends on a chapter outside of its goal")
+)
+
+type (
+	// CriticalPath describes which chapters determine when the goal can be finished
+	CriticalPath struct {
+		GoalDeadline    time.Time             `json:"goal_deadline"`
+		ProjectedFinish time.Time             `json:"projected_finish"`
+		LatestStart     time.Time             `json:"latest_start"` // LatestStart is the last moment work can start without missing GoalDeadline
+		SlackSeconds    int64                 `json:"slack_seconds"`
+		Path            []string              `json:"path"`
+		Chapters        []CriticalPathChapter `json:"chapters"`
+	}
+
+	// CriticalPathChapter holds the schedule of a single chapter. SlackSeconds is how far the chapter
+	// can slip before the goal deadline is jeopardized.
+	CriticalPathChapter struct {
+		ChapterID      string    `json:"chapter_id"`
+		Title          string    `json:"title"`
+		IsDone         bool      `json:"is_done"`
+		Deadline       time.Time `json:"deadline"`
+		EarliestStart  time.Time `json:"earliest_start"`
+		EarliestFinish time.Time `json:"earliest_finish"`
+		LatestStart    time.Time `json:"latest_start"`
+		LatestFinish   time.Time `json:"latest_finish"`
+		SlackSeconds   int64     `json:"slack_seconds"`
+		Critical       bool      `json:"critical"`
+	}
+)
+
+// SortChaptersByDependencies returns the chapters in topological order, dependencies first.
+// It fails if a dependency points outside of the given chapters or if the dependencies contain a cycle.
+func SortChaptersByDependencies(chapters []Chapter) ([]Chapter, error) {
+	byID := make(map[string]Chapter, len(chapters))
+	for _, chapter := range chapters {
+		byID[chapter.ID] = chapter
+	}
+
+	inDegree := make(map[string]int, len(chapters))
+	dependents := make(map[string][]string, len(chapters))
+	for _, chapter := range chapters {
+		for _, dependencyID := range chapter.DependsOn {
+			if _, ok := byID[dependencyID]; !ok {
+				return nil, ErrUnknownChapterDependency
+			}
+
+			inDegree[chapter.ID]++
+			dependents[dependencyID] = append(dependents[dependencyID], chapter.ID)
+		}
+	}
+
+	queue := make([]string, 0, len(chapters))
+	for _, chapter := range chapters {
+		if inDegree[chapter.ID] == 0 {
+			queue = append(queue, chapter.ID)
+		}
+	}
+
+	sorted := make([]Chapter, 0, len(chapters))
+	for len(queue) > 0 {
+		id := queue[0]
+		queue = queue[1:]
+		sorted = append(sorted, byID[id])
+
+		for _, dependentID := range dependents[id] {
+			inDegree[dependentID]--
+			if inDegree[dependentID] == 0 {
+				queue = append(queue, dependentID)
+			}
+		}
+	}
+
+	if len(sorted) != len(chapters) {
+		return nil, ErrChapterCycle
+	}
+
+	return sorted, nil
+}
+
+// UnmetDependencies returns the dependencies of the chapter that are not done yet
+func UnmetDependencies(chapter Chapter, chapters []Chapter) []Chapter {
+	var unmet []Chapter
+
+	for _, dependencyID := range chapter.DependsOn {
+		for _, candidate := range chapters {
+			if candidate.ID == dependencyID && !candidate.IsDone {
+				unmet = append(unmet, candidate)
+			}
+		}
+	}
+
+	return unmet
+}
+
+// NewCriticalPath schedules the open chapters of the goal starting at now.
+// Chapters carry no explicit duration, so a chapter is assumed to take the time between the latest
+// deadline of its dependencies (or its creation) and its own deadline. Done chapters take no time.
+func NewCriticalPath(goal Goal, chapters []Chapter, now time.Time) (*CriticalPath, error) {
+	sorted, err := SortChaptersByDependencies(chapters)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Chapter, len(sorted))
+	for _, chapter := range sorted {
+		byID[chapter.ID] = chapter
+	}
+
+	durations := make(map[string]time.Duration, len(sorted))
+	earliestStart := make(map[string]time.Time, len(sorted))
+	earliestFinish := make(map[string]time.Time, len(sorted))
+	dependents := make(map[string][]string, len(sorted))
+	projectedFinish := now
+
+	for _, chapter := range sorted {
+		start := chapter.CreatedAt
+		earliest := now
+
+		for _, dependencyID := range chapter.DependsOn {
+			dependents[dependencyID] = append(dependents[dependencyID], chapter.ID)
+
+			if deadline := byID[dependencyID].Deadline; deadline.After(start) {
+				start = deadline
+			}
+
+			if finish := earliestFinish[dependencyID]; finish.After(earliest) {
+				earliest = finish
+			}
+		}
+
+		if !chapter.IsDone && !chapter.Deadline.IsZero() && chapter.Deadline.After(start) {
+			durations[chapter.ID] = chapter.Deadline.Sub(start)
+		}
+
+		earliestStart[chapter.ID] = earliest
+		earliestFinish[chapter.ID] = earliest.Add(durations[chapter.ID])
+
+		if earliestFinish[chapter.ID].After(projectedFinish) {
+			projectedFinish = earliestFinish[chapter.ID]
+		}
+	}
+
+	target := goal.Deadline
+	if target.IsZero() {
+		target = projectedFinish
+	}
+
+	latestStart := make(map[string]time.Time, len(sorted))
+	latestFinish := make(map[string]time.Time, len(sorted))
+
+	for i := len(sorted) - 1; i >= 0; i-- {
+		chapter := sorted[i]
+
+		finish := target
+		for _, dependentID := range dependents[chapter.ID] {
+			if latestStart[dependentID].Before(finish) {
+				finish = latestStart[dependentID]
+			}
+		}
+
+		latestFinish[chapter.ID] = finish
+		latestStart[chapter.ID] = finish.Add(-durations[chapter.ID])
+	}
+
+	path := &CriticalPath{
+		GoalDeadline:    goal.Deadline,
+		ProjectedFinish: projectedFinish,
+		LatestStart:     target,
+		SlackSeconds:    int64(target.Sub(projectedFinish).Seconds()),
+		Chapters:        make([]CriticalPathChapter, 0, len(sorted)),
+	}
+
+	var minSlack *time.Duration
+	for _, chapter := range sorted {
+		if chapter.IsDone {
+			continue
+		}
+
+		slack := latestStart[chapter.ID].Sub(earliestStart[chapter.ID])
+		if minSlack == nil || slack < *minSlack {
+			minSlack = &slack
+		}
+
+		if latestStart[chapter.ID].Before(path.LatestStart) {
+			path.LatestStart = latestStart[chapter.ID]
+		}
+	}
+
+	var last string
+	for _, chapter := range sorted {
+		slack := latestStart[chapter.ID].Sub(earliestStart[chapter.ID])
+		critical := !chapter.IsDone && minSlack != nil && slack == *minSlack
+
+		path.Chapters = append(path.Chapters, CriticalPathChapter{
+			ChapterID:      chapter.ID,
+			Title:          chapter.Title,
+			IsDone:         chapter.IsDone,
+			Deadline:       chapter.Deadline,
+			EarliestStart:  earliestStart[chapter.ID],
+			EarliestFinish: earliestFinish[chapter.ID],
+			LatestStart:    latestStart[chapter.ID],
+			LatestFinish:   latestFinish[chapter.ID],
+			SlackSeconds:   int64(slack.Seconds()),
+			Critical:       critical,
+		})
+
+		if critical && (last == "" || earliestFinish[chapter.ID].After(earliestFinish[last])) {
+			last = chapter.ID
+		}
+	}
+
+	// walk back from the critical chapter finishing last through the dependencies finishing last
+	for last != "" {
+		path.Path = append([]string{last}, path.Path...)
+
+		next := ""
+		for _, dependencyID := range byID[last].DependsOn {
+			if byID[dependencyID].IsDone {
+				continue
+			}
+
+			if next == "" || earliestFinish[dependencyID].After(earliestFinish[next]) {
+				next = dependencyID
+			}
+		}
+		last = next
+	}
+
+	return path, nil
+}
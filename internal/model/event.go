@@ -0,0 +1,160 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Domain events
+const (
+	EventUserRegistered      = "user.registered"
+	EventUserAuthorized      = "user.authorized"
+	EventGoalCreated         = "goal.created"
+	EventGoalUpdated         = "goal.updated"
+	EventGoalRestored        = "goal.restored"
+	EventGoalDeleted         = "goal.deleted"
+	EventGoalRecovered       = "goal.recovered"
+	EventGoalMoved           = "goal.moved"
+	EventGoalArchived        = "goal.archived"
+	EventGoalUnarchived      = "goal.unarchived"
+	EventGoalProgressChanged = "goal.progress_changed"
+	EventGoalCompleted       = "goal.completed"
+	EventChapterCreated      = "chapter.created"
+	EventChapterUpdated      = "chapter.updated"
+	EventChapterCompleted    = "chapter.completed"
+	EventChapterDeleted      = "chapter.deleted"
+	EventChapterMoved        = "chapter.moved"
+	EventChapterRecovered    = "chapter.recovered"
+	EventCommentCreated      = "comment.created"
+	EventCommentDeleted      = "comment.deleted"
+	EventCommentRecovered    = "comment.recovered"
+	EventKeyResultCheckedIn  = "key_result.checked_in"
+	EventDeadlineMissed      = "deadline.missed"
+	EventAchievementUnlocked = "achievement.unlocked"
+	EventFocusEnded          = "focus.ended"
+)
+
+const (
+	eventBaseBackoff = 5 * time.Second
+	eventMaxBackoff  = time.Hour
+	// MaxEventAttempts is the number of failed dispatches after which an event is given up on
+	MaxEventAttempts = 20
+)
+
+type (
+	// Event is a domain event stored in the outbox together with the change that caused it
+	Event struct {
+		ID            string          `json:"id"`
+		Type          string          `json:"type"`
+		UserID        string          `json:"user_id"`
+		Payload       json.RawMessage `json:"payload"`
+		Attempts      int             `json:"attempts"`
+		NextAttemptAt time.Time       `json:"next_attempt_at"`
+		LastError     string          `json:"last_error,omitempty"`
+		DispatchedAt  *time.Time      `json:"dispatched_at,omitempty"`
+		DeadAt        *time.Time      `json:"dead_at,omitempty"` // DeadAt is set once the event ran out of attempts
+		CreatedAt     time.Time       `json:"created_at"`
+	}
+
+	// ProgressChange is the payload of goal.progress_changed
+	ProgressChange struct {
+		GoalID   string `json:"goal_id"`
+		Previous int    `json:"previous"`
+		Progress int    `json:"progress"`
+	}
+
+	// GoalMove is the payload of goal.moved
+	GoalMove struct {
+		GoalID      string `json:"goal_id"`
+		OldParentID string `json:"old_parent_id,omitempty"`
+		ParentID    string `json:"parent_id,omitempty"`
+	}
+
+	// ChapterMove is the payload of chapter.moved
+	ChapterMove struct {
+		ChapterID  string `json:"chapter_id"`
+		FromGoalID string `json:"from_goal_id"`
+		ToGoalID   string `json:"to_goal_id"`
+	}
+
+	// GoalRestore is the payload of goal.restored
+	GoalRestore struct {
+		GoalID   string `json:"goal_id"`
+		Revision int    `json:"revision"` // Revision is the number of the restored revision
+		Chapters bool   `json:"chapters"` // Chapters reports whether the chapters were restored too
+	}
+)
+
+// NewEvent encodes data as the payload of a new event
+func NewEvent(id, eventType, userID string, data any, createdAt time.Time) (*Event, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if eventType == "" {
+		return nil, errors.New("type cannot be empty")
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Event{
+		ID:            id,
+		Type:          eventType,
+		UserID:        userID,
+		Payload:       payload,
+		NextAttemptAt: createdAt,
+		CreatedAt:     createdAt,
+	}, nil
+}
+
+// Decode unmarshals the payload into v
+func (e *Event) Decode(v any) error {
+	return json.Unmarshal(e.Payload, v)
+}
+
+func (e *Event) MarkDispatched(now time.Time) {
+	e.Attempts++
+	e.LastError = ""
+	e.DispatchedAt = &now
+}
+
+// RecordFailure schedules the next dispatch with exponential backoff capped at an hour. After
+// MaxEventAttempts failures the event is marked dead and is not dispatched again.
+func (e *Event) RecordFailure(err error, now time.Time) {
+	e.Attempts++
+	e.LastError = err.Error()
+
+	if e.Attempts >= MaxEventAttempts {
+		e.DeadAt = &now
+		return
+	}
+
+	backoff := eventBaseBackoff
+	for i := 1; i < e.Attempts && backoff < eventMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > eventMaxBackoff {
+		backoff = eventMaxBackoff
+	}
+
+	e.NextAttemptAt = now.Add(backoff)
+}
+
+// IsDead reports whether the event ran out of attempts
+func (e *Event) IsDead() bool {
+	return e.DeadAt != nil
+}
@@ -0,0 +1,272 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Fields of filter expressions
+const (
+	FilterTag      = "tag"
+	FilterDue      = "due"
+	FilterPriority = "priority"
+	FilterProgress = "progress"
+	FilterDone     = "done"
+	FilterOverdue  = "overdue"
+	FilterArchived = "archived"
+	FilterText     = "text"
+)
+
+// Comparison operators of filter terms
+const (
+	FilterEq  = "="
+	FilterLt  = "<"
+	FilterLte = "<="
+	FilterGt  = ">"
+	FilterGte = ">="
+)
+
+const (
+	MaxFilterLength = 500
+	MaxFilterTerms  = 20
+)
+
+// FilterExpr is a parsed filter expression such as `tag:work due<30d priority>=2 !done`.
+// A goal matches when it matches every term.
+type FilterExpr struct {
+	Source string
+	Terms  []FilterTerm
+}
+
+// FilterTerm is a single condition of a filter expression. Which of the value fields is set depends on Field.
+type FilterTerm struct {
+	Field  string
+	Op     string
+	Negate bool
+	Text   string    // Text is the tag or the searched text
+	Number int       // Number is the priority or progress
+	Days   int       // Days is the offset from today of a relative due date such as 30d
+	Date   time.Time // Date is an absolute due date, relative ones leave it zero
+}
+
+// ParseFilter parses a filter expression. Terms are separated by spaces and combined with AND, a leading !
+// negates a term. Supported terms:
+//
+//	tag:work            the goal has the tag, quotes allow spaces as in tag:"deep work"
+//	due<30d             the deadline compared to a number of days (d) or weeks (w) from now, or to a date (2006-01-02)
+//	priority>=2         the priority compared with =, <, <=, > or >=
+//	progress<50         the progress in percent, compared like the priority
+//	done                the goal is completed
+//	overdue             the deadline passed and the goal is not completed
+//	archived            the goal is archived, archived goals are left out unless a term mentions them
+//	anything else       the title, description or tags contain the word
+func ParseFilter(source string) (*FilterExpr, error) {
+	if len(source) > MaxFilterLength {
+		return nil, fmt.Errorf("filter cannot be longer than %d characters", MaxFilterLength)
+	}
+
+	tokens, err := splitFilter(source)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tokens) > MaxFilterTerms {
+		return nil, fmt.Errorf("filter cannot have more than %d terms", MaxFilterTerms)
+	}
+
+	expr := &FilterExpr{Source: strings.TrimSpace(source)}
+	for _, token := range tokens {
+		term, err := parseFilterTerm(token)
+		if err != nil {
+			return nil, fmt.Errorf("invalid term %q: %w", token.raw, err)
+		}
+
+		expr.Terms = append(expr.Terms, term)
+	}
+
+	return expr, nil
+}
+
+// MentionsArchived reports whether a term decides about archived goals
+func (e *FilterExpr) MentionsArchived() bool {
+	for _, term := range e.Terms {
+		if term.Field == FilterArchived {
+			return true
+		}
+	}
+
+	return false
+}
+
+// filterToken is a term of the source, with the quotes removed from value
+type filterToken struct {
+	raw    string
+	value  string
+	quoted bool // quoted is set when the whole term, apart from a leading !, was quoted, which makes it plain text
+}
+
+// splitFilter splits the source at spaces outside of double quotes
+func splitFilter(source string) ([]filterToken, error) {
+	var (
+		tokens  []filterToken
+		raw     strings.Builder
+		value   strings.Builder
+		inQuote bool
+		quoted  bool
+	)
+
+	flush := func() {
+		if raw.Len() > 0 {
+			tokens = append(tokens, filterToken{raw: raw.String(), value: value.String(), quoted: quoted})
+		}
+		raw.Reset()
+		value.Reset()
+		quoted = false
+	}
+
+	for _, r := range source {
+		switch {
+		case r == '"':
+			if !inQuote && (raw.Len() == 0 || raw.String() == "!") {
+				quoted = true
+			}
+			inQuote = !inQuote
+			raw.WriteRune(r)
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			raw.WriteRune(r)
+			value.WriteRune(r)
+		}
+	}
+
+	if inQuote {
+		return nil, errors.New("filter has an unterminated quote")
+	}
+	flush()
+
+	return tokens, nil
+}
+
+func parseFilterTerm(token filterToken) (FilterTerm, error) {
+	value := token.value
+
+	var term FilterTerm
+	if strings.HasPrefix(token.raw, "!") {
+		term.Negate = true
+		value = value[1:]
+	}
+
+	if token.quoted {
+		return filterText(term, value)
+	}
+
+	switch strings.ToLower(value) {
+	case FilterDone, FilterOverdue, FilterArchived:
+		term.Field = strings.ToLower(value)
+		return term, nil
+	}
+
+	end := strings.IndexAny(value, ":<>=")
+	if end <= 0 {
+		return filterText(term, value)
+	}
+
+	field := strings.ToLower(value[:end])
+	op, operand := splitFilterOp(value[end:])
+
+	switch field {
+	case FilterTag:
+		if op != ":" {
+			return term, errors.New("tag must be followed by a colon")
+		}
+
+		term.Field = FilterTag
+		term.Op = FilterEq
+		term.Text = NormalizeTag(operand)
+		if term.Text == "" {
+			return term, errors.New("tag cannot be empty")
+		}
+
+		return term, nil
+	case FilterPriority, FilterProgress:
+		number, err := strconv.Atoi(operand)
+		if err != nil || number < 0 {
+			return term, errors.New("value must be a non-negative integer")
+		}
+
+		term.Field = field
+		term.Op = comparisonOp(op)
+		term.Number = number
+		return term, nil
+	case FilterDue:
+		term.Field = FilterDue
+		term.Op = comparisonOp(op)
+		return parseDue(term, operand)
+	default:
+		return term, fmt.Errorf("unknown field %q", field)
+	}
+}
+
+func filterText(term FilterTerm, value string) (FilterTerm, error) {
+	if value == "" {
+		return term, errors.New("text cannot be empty")
+	}
+
+	term.Field = FilterText
+	term.Text = value
+	return term, nil
+}
+
+// splitFilterOp splits the operator off the start of s, a colon is kept as is
+func splitFilterOp(s string) (op, operand string) {
+	for _, candidate := range []string{FilterLte, FilterGte, FilterLt, FilterGt, FilterEq, ":"} {
+		if strings.HasPrefix(s, candidate) {
+			return candidate, s[len(candidate):]
+		}
+	}
+
+	return "", s
+}
+
+// comparisonOp treats a colon as equality, so priority:2 reads like priority=2
+func comparisonOp(op string) string {
+	if op == ":" {
+		return FilterEq
+	}
+
+	return op
+}
+
+func parseDue(term FilterTerm, operand string) (FilterTerm, error) {
+	invalid := errors.New("due must be compared to days (30d), weeks (2w) or a date (2006-01-02)")
+
+	if date, err := time.Parse(time.DateOnly, operand); err == nil {
+		term.Date = date
+		return term, nil
+	}
+
+	if len(operand) < 2 {
+		return term, invalid
+	}
+
+	count, err := strconv.Atoi(operand[:len(operand)-1])
+	if err != nil {
+		return term, invalid
+	}
+
+	switch operand[len(operand)-1] {
+	case 'd':
+		term.Days = count
+	case 'w':
+		term.Days = count * 7
+	default:
+		return term, invalid
+	}
+
+	return term, nil
+}
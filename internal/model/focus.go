@@ -0,0 +1,209 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Focus session modes
+const (
+	FocusModeFree     = "free"
+	FocusModePomodoro = "pomodoro"
+)
+
+// Focus session states
+const (
+	FocusRunning = "running"
+	FocusPaused  = "paused"
+	FocusEnded   = "ended"
+)
+
+const (
+	DefaultPomodoroMinutes = 25
+	MaxPomodoroMinutes     = 120
+	// PomodoroBreakMinutes is the break suggested after a completed pomodoro
+	PomodoroBreakMinutes = 5
+	// MaxFocusDuration ends free sessions that were left running
+	MaxFocusDuration = 12 * time.Hour
+)
+
+type (
+	// FocusSession is time spent working on a chapter. The server keeps the time: FocusedSeconds is the time
+	// focused before the current stretch, which runs from ResumedAt while the session is running.
+	FocusSession struct {
+		ID             string     `json:"id"`
+		UserID         string     `json:"user_id"`
+		GoalID         string     `json:"goal_id"`
+		ChapterID      string     `json:"chapter_id"`
+		ChapterTitle   string     `json:"chapter_title"`
+		Mode           string     `json:"mode"`
+		State          string     `json:"state"`
+		PlannedSeconds int        `json:"planned_seconds,omitempty"` // PlannedSeconds is the length of a pomodoro
+		FocusedSeconds int        `json:"focused_seconds"`
+		StartedAt      time.Time  `json:"started_at"`
+		ResumedAt      *time.Time `json:"resumed_at,omitempty"`
+		EndsAt         *time.Time `json:"ends_at,omitempty"` // EndsAt is when a running session ends by itself
+		EndedAt        *time.Time `json:"ended_at,omitempty"`
+		Completed      bool       `json:"completed"` // Completed reports whether a pomodoro ran its full length
+		CreatedAt      time.Time  `json:"created_at"`
+		UpdatedAt      time.Time  `json:"updated_at"`
+	}
+
+	// FocusTotals is the time focused on a goal and each of its chapters, in seconds
+	FocusTotals struct {
+		GoalID   string          `json:"goal_id"`
+		Seconds  int             `json:"seconds"`
+		Chapters []*ChapterFocus `json:"chapters"`
+	}
+
+	ChapterFocus struct {
+		ChapterID string `json:"chapter_id"`
+		Title     string `json:"title"`
+		Seconds   int    `json:"seconds"`
+		Sessions  int    `json:"sessions"`
+	}
+
+	// GoalFocus is the time invested in a goal next to its progress
+	GoalFocus struct {
+		GoalID   string `json:"goal_id"`
+		Title    string `json:"title"`
+		Progress int    `json:"progress"`
+		Seconds  int    `json:"seconds"`
+	}
+)
+
+// NewFocusSession starts a session on the chapter. Pomodoros last the given minutes, zero meaning
+// DefaultPomodoroMinutes, free sessions run until they are stopped.
+func NewFocusSession(id, userID, goalID, chapterID, mode string, minutes int, now time.Time) (*FocusSession, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(chapterID); err != nil {
+		return nil, errors.New("chapter_id must be a valid UUID")
+	}
+
+	if now.IsZero() {
+		return nil, errors.New("started_at cannot be zero")
+	}
+
+	session := &FocusSession{
+		ID:        id,
+		UserID:    userID,
+		GoalID:    goalID,
+		ChapterID: chapterID,
+		State:     FocusRunning,
+		StartedAt: now,
+		ResumedAt: &now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	switch mode {
+	case "", FocusModeFree:
+		if minutes != 0 {
+			return nil, errors.New("minutes can only be set for pomodoros")
+		}
+
+		session.Mode = FocusModeFree
+	case FocusModePomodoro:
+		if minutes == 0 {
+			minutes = DefaultPomodoroMinutes
+		}
+
+		if minutes < 1 || minutes > MaxPomodoroMinutes {
+			return nil, fmt.Errorf("minutes must be between 1 and %d", MaxPomodoroMinutes)
+		}
+
+		session.Mode = FocusModePomodoro
+		session.PlannedSeconds = minutes * 60
+	default:
+		return nil, errors.New("mode must be free or pomodoro")
+	}
+
+	session.scheduleEnd(now)
+
+	return session, nil
+}
+
+// Focused returns the seconds focused up to now
+func (s *FocusSession) Focused(now time.Time) int {
+	focused := s.FocusedSeconds
+	if s.State == FocusRunning && s.ResumedAt != nil && now.After(*s.ResumedAt) {
+		focused += int(now.Sub(*s.ResumedAt) / time.Second)
+	}
+
+	return focused
+}
+
+// Due reports whether a running session reached its end
+func (s *FocusSession) Due(now time.Time) bool {
+	return s.State == FocusRunning && s.EndsAt != nil && !now.Before(*s.EndsAt)
+}
+
+func (s *FocusSession) Pause(now time.Time) error {
+	if s.State != FocusRunning || s.Due(now) {
+		return errors.New("only running sessions can be paused")
+	}
+
+	s.FocusedSeconds = s.Focused(now)
+	s.State = FocusPaused
+	s.ResumedAt = nil
+	s.EndsAt = nil
+	s.UpdatedAt = now
+	return nil
+}
+
+func (s *FocusSession) Resume(now time.Time) error {
+	if s.State != FocusPaused {
+		return errors.New("only paused sessions can be resumed")
+	}
+
+	s.State = FocusRunning
+	s.ResumedAt = &now
+	s.scheduleEnd(now)
+	s.UpdatedAt = now
+	return nil
+}
+
+// Stop ends the session. A session past its end is ended at EndsAt, so time after it is not counted.
+func (s *FocusSession) Stop(now time.Time) error {
+	if s.State == FocusEnded {
+		return errors.New("session has ended already")
+	}
+
+	if s.Due(now) {
+		now = *s.EndsAt
+	}
+
+	s.FocusedSeconds = s.Focused(now)
+	if s.Mode == FocusModePomodoro && s.FocusedSeconds >= s.PlannedSeconds {
+		s.FocusedSeconds = s.PlannedSeconds
+		s.Completed = true
+	}
+
+	s.State = FocusEnded
+	s.ResumedAt = nil
+	s.EndsAt = nil
+	s.EndedAt = &now
+	s.UpdatedAt = now
+	return nil
+}
+
+// scheduleEnd sets when the running session ends by itself, pomodoros after their length and free
+// sessions after MaxFocusDuration
+func (s *FocusSession) scheduleEnd(now time.Time) {
+	limit := int(MaxFocusDuration / time.Second)
+	if s.Mode == FocusModePomodoro {
+		limit = s.PlannedSeconds
+	}
+
+	endsAt := now.Add(time.Duration(limit-s.FocusedSeconds) * time.Second)
+	s.EndsAt = &endsAt
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+const (
+	// FriendInviteTTL is how long an invite link can be used
+	FriendInviteTTL = 7 * 24 * time.Hour
+
+	// friendInviteBytes is the entropy of an invite code, the code fits into a Telegram deep link
+	friendInviteBytes = 16
+	// friendInvitePrefix marks invite codes in the start parameter of deep links
+	friendInvitePrefix = "invite_"
+)
+
+type (
+	// FriendInvite is a single-use code that makes whoever accepts it a friend of the user
+	FriendInvite struct {
+		Code      string    `json:"code"`
+		UserID    string    `json:"user_id"`
+		Link      string    `json:"link,omitempty"` // Link is the bot deep link accepting the invite, when the bot is known
+		CreatedAt time.Time `json:"created_at"`
+		ExpiresAt time.Time `json:"expires_at"`
+	}
+
+	// Friend is a user connected to another one, Since is when they became friends
+	Friend struct {
+		ID        string    `json:"id"`
+		FirstName string    `json:"first_name"`
+		LastName  string    `json:"last_name"`
+		Since     time.Time `json:"since"`
+	}
+
+	// PrivacySettings control what friends see of the user
+	PrivacySettings struct {
+		UserID               string    `json:"user_id"`
+		HideFromLeaderboards bool      `json:"hide_from_leaderboards"`
+		UpdatedAt            time.Time `json:"updated_at"`
+	}
+)
+
+// NewFriendInvite creates an invite of the user with a fresh random code
+func NewFriendInvite(userID string, createdAt time.Time) (*FriendInvite, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	code := make([]byte, friendInviteBytes)
+	if _, err := rand.Read(code); err != nil {
+		return nil, err
+	}
+
+	return &FriendInvite{
+		Code:      base64.RawURLEncoding.EncodeToString(code),
+		UserID:    userID,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(FriendInviteTTL),
+	}, nil
+}
+
+func (i *FriendInvite) Expired(now time.Time) bool {
+	return !now.Before(i.ExpiresAt)
+}
+
+// StartParameter returns the start parameter of the deep link accepting the invite
+func (i *FriendInvite) StartParameter() string {
+	return friendInvitePrefix + i.Code
+}
+
+// DeepLink returns the link that opens the bot with the invite
+func (i *FriendInvite) DeepLink(botUsername string) string {
+	return fmt.Sprintf("https://t.me/%s?start=%s", botUsername, i.StartParameter())
+}
+
+// ParseStartParameter returns the invite code of a deep link start parameter
+func ParseStartParameter(parameter string) (string, bool) {
+	code, ok := strings.CutPrefix(strings.TrimSpace(parameter), friendInvitePrefix)
+	if !ok || code == "" {
+		return "", false
+	}
+
+	return code, true
+}
+
+// NewPrivacySettings returns the settings users have until they change them
+func NewPrivacySettings(userID string) *PrivacySettings {
+	return &PrivacySettings{UserID: userID}
+}
+
+func (p *PrivacySettings) SetHideFromLeaderboards(hide bool) *PrivacySettings {
+	p.HideFromLeaderboards = hide
+	return p
+}
+
+func (p *PrivacySettings) SetUpdatedAt(updatedAt time.Time) (*PrivacySettings, error) {
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	p.UpdatedAt = updatedAt
+	return p, nil
+}
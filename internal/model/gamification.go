@@ -0,0 +1,241 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+// Sources of XP awards
+const (
+	XPSourceGoal    = "goal"
+	XPSourceChapter = "chapter"
+)
+
+const (
+	ChapterXP = 10
+	GoalXP    = 50
+
+	// XPPriorityBonus is the percentage added to the XP for each priority level, up to MaxXPPriority levels
+	XPPriorityBonus = 25
+	MaxXPPriority   = 4
+	// XPOnTimeBonus is the percentage added when the item is completed by its deadline
+	XPOnTimeBonus = 50
+
+	// LevelXPStep is the XP the first level up takes, each further level takes that much more
+	LevelXPStep = 100
+)
+
+// XPEvents are the events that award XP
+var XPEvents = []string{EventGoalCompleted, EventChapterCompleted}
+
+type (
+	// XPAward is the XP a user earned by completing a goal or chapter
+	XPAward struct {
+		ID        string    `json:"id"`
+		UserID    string    `json:"user_id"`
+		Source    string    `json:"source"`
+		SourceID  string    `json:"source_id"`
+		Points    int       `json:"points"`
+		OnTime    bool      `json:"on_time"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+
+	// Level is the level reached with XP. LevelXP is the XP the level started at, NextLevelXP the XP the next one starts at.
+	Level struct {
+		Level       int `json:"level"`
+		XP          int `json:"xp"`
+		LevelXP     int `json:"level_xp"`
+		NextLevelXP int `json:"next_level_xp"`
+	}
+
+	// Achievement is a badge users unlock by reaching the target of its rule
+	Achievement struct {
+		Code        string `json:"code"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+		Emoji       string `json:"emoji"`
+	}
+
+	// AchievementRule unlocks the achievement once the metric of the user's progress reaches the target
+	AchievementRule struct {
+		Achievement
+		Target int
+		Metric func(progress *AchievementProgress) int
+	}
+
+	// AchievementProgress is what achievement rules are evaluated against
+	AchievementProgress struct {
+		XP          int
+		Goals       int // Goals is the number of completed goals
+		Chapters    int // Chapters is the number of completed chapters
+		OnTimeGoals int // OnTimeGoals is the number of goals completed by their deadline
+		Streak      int // Streak is the number of consecutive days, up to today, with a completion
+	}
+
+	// UnlockedAchievement is an achievement the user earned, it is the payload of achievement.unlocked
+	UnlockedAchievement struct {
+		Achievement
+		UnlockedAt time.Time `json:"unlocked_at"`
+	}
+
+	// Profile is the XP, level, streak and badges of a user
+	Profile struct {
+		Level
+		Streak       int                    `json:"streak"`
+		Achievements []*UnlockedAchievement `json:"achievements"`
+	}
+)
+
+// AchievementRules are the achievements users can unlock, in the order they are shown
+var AchievementRules = []AchievementRule{
+	{
+		Achievement: Achievement{Code: "first_chapter", Name: "First step", Description: "Complete your first chapter", Emoji: "👣"},
+		Target:      1,
+		Metric:      func(p *AchievementProgress) int { return p.Chapters },
+	},
+	{
+		Achievement: Achievement{Code: "first_goal", Name: "First goal", Description: "Complete your first goal", Emoji: "🎯"},
+		Target:      1,
+		Metric:      func(p *AchievementProgress) int { return p.Goals },
+	},
+	{
+		Achievement: Achievement{Code: "chapters_50", Name: "Chapter by chapter", Description: "Complete 50 chapters", Emoji: "📚"},
+		Target:      50,
+		Metric:      func(p *AchievementProgress) int { return p.Chapters },
+	},
+	{
+		Achievement: Achievement{Code: "goals_10", Name: "Goal getter", Description: "Complete 10 goals", Emoji: "🏆"},
+		Target:      10,
+		Metric:      func(p *AchievementProgress) int { return p.Goals },
+	},
+	{
+		Achievement: Achievement{Code: "on_time_goals_5", Name: "Ahead of schedule", Description: "Finish 5 goals by their deadline", Emoji: "⏰"},
+		Target:      5,
+		Metric:      func(p *AchievementProgress) int { return p.OnTimeGoals },
+	},
+	{
+		Achievement: Achievement{Code: "streak_3", Name: "On a roll", Description: "Complete something 3 days in a row", Emoji: "🔥"},
+		Target:      3,
+		Metric:      func(p *AchievementProgress) int { return p.Streak },
+	},
+	{
+		Achievement: Achievement{Code: "streak_10", Name: "Unstoppable", Description: "Complete something 10 days in a row", Emoji: "⚡"},
+		Target:      10,
+		Metric:      func(p *AchievementProgress) int { return p.Streak },
+	},
+	{
+		Achievement: Achievement{Code: "level_5", Name: "Rising star", Description: "Reach level 5", Emoji: "⭐"},
+		Target:      5,
+		Metric:      func(p *AchievementProgress) int { return LevelFor(p.XP).Level },
+	},
+}
+
+// NewXPAward awards the XP for completing a goal or chapter with the given priority and deadline at completedAt,
+// a zero deadline means the item has none
+func NewXPAward(id, userID, source, sourceID string, priority int, deadline, completedAt time.Time) (*XPAward, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(sourceID); err != nil {
+		return nil, errors.New("source_id must be a valid UUID")
+	}
+
+	var base int
+	switch source {
+	case XPSourceGoal:
+		base = GoalXP
+	case XPSourceChapter:
+		base = ChapterXP
+	default:
+		return nil, errors.New("source must be goal or chapter")
+	}
+
+	if completedAt.IsZero() {
+		return nil, errors.New("completed_at cannot be zero")
+	}
+
+	priority = min(max(priority, 0), MaxXPPriority)
+	onTime := !deadline.IsZero() && !completedAt.After(deadline)
+
+	percent := 100 + priority*XPPriorityBonus
+	if onTime {
+		percent += XPOnTimeBonus
+	}
+
+	return &XPAward{
+		ID:        id,
+		UserID:    userID,
+		Source:    source,
+		SourceID:  sourceID,
+		Points:    base * percent / 100,
+		OnTime:    onTime,
+		CreatedAt: completedAt,
+	}, nil
+}
+
+// LevelFor returns the level reached with xp. Users start at level 1 and level n+1 takes n*LevelXPStep more XP than level n.
+func LevelFor(xp int) Level {
+	level := Level{Level: 1, XP: xp, NextLevelXP: LevelXPStep}
+	for xp >= level.NextLevelXP {
+		level.Level++
+		level.LevelXP = level.NextLevelXP
+		level.NextLevelXP += level.Level * LevelXPStep
+	}
+
+	return level
+}
+
+// MaxStreakDays bounds the days of activity looked at when counting a streak
+const MaxStreakDays = 366
+
+// Streak counts the consecutive days with activity ending today or, while today has none yet, yesterday.
+// days are truncated to dates in the same location as today and ordered newest first.
+func Streak(days []time.Time, today time.Time) int {
+	day := time.Date(today.Year(), today.Month(), today.Day(), 0, 0, 0, 0, today.Location())
+	if len(days) == 0 || days[0].Before(day) {
+		day = day.AddDate(0, 0, -1)
+	}
+
+	streak := 0
+	for _, activity := range days {
+		if activity.Before(day) {
+			break
+		}
+
+		if activity.Equal(day) {
+			streak++
+			day = day.AddDate(0, 0, -1)
+		}
+	}
+
+	return streak
+}
+
+// Reached returns the achievements whose targets the progress reaches
+func (p *AchievementProgress) Reached() []Achievement {
+	var achievements []Achievement
+	for _, rule := range AchievementRules {
+		if rule.Metric(p) >= rule.Target {
+			achievements = append(achievements, rule.Achievement)
+		}
+	}
+
+	return achievements
+}
+
+// FindAchievement returns the achievement with the given code
+func FindAchievement(code string) (Achievement, bool) {
+	for _, rule := range AchievementRules {
+		if rule.Code == code {
+			return rule.Achievement, true
+		}
+	}
+
+	return Achievement{}, false
+}
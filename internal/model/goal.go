@@ -6,43 +6,64 @@ import (
 	"time"
 )
 
+// Goal types. Chapter goals track progress by completed chapters,
+// OKR goals by the normalized progress of their key results.
+const (
+	GoalTypeChapters = "chapters"
+	GoalTypeOKR      = "okr"
+)
+
 type (
 	Goal struct {
-		ID          string    `json:"id"`
-		UserID      string    `json:"user_id"`
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		Chapters    []Chapter `json:"chapters"`
-		Progress    int       `json:"progress"` // Progress is a percentage of completed chapters
-		IsDone      bool      `json:"is_done"`
-		Deadline    time.Time `json:"deadline"`
-		Priority    int       `json:"priority"`
-		Tags        []string  `json:"tags"`
-		Comments    []Comment `json:"comments"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
+		ID          string      `json:"id"`
+		UserID      string      `json:"user_id"`
+		ParentID    string      `json:"parent_id,omitempty"` // ParentID is empty for root goals
+		Type        string      `json:"type"`
+		Title       string      `json:"title"`
+		Description string      `json:"description"`
+		Chapters    []Chapter   `json:"chapters"`
+		KeyResults  []KeyResult `json:"key_results"`
+		Progress    int         `json:"progress"` // Progress is a percentage of completed chapters or key results
+		IsDone      bool        `json:"is_done"`
+		Deadline    time.Time   `json:"deadline"`
+		Priority    int         `json:"priority"`
+		Tags        []string    `json:"tags"`
+		Comments    []Comment   `json:"comments"`
+		Children    []*Goal     `json:"children,omitempty"`
+		Version     int         `json:"version"` // Version is incremented by every update of the editable fields
+		CreatedAt   time.Time   `json:"created_at"`
+		UpdatedAt   time.Time   `json:"updated_at"`
+		DeletedAt   *time.Time  `json:"deleted_at,omitempty"`   // DeletedAt is set while the goal is in the trash
+		ArchivedAt  *time.Time  `json:"archived_at,omitempty"`  // ArchivedAt is set while the goal is archived
+		CompletedAt *time.Time  `json:"completed_at,omitempty"` // CompletedAt is set by storage while the goal is completed
 	}
 
 	Chapter struct {
-		ID          string    `json:"id"`
-		GoalID      string    `json:"goal_id"`
-		Title       string    `json:"title"`
-		Description string    `json:"description"`
-		IsDone      bool      `json:"is_done"`
-		Deadline    time.Time `json:"deadline"`
-		Priority    int       `json:"priority"`
-		Comments    []Comment `json:"comments"`
-		CreatedAt   time.Time `json:"created_at"`
-		UpdatedAt   time.Time `json:"updated_at"`
+		ID          string     `json:"id"`
+		GoalID      string     `json:"goal_id"`
+		Title       string     `json:"title"`
+		Description string     `json:"description"`
+		IsDone      bool       `json:"is_done"`
+		Deadline    time.Time  `json:"deadline"`
+		Priority    int        `json:"priority"`
+		Position    string     `json:"position"`   // Position is a lexicographic rank key, chapters are listed in ascending order
+		DependsOn   []string   `json:"depends_on"` // DependsOn holds IDs of chapters of the same goal that must be done first
+		Comments    []Comment  `json:"comments"`
+		Version     int        `json:"version"` // Version is incremented by every update of the editable fields
+		CreatedAt   time.Time  `json:"created_at"`
+		UpdatedAt   time.Time  `json:"updated_at"`
+		DeletedAt   *time.Time `json:"deleted_at,omitempty"`   // DeletedAt is set while the chapter is in the trash
+		CompletedAt *time.Time `json:"completed_at,omitempty"` // CompletedAt is set by storage while the chapter is done
 	}
 
 	Comment struct {
-		ID        string    `json:"id"`
-		GoalID    string    `json:"goal_id omitempty"`
-		ChapterID string    `json:"chapter_id omitempty"`
-		Content   string    `json:"content"`
-		CreatedAt time.Time `json:"created_at"`
-		UpdatedAt time.Time `json:"updated_at"`
+		ID        string     `json:"id"`
+		GoalID    string     `json:"goal_id,omitempty"`
+		ChapterID string     `json:"chapter_id,omitempty"`
+		Content   string     `json:"content"`
+		CreatedAt time.Time  `json:"created_at"`
+		UpdatedAt time.Time  `json:"updated_at"`
+		DeletedAt *time.Time `json:"deleted_at,omitempty"` // DeletedAt is set while the comment is in the trash
 	}
 )
 
@@ -84,6 +105,11 @@ func NewGoal(
 		return nil, errors.New("priority must be a positive integer")
 	}
 
+	tags = NormalizeTags(tags)
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+
 	if deadline.IsZero() {
 		return nil, errors.New("deadline cannot be zero")
 	}
@@ -103,6 +129,7 @@ func NewGoal(
 	return &Goal{
 		ID:          id,
 		UserID:      userID,
+		Type:        GoalTypeChapters,
 		Title:       title,
 		Description: description,
 		Chapters:    chapters,
@@ -112,6 +139,7 @@ func NewGoal(
 		Priority:    priority,
 		Tags:        tags,
 		Comments:    comments,
+		Version:     1,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
 	}, nil
@@ -135,6 +163,33 @@ func (g *Goal) SetUserID(userID string) (*Goal, error) {
 	return g, nil
 }
 
+// SetParentID attaches the goal to a parent goal. An empty parentID makes it a root goal.
+func (g *Goal) SetParentID(parentID string) (*Goal, error) {
+	if parentID != "" {
+		if _, err := uuid.Parse(parentID); err != nil {
+			return nil, errors.New("parent_id must be a valid UUID")
+		}
+
+		if parentID == g.ID {
+			return nil, errors.New("goal cannot be its own parent")
+		}
+	}
+
+	g.ParentID = parentID
+	return g, nil
+}
+
+func (g *Goal) SetType(goalType string) (*Goal, error) {
+	switch goalType {
+	case GoalTypeChapters, GoalTypeOKR:
+	default:
+		return nil, errors.New("type must be either chapters or okr")
+	}
+
+	g.Type = goalType
+	return g, nil
+}
+
 func (g *Goal) SetTitle(title string) (*Goal, error) {
 	if title == "" {
 		return nil, errors.New("title cannot be empty")
@@ -167,6 +222,16 @@ func (g *Goal) SetIsDone(isDone bool) (*Goal, error) {
 	return g, nil
 }
 
+// ETag identifies the current version of the goal
+func (g *Goal) ETag() string {
+	return versionETag(g.Version)
+}
+
+// IsCompleted reports whether the goal is marked done or all of its chapters or key results are done
+func (g *Goal) IsCompleted() bool {
+	return g.IsDone || g.Progress >= 100
+}
+
 func (g *Goal) SetDeadline(deadline time.Time) (*Goal, error) {
 	if deadline.IsZero() {
 		return nil, errors.New("deadline cannot be zero")
@@ -178,6 +243,16 @@ func (g *Goal) SetDeadline(deadline time.Time) (*Goal, error) {
 	return g, nil
 }
 
+// RestoreDeadline sets a deadline taken from a revision, which unlike SetDeadline may lie in the past
+func (g *Goal) RestoreDeadline(deadline time.Time) (*Goal, error) {
+	if deadline.IsZero() {
+		return nil, errors.New("deadline cannot be zero")
+	}
+
+	g.Deadline = deadline
+	return g, nil
+}
+
 func (g *Goal) SetPriority(priority int) (*Goal, error) {
 	if priority < 0 {
 		return nil, errors.New("priority must be a positive integer")
@@ -187,7 +262,13 @@ func (g *Goal) SetPriority(priority int) (*Goal, error) {
 	return g, nil
 }
 
+// SetTags stores the tags normalized with NormalizeTags
 func (g *Goal) SetTags(tags []string) (*Goal, error) {
+	tags = NormalizeTags(tags)
+	if err := validateTags(tags); err != nil {
+		return nil, err
+	}
+
 	g.Tags = tags
 	return g, nil
 }
@@ -212,6 +293,62 @@ func (g *Goal) RemoveChapter(chapterID string) (*Goal, error) {
 	return nil, errors.New("chapter not found")
 }
 
+func (g *Goal) AddKeyResult(keyResult KeyResult) (*Goal, error) {
+	if g.Type != GoalTypeOKR {
+		return nil, errors.New("key results can only be added to okr goals")
+	}
+
+	g.KeyResults = append(g.KeyResults, keyResult)
+	return g, nil
+}
+
+// CalculateProgress recomputes Progress from chapters or key results depending on the goal type
+func (g *Goal) CalculateProgress() int {
+	switch g.Type {
+	case GoalTypeOKR:
+		if len(g.KeyResults) == 0 {
+			g.Progress = 0
+			break
+		}
+
+		total := 0
+		for i := range g.KeyResults {
+			total += g.KeyResults[i].Progress()
+		}
+		g.Progress = total / len(g.KeyResults)
+	default:
+		if len(g.Chapters) == 0 {
+			g.Progress = 0
+			break
+		}
+
+		done := 0
+		for _, chapter := range g.Chapters {
+			if chapter.IsDone {
+				done++
+			}
+		}
+		g.Progress = done * 100 / len(g.Chapters)
+	}
+
+	return g.Progress
+}
+
+// RollUpProgress sets Progress to the average progress of the children, if the goal has any
+func (g *Goal) RollUpProgress() int {
+	if len(g.Children) == 0 {
+		return g.Progress
+	}
+
+	total := 0
+	for _, child := range g.Children {
+		total += child.Progress
+	}
+	g.Progress = total / len(g.Children)
+
+	return g.Progress
+}
+
 func (g *Goal) SetCreatedAt(createdAt time.Time) (*Goal, error) {
 	if createdAt.IsZero() {
 		return nil, errors.New("created_at cannot be zero")
@@ -290,6 +427,7 @@ func NewChapter(
 		Deadline:    deadline,
 		Priority:    priority,
 		Comments:    comments,
+		Version:     1,
 		CreatedAt:   createdAt,
 		UpdatedAt:   updatedAt,
 	}, nil
@@ -347,6 +485,16 @@ func (c *Chapter) SetDeadline(deadline time.Time) (*Chapter, error) {
 	return c, nil
 }
 
+// RestoreDeadline sets a deadline taken from a revision, which unlike SetDeadline may lie in the past
+func (c *Chapter) RestoreDeadline(deadline time.Time) (*Chapter, error) {
+	if deadline.IsZero() {
+		return nil, errors.New("deadline cannot be zero")
+	}
+
+	c.Deadline = deadline
+	return c, nil
+}
+
 func (c *Chapter) SetPriority(priority int) (*Chapter, error) {
 	if priority < 0 {
 		return nil, errors.New("priority must be a positive integer")
@@ -356,6 +504,39 @@ func (c *Chapter) SetPriority(priority int) (*Chapter, error) {
 	return c, nil
 }
 
+func (c *Chapter) SetPosition(position string) (*Chapter, error) {
+	if position == "" {
+		return nil, errors.New("position cannot be empty")
+	}
+
+	c.Position = position
+	return c, nil
+}
+
+func (c *Chapter) SetDependsOn(dependsOn []string) (*Chapter, error) {
+	seen := make(map[string]struct{}, len(dependsOn))
+	unique := make([]string, 0, len(dependsOn))
+
+	for _, id := range dependsOn {
+		if _, err := uuid.Parse(id); err != nil {
+			return nil, errors.New("depends_on must contain valid UUIDs")
+		}
+
+		if id == c.ID {
+			return nil, errors.New("chapter cannot depend on itself")
+		}
+
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+
+	c.DependsOn = unique
+	return c, nil
+}
+
 func (c *Chapter) SetComments(comments []Comment) (*Chapter, error) {
 	c.Comments = comments
 	return c, nil
@@ -383,6 +564,11 @@ func (c *Chapter) SetUpdatedAt(updatedAt time.Time) (*Chapter, error) {
 	return c, nil
 }
 
+// ETag identifies the current version of the chapter
+func (c *Chapter) ETag() string {
+	return versionETag(c.Version)
+}
+
 func NewComment(
 	id string,
 	goalID string,
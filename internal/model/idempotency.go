@@ -0,0 +1,67 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// MaxIdempotencyKeyLength is the length of the longest Idempotency-Key accepted
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyRecord remembers a mutating request sent with an Idempotency-Key and the response it got.
+// The response is empty while the request is still being handled.
+type IdempotencyRecord struct {
+	Scope       string
+	Key         string
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+func NewIdempotencyRecord(scope, key, fingerprint string, createdAt time.Time, ttl time.Duration) (*IdempotencyRecord, error) {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return nil, errors.New("idempotency key must be between 1 and 255 characters")
+	}
+
+	if fingerprint == "" {
+		return nil, errors.New("fingerprint cannot be empty")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	if ttl <= 0 {
+		return nil, errors.New("ttl must be positive")
+	}
+
+	return &IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		Fingerprint: fingerprint,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(ttl),
+	}, nil
+}
+
+// Completed reports whether the response of the request has been stored
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// IdempotencyHash hashes the parts in order, it is used for both the scope and the request fingerprint
+func IdempotencyHash(parts ...[]byte) string {
+	hash := sha256.New()
+	for _, part := range parts {
+		// the length prefix keeps "ab"+"c" and "a"+"bc" apart
+		hash.Write([]byte{byte(len(part) >> 24), byte(len(part) >> 16), byte(len(part) >> 8), byte(len(part))})
+		hash.Write(part)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,175 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"strings"
+	"time"
+)
+
+type (
+	// KeyResult is a measurable outcome of an OKR goal, e.g. "MRR 10k → 20k USD"
+	KeyResult struct {
+		ID           string    `json:"id"`
+		GoalID       string    `json:"goal_id"`
+		Title        string    `json:"title"`
+		StartValue   float64   `json:"start_value"`
+		TargetValue  float64   `json:"target_value"`
+		CurrentValue float64   `json:"current_value"`
+		Unit         string    `json:"unit"`
+		CheckIns     []CheckIn `json:"check_ins"`
+		CreatedAt    time.Time `json:"created_at"`
+		UpdatedAt    time.Time `json:"updated_at"`
+	}
+
+	// CheckIn is a single recorded value of a key result
+	CheckIn struct {
+		ID          string    `json:"id"`
+		KeyResultID string    `json:"key_result_id"`
+		Value       float64   `json:"value"`
+		Note        string    `json:"note"`
+		CreatedAt   time.Time `json:"created_at"`
+	}
+)
+
+func NewKeyResult(
+	id string,
+	goalID string,
+	title string,
+	startValue float64,
+	targetValue float64,
+	currentValue float64,
+	unit string,
+	createdAt time.Time,
+	updatedAt time.Time) (*KeyResult, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(goalID); err != nil {
+		return nil, errors.New("goal_id must be a valid UUID")
+	}
+
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+
+	if startValue == targetValue {
+		return nil, errors.New("target_value must differ from start_value")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	if updatedAt.Before(createdAt) {
+		return nil, errors.New("updated_at cannot be before created_at")
+	}
+
+	return &KeyResult{
+		ID:           id,
+		GoalID:       goalID,
+		Title:        title,
+		StartValue:   startValue,
+		TargetValue:  targetValue,
+		CurrentValue: currentValue,
+		Unit:         strings.TrimSpace(unit),
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+	}, nil
+}
+
+func (k *KeyResult) SetTitle(title string) (*KeyResult, error) {
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+
+	k.Title = title
+	return k, nil
+}
+
+func (k *KeyResult) SetTargetValue(targetValue float64) (*KeyResult, error) {
+	if targetValue == k.StartValue {
+		return nil, errors.New("target_value must differ from start_value")
+	}
+
+	k.TargetValue = targetValue
+	return k, nil
+}
+
+func (k *KeyResult) SetCurrentValue(currentValue float64) (*KeyResult, error) {
+	k.CurrentValue = currentValue
+	return k, nil
+}
+
+func (k *KeyResult) SetUpdatedAt(updatedAt time.Time) (*KeyResult, error) {
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	} else if updatedAt.Before(k.CreatedAt) {
+		return nil, errors.New("updated_at cannot be before created_at")
+	}
+
+	k.UpdatedAt = updatedAt
+	return k, nil
+}
+
+// AddCheckIn records a new value and moves the current value of the key result to it
+func (k *KeyResult) AddCheckIn(checkIn CheckIn) (*KeyResult, error) {
+	if checkIn.KeyResultID != k.ID {
+		return nil, errors.New("check-in does not belong to key result")
+	}
+
+	k.CheckIns = append(k.CheckIns, checkIn)
+	k.CurrentValue = checkIn.Value
+	return k, nil
+}
+
+// Progress returns the normalized progress between start and target value as a percentage.
+// Decreasing targets (e.g. "churn 8% → 3%") are supported.
+func (k *KeyResult) Progress() int {
+	span := k.TargetValue - k.StartValue
+	if span == 0 {
+		return 0
+	}
+
+	progress := (k.CurrentValue - k.StartValue) / span * 100
+	if progress < 0 {
+		return 0
+	}
+	if progress > 100 {
+		return 100
+	}
+
+	return int(progress)
+}
+
+func NewCheckIn(
+	id string,
+	keyResultID string,
+	value float64,
+	note string,
+	createdAt time.Time) (*CheckIn, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(keyResultID); err != nil {
+		return nil, errors.New("key_result_id must be a valid UUID")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	return &CheckIn{
+		ID:          id,
+		KeyResultID: keyResultID,
+		Value:       value,
+		Note:        strings.TrimSpace(note),
+		CreatedAt:   createdAt,
+	}, nil
+}
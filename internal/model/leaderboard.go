@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"time"
+)
+
+// Leaderboard periods, they are also the periods of the XP totals
+const (
+	LeaderboardWeek  = "week"
+	LeaderboardMonth = "month"
+)
+
+// Leaderboard rankings
+const (
+	LeaderboardByXP       = "xp"
+	LeaderboardByChapters = "chapters"
+)
+
+type (
+	// Leaderboard ranks the user and their friends by the XP or chapters of the current week or month.
+	// Friends hiding from leaderboards are left out.
+	Leaderboard struct {
+		Period  string              `json:"period"`
+		Metric  string              `json:"metric"`
+		Start   time.Time           `json:"start"`
+		Entries []*LeaderboardEntry `json:"entries"`
+	}
+
+	// LeaderboardEntry is a place on the leaderboard, users with the same score share a rank
+	LeaderboardEntry struct {
+		Rank      int    `json:"rank"`
+		UserID    string `json:"user_id"`
+		FirstName string `json:"first_name"`
+		LastName  string `json:"last_name"`
+		XP        int    `json:"xp"`
+		Chapters  int    `json:"chapters"`
+		Me        bool   `json:"me"` // Me marks the entry of the user asking
+	}
+)
+
+// NewLeaderboard checks the period and metric, empty ones default to the weekly XP ranking. Start is the
+// beginning of the period containing now, weeks start on Monday and periods are in UTC.
+func NewLeaderboard(period, metric string, now time.Time) (*Leaderboard, error) {
+	if period == "" {
+		period = LeaderboardWeek
+	}
+
+	if metric == "" {
+		metric = LeaderboardByXP
+	}
+
+	start, err := PeriodStart(period, now)
+	if err != nil {
+		return nil, err
+	}
+
+	switch metric {
+	case LeaderboardByXP, LeaderboardByChapters:
+	default:
+		return nil, errors.New("metric must be xp or chapters")
+	}
+
+	return &Leaderboard{Period: period, Metric: metric, Start: start, Entries: []*LeaderboardEntry{}}, nil
+}
+
+// PeriodStart returns the beginning of the week or month containing t, in UTC
+func PeriodStart(period string, t time.Time) (time.Time, error) {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch period {
+	case LeaderboardWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7)), nil
+	case LeaderboardMonth:
+		return day.AddDate(0, 0, 1-day.Day()), nil
+	default:
+		return time.Time{}, errors.New("period must be week or month")
+	}
+}
@@ -0,0 +1,190 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	// GoalRevision is a numbered snapshot of a goal and its chapters taken after a change
+	GoalRevision struct {
+		ID        string        `json:"id"`
+		GoalID    string        `json:"goal_id"`
+		Number    int           `json:"number"`
+		ActorID   string        `json:"actor_id"`
+		Snapshot  *GoalSnapshot `json:"snapshot,omitempty"` // Snapshot is omitted when revisions are listed
+		CreatedAt time.Time     `json:"created_at"`
+	}
+
+	// GoalSnapshot holds the user editable fields of a goal and its chapters in position order.
+	// Times are kept in UTC, so equal snapshots have equal JSON encodings.
+	GoalSnapshot struct {
+		Type        string            `json:"type"`
+		ParentID    string            `json:"parent_id,omitempty"`
+		Title       string            `json:"title"`
+		Description string            `json:"description"`
+		Deadline    time.Time         `json:"deadline"`
+		Priority    int               `json:"priority"`
+		Tags        []string          `json:"tags"`
+		Chapters    []ChapterSnapshot `json:"chapters"`
+	}
+
+	ChapterSnapshot struct {
+		ID          string    `json:"id"`
+		Title       string    `json:"title"`
+		Description string    `json:"description"`
+		IsDone      bool      `json:"is_done"`
+		Deadline    time.Time `json:"deadline"`
+		Priority    int       `json:"priority"`
+		Position    string    `json:"position"`
+		DependsOn   []string  `json:"depends_on"`
+	}
+
+	// RevisionDiff lists the goal fields and chapters that differ between two revisions
+	RevisionDiff struct {
+		From     int                    `json:"from"`
+		To       int                    `json:"to"`
+		Changes  map[string]FieldChange `json:"changes"`
+		Chapters []ChapterChange        `json:"chapters"`
+	}
+
+	// ChapterChange describes a chapter that was added (create), removed (delete) or changed (update)
+	ChapterChange struct {
+		ChapterID string                 `json:"chapter_id"`
+		Action    string                 `json:"action"`
+		Changes   map[string]FieldChange `json:"changes"`
+	}
+)
+
+func NewGoalRevision(
+	id string,
+	goalID string,
+	number int,
+	actorID string,
+	snapshot *GoalSnapshot,
+	createdAt time.Time) (*GoalRevision, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(goalID); err != nil {
+		return nil, errors.New("goal_id must be a valid UUID")
+	}
+
+	if number < 1 {
+		return nil, errors.New("number must be positive")
+	}
+
+	if actorID == "" {
+		return nil, errors.New("actor_id cannot be empty")
+	}
+
+	if snapshot == nil {
+		return nil, errors.New("snapshot cannot be empty")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	return &GoalRevision{
+		ID:        id,
+		GoalID:    goalID,
+		Number:    number,
+		ActorID:   actorID,
+		Snapshot:  snapshot,
+		CreatedAt: createdAt,
+	}, nil
+}
+
+// NewGoalSnapshot captures the goal and its chapters, which must be sorted by position
+func NewGoalSnapshot(goal Goal, chapters []Chapter) *GoalSnapshot {
+	snapshot := &GoalSnapshot{
+		Type:        goal.Type,
+		ParentID:    goal.ParentID,
+		Title:       goal.Title,
+		Description: goal.Description,
+		Deadline:    goal.Deadline.UTC(),
+		Priority:    goal.Priority,
+		Tags:        append([]string{}, goal.Tags...),
+		Chapters:    make([]ChapterSnapshot, 0, len(chapters)),
+	}
+
+	for _, chapter := range chapters {
+		snapshot.Chapters = append(snapshot.Chapters, ChapterSnapshot{
+			ID:          chapter.ID,
+			Title:       chapter.Title,
+			Description: chapter.Description,
+			IsDone:      chapter.IsDone,
+			Deadline:    chapter.Deadline.UTC(),
+			Priority:    chapter.Priority,
+			Position:    chapter.Position,
+			DependsOn:   append([]string{}, chapter.DependsOn...),
+		})
+	}
+
+	return snapshot
+}
+
+// DiffSnapshots compares the goal fields and the chapters of two snapshots
+func DiffSnapshots(from, to *GoalSnapshot) (map[string]FieldChange, []ChapterChange, error) {
+	changes, err := Diff(from, to)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fromChapters := make(map[string]*ChapterSnapshot, len(from.Chapters))
+	for i := range from.Chapters {
+		fromChapters[from.Chapters[i].ID] = &from.Chapters[i]
+	}
+
+	chapterChanges := make([]ChapterChange, 0)
+	for i := range to.Chapters {
+		chapter := &to.Chapters[i]
+
+		previous, ok := fromChapters[chapter.ID]
+		delete(fromChapters, chapter.ID)
+
+		action := AuditActionUpdate
+		if !ok {
+			action = AuditActionCreate
+		}
+
+		fields, err := Diff(previous, chapter)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if len(fields) > 0 {
+			chapterChanges = append(chapterChanges, ChapterChange{ChapterID: chapter.ID, Action: action, Changes: fields})
+		}
+	}
+
+	// removed chapters are reported in their original order
+	for i := range from.Chapters {
+		chapter := &from.Chapters[i]
+		if _, ok := fromChapters[chapter.ID]; !ok {
+			continue
+		}
+
+		fields, err := Diff(chapter, nil)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		chapterChanges = append(chapterChanges, ChapterChange{ChapterID: chapter.ID, Action: AuditActionDelete, Changes: fields})
+	}
+
+	return changes, chapterChanges, nil
+}
+
+// Equal reports whether both snapshots hold the same goal fields and chapters
+func (s *GoalSnapshot) Equal(other *GoalSnapshot) (bool, error) {
+	changes, chapterChanges, err := DiffSnapshots(s, other)
+	if err != nil {
+		return false, err
+	}
+
+	return len(changes) == 0 && len(chapterChanges) == 0, nil
+}
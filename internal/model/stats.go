@@ -0,0 +1,266 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+const (
+	DefaultStatsWeeks = 12
+	MaxStatsWeeks     = 104
+)
+
+// Kinds of completion records
+const (
+	RecordGoal    = "goal"
+	RecordChapter = "chapter"
+)
+
+type (
+	// CompletionRecord is the part of a goal or chapter the statistics are computed from
+	CompletionRecord struct {
+		Kind        string
+		CreatedAt   time.Time
+		CompletedAt *time.Time
+		Deadline    *time.Time
+		Tags        []string // Tags are only set for goals
+	}
+
+	// Stats are insights into how a user works on their goals. Weekdays and hours are in the requested time zone.
+	Stats struct {
+		Weeks          int                `json:"weeks"`
+		TimeZone       string             `json:"time_zone"`
+		CompletionRate []CompletionPeriod `json:"completion_rate"`
+		TimeToComplete TimeToComplete     `json:"time_to_complete"`
+		Punctuality    Punctuality        `json:"punctuality"`
+		Productivity   Productivity       `json:"productivity"`
+		Tags           []TagStats         `json:"tags"`
+		Focus          []*GoalFocus       `json:"focus"` // Focus is the time invested in goals next to their progress
+		GeneratedAt    time.Time          `json:"generated_at"`
+	}
+
+	// CompletionPeriod tells how many of the goals and chapters created in the week starting at Start are completed
+	CompletionPeriod struct {
+		Start     time.Time `json:"start"`
+		Created   int       `json:"created"`
+		Completed int       `json:"completed"`
+		Rate      float64   `json:"rate"`
+	}
+
+	// TimeToComplete is the average time from creation to completion in hours
+	TimeToComplete struct {
+		GoalHours    float64 `json:"goal_hours"`
+		GoalCount    int     `json:"goal_count"`
+		ChapterHours float64 `json:"chapter_hours"`
+		ChapterCount int     `json:"chapter_count"`
+	}
+
+	// Punctuality compares completions to deadlines, items completed without a deadline are not counted
+	Punctuality struct {
+		OnTime      int     `json:"on_time"`
+		Late        int     `json:"late"`
+		OnTimeRatio float64 `json:"on_time_ratio"`
+	}
+
+	// Productivity counts completions by weekday, Sunday first, and by hour of the day
+	Productivity struct {
+		ByWeekday   [7]int  `json:"by_weekday"`
+		ByHour      [24]int `json:"by_hour"`
+		BestWeekday string  `json:"best_weekday,omitempty"`
+		BestHour    *int    `json:"best_hour,omitempty"`
+	}
+
+	// TagStats are the goals of a tag
+	TagStats struct {
+		Tag          string  `json:"tag"`
+		Goals        int     `json:"goals"`
+		Completed    int     `json:"completed"`
+		Rate         float64 `json:"rate"`
+		AverageHours float64 `json:"average_hours"`
+	}
+)
+
+// ParseStatsWeeks checks the number of weeks the completion rate covers, zero means the default
+func ParseStatsWeeks(weeks int) (int, error) {
+	if weeks == 0 {
+		return DefaultStatsWeeks, nil
+	}
+
+	if weeks < 1 || weeks > MaxStatsWeeks {
+		return 0, fmt.Errorf("weeks must be between 1 and %d", MaxStatsWeeks)
+	}
+
+	return weeks, nil
+}
+
+// ComputeStats computes the statistics of the records. The completion rate covers the given number of weeks up to
+// now, the other figures cover all records.
+func ComputeStats(records []CompletionRecord, now time.Time, weeks int, loc *time.Location) *Stats {
+	stats := &Stats{
+		Weeks:       weeks,
+		TimeZone:    loc.String(),
+		GeneratedAt: now,
+		Tags:        []TagStats{},
+		Focus:       []*GoalFocus{},
+	}
+
+	stats.CompletionRate = completionRate(records, now.In(loc), weeks)
+
+	var goalHours, chapterHours float64
+	tags := make(map[string]*tagTotals)
+
+	for _, record := range records {
+		if record.Kind == RecordGoal {
+			for _, tag := range record.Tags {
+				totals, ok := tags[tag]
+				if !ok {
+					totals = &tagTotals{}
+					tags[tag] = totals
+				}
+				totals.add(record)
+			}
+		}
+
+		if record.CompletedAt == nil {
+			continue
+		}
+
+		hours := completionHours(record)
+		switch record.Kind {
+		case RecordGoal:
+			goalHours += hours
+			stats.TimeToComplete.GoalCount++
+		case RecordChapter:
+			chapterHours += hours
+			stats.TimeToComplete.ChapterCount++
+		}
+
+		if record.Deadline != nil {
+			if record.CompletedAt.After(*record.Deadline) {
+				stats.Punctuality.Late++
+			} else {
+				stats.Punctuality.OnTime++
+			}
+		}
+
+		completed := record.CompletedAt.In(loc)
+		stats.Productivity.ByWeekday[completed.Weekday()]++
+		stats.Productivity.ByHour[completed.Hour()]++
+	}
+
+	stats.TimeToComplete.GoalHours = average(goalHours, stats.TimeToComplete.GoalCount)
+	stats.TimeToComplete.ChapterHours = average(chapterHours, stats.TimeToComplete.ChapterCount)
+	stats.Punctuality.OnTimeRatio = ratio(stats.Punctuality.OnTime, stats.Punctuality.OnTime+stats.Punctuality.Late)
+
+	if weekday := busiest(stats.Productivity.ByWeekday[:]); weekday >= 0 {
+		stats.Productivity.BestWeekday = time.Weekday(weekday).String()
+	}
+	if hour := busiest(stats.Productivity.ByHour[:]); hour >= 0 {
+		stats.Productivity.BestHour = &hour
+	}
+
+	for tag, totals := range tags {
+		stats.Tags = append(stats.Tags, TagStats{
+			Tag:          tag,
+			Goals:        totals.goals,
+			Completed:    totals.completed,
+			Rate:         ratio(totals.completed, totals.goals),
+			AverageHours: average(totals.hours, totals.completed),
+		})
+	}
+	sort.Slice(stats.Tags, func(i, j int) bool {
+		if stats.Tags[i].Goals != stats.Tags[j].Goals {
+			return stats.Tags[i].Goals > stats.Tags[j].Goals
+		}
+		return stats.Tags[i].Tag < stats.Tags[j].Tag
+	})
+
+	return stats
+}
+
+type tagTotals struct {
+	goals     int
+	completed int
+	hours     float64
+}
+
+func (t *tagTotals) add(record CompletionRecord) {
+	t.goals++
+	if record.CompletedAt != nil {
+		t.completed++
+		t.hours += completionHours(record)
+	}
+}
+
+// completionRate groups the records created in the last weeks by the week they were created in, weeks start on Monday
+func completionRate(records []CompletionRecord, now time.Time, weeks int) []CompletionPeriod {
+	daysSinceMonday := (int(now.Weekday()) + 6) % 7
+	thisWeek := time.Date(now.Year(), now.Month(), now.Day()-daysSinceMonday, 0, 0, 0, 0, now.Location())
+	first := thisWeek.AddDate(0, 0, -7*(weeks-1))
+
+	periods := make([]CompletionPeriod, weeks)
+	for i := range periods {
+		periods[i].Start = first.AddDate(0, 0, 7*i)
+	}
+
+	for _, record := range records {
+		created := record.CreatedAt.In(now.Location())
+		if created.Before(first) {
+			continue
+		}
+
+		i := weeks - 1
+		for i > 0 && created.Before(periods[i].Start) {
+			i--
+		}
+
+		periods[i].Created++
+		if record.CompletedAt != nil {
+			periods[i].Completed++
+		}
+	}
+
+	for i := range periods {
+		periods[i].Rate = ratio(periods[i].Completed, periods[i].Created)
+	}
+
+	return periods
+}
+
+func completionHours(record CompletionRecord) float64 {
+	hours := record.CompletedAt.Sub(record.CreatedAt).Hours()
+	if hours < 0 {
+		return 0
+	}
+
+	return hours
+}
+
+// busiest returns the index of the largest count, or -1 when all counts are zero
+func busiest(counts []int) int {
+	best := -1
+	for i, count := range counts {
+		if count > 0 && (best < 0 || count > counts[best]) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func average(total float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+
+	return total / float64(count)
+}
+
+func ratio(part, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+
+	return float64(part) / float64(total)
+}
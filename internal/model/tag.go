@@ -0,0 +1,160 @@
+package model
+
+import (
+	"errors"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	MaxTagLength   = 64
+	maxEmojiLength = 16
+
+	DefaultTagSuggestions = 10
+	MaxTagSuggestions     = 50
+)
+
+var tagColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// Tag is a label of the goals of a user. Goals reference tags by their normalized name, the tag itself
+// adds the color and emoji shown next to it.
+type Tag struct {
+	ID         string    `json:"id"`
+	UserID     string    `json:"user_id"`
+	Name       string    `json:"name"`
+	Color      string    `json:"color,omitempty"`
+	Emoji      string    `json:"emoji,omitempty"`
+	UsageCount int       `json:"usage_count"` // UsageCount is the number of goals outside of the trash using the tag
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+func NewTag(id, userID, name, color, emoji string, createdAt time.Time) (*Tag, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	if userID == "" {
+		return nil, errors.New("user_id cannot be empty")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	tag := &Tag{
+		ID:        id,
+		UserID:    userID,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	if _, err := tag.SetName(name); err != nil {
+		return nil, err
+	}
+
+	if _, err := tag.SetColor(color); err != nil {
+		return nil, err
+	}
+
+	if _, err := tag.SetEmoji(emoji); err != nil {
+		return nil, err
+	}
+
+	return tag, nil
+}
+
+// SetName normalizes the name with NormalizeTag
+func (t *Tag) SetName(name string) (*Tag, error) {
+	name = NormalizeTag(name)
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	if utf8.RuneCountInString(name) > MaxTagLength {
+		return nil, errors.New("name cannot be longer than 64 characters")
+	}
+
+	t.Name = name
+	return t, nil
+}
+
+// SetColor accepts a hex color such as #4caf50, an empty color removes it
+func (t *Tag) SetColor(color string) (*Tag, error) {
+	color = strings.ToLower(strings.TrimSpace(color))
+	if color != "" && !tagColorPattern.MatchString(color) {
+		return nil, errors.New("color must be a hex color such as #4caf50")
+	}
+
+	t.Color = color
+	return t, nil
+}
+
+// SetEmoji accepts a single emoji, which may be built from several code points, an empty emoji removes it
+func (t *Tag) SetEmoji(emoji string) (*Tag, error) {
+	emoji = strings.TrimSpace(emoji)
+	if len(emoji) > maxEmojiLength {
+		return nil, errors.New("emoji cannot be longer than 16 bytes")
+	}
+
+	for _, r := range emoji {
+		if r < utf8.RuneSelf || unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsSpace(r) {
+			return nil, errors.New("emoji must be a single emoji")
+		}
+	}
+
+	t.Emoji = emoji
+	return t, nil
+}
+
+func (t *Tag) SetUpdatedAt(updatedAt time.Time) (*Tag, error) {
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	if updatedAt.Before(t.CreatedAt) {
+		return nil, errors.New("updated_at cannot be before created_at")
+	}
+
+	t.UpdatedAt = updatedAt
+	return t, nil
+}
+
+// NormalizeTag lowercases the tag and collapses its whitespace, so "Fitness" and " fitness " are the same tag
+func NormalizeTag(tag string) string {
+	return strings.ToLower(strings.Join(strings.Fields(tag), " "))
+}
+
+// validateTags checks the length of normalized tags
+func validateTags(tags []string) error {
+	for _, tag := range tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			return errors.New("tags cannot be longer than 64 characters")
+		}
+	}
+
+	return nil
+}
+
+// NormalizeTags normalizes every tag, dropping empty and duplicate ones while keeping the order
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = NormalizeTag(tag)
+		if tag == "" || slices.Contains(normalized, tag) {
+			continue
+		}
+
+		normalized = append(normalized, tag)
+	}
+
+	return normalized
+}
@@ -0,0 +1,197 @@
+package model
+
+import (
+	"errors"
+	"github.com/google/uuid"
+	"time"
+)
+
+type (
+	// GoalTemplate is a reusable goal structure. Templates without UserID are built-in.
+	GoalTemplate struct {
+		ID                 string             `json:"id"`
+		UserID             string             `json:"user_id,omitempty"`
+		Title              string             `json:"title"`
+		Description        string             `json:"description"`
+		Tags               []string           `json:"tags"`
+		IsPublic           bool               `json:"is_public"`
+		DeadlineOffsetDays int                `json:"deadline_offset_days"`
+		Chapters           []ChapterBlueprint `json:"chapters"`
+		CreatedAt          time.Time          `json:"created_at"`
+		UpdatedAt          time.Time          `json:"updated_at"`
+	}
+
+	// ChapterBlueprint describes a chapter of a template, its deadline is relative to the goal start date
+	ChapterBlueprint struct {
+		ID                 string `json:"id"`
+		TemplateID         string `json:"template_id"`
+		Title              string `json:"title"`
+		Description        string `json:"description"`
+		DeadlineOffsetDays int    `json:"deadline_offset_days"`
+		Priority           int    `json:"priority"`
+	}
+)
+
+func NewGoalTemplate(
+	id string,
+	userID string,
+	title string,
+	description string,
+	tags []string,
+	isPublic bool,
+	deadlineOffsetDays int,
+	chapters []ChapterBlueprint,
+	createdAt time.Time,
+	updatedAt time.Time) (*GoalTemplate, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+
+	if description == "" {
+		return nil, errors.New("description cannot be empty")
+	}
+
+	if deadlineOffsetDays < 0 {
+		return nil, errors.New("deadline_offset_days must be a positive integer")
+	}
+
+	for _, chapter := range chapters {
+		if chapter.DeadlineOffsetDays > deadlineOffsetDays && deadlineOffsetDays != 0 {
+			return nil, errors.New("chapter deadline cannot be after the goal deadline")
+		}
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	if updatedAt.Before(createdAt) {
+		return nil, errors.New("updated_at cannot be before created_at")
+	}
+
+	return &GoalTemplate{
+		ID:                 id,
+		UserID:             userID,
+		Title:              title,
+		Description:        description,
+		Tags:               tags,
+		IsPublic:           isPublic,
+		DeadlineOffsetDays: deadlineOffsetDays,
+		Chapters:           chapters,
+		CreatedAt:          createdAt,
+		UpdatedAt:          updatedAt,
+	}, nil
+}
+
+func NewChapterBlueprint(
+	id string,
+	templateID string,
+	title string,
+	description string,
+	deadlineOffsetDays int,
+	priority int) (*ChapterBlueprint, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(templateID); err != nil {
+		return nil, errors.New("template_id must be a valid UUID")
+	}
+
+	if title == "" {
+		return nil, errors.New("title cannot be empty")
+	}
+
+	if description == "" {
+		return nil, errors.New("description cannot be empty")
+	}
+
+	if deadlineOffsetDays <= 0 {
+		return nil, errors.New("deadline_offset_days must be greater than zero")
+	}
+
+	if priority < 0 {
+		return nil, errors.New("priority must be a positive integer")
+	}
+
+	return &ChapterBlueprint{
+		ID:                 id,
+		TemplateID:         templateID,
+		Title:              title,
+		Description:        description,
+		DeadlineOffsetDays: deadlineOffsetDays,
+		Priority:           priority,
+	}, nil
+}
+
+// IsBuiltIn reports whether the template ships with Strive
+func (t *GoalTemplate) IsBuiltIn() bool {
+	return t.UserID == ""
+}
+
+// IsVisibleTo reports whether the user may see and instantiate the template
+func (t *GoalTemplate) IsVisibleTo(userID string) bool {
+	return t.IsBuiltIn() || t.IsPublic || t.UserID == userID
+}
+
+// Instantiate builds a goal with its chapters for the user, deadlines are counted from start.
+// The goal deadline falls back to the latest chapter deadline when the template has no own offset.
+func (t *GoalTemplate) Instantiate(userID string, start time.Time, now time.Time) (*Goal, error) {
+	offset := t.DeadlineOffsetDays
+	for _, blueprint := range t.Chapters {
+		if blueprint.DeadlineOffsetDays > offset {
+			offset = blueprint.DeadlineOffsetDays
+		}
+	}
+
+	goal, err := NewGoal(
+		uuid.NewString(),
+		userID,
+		t.Title,
+		t.Description,
+		nil,
+		0,
+		false,
+		start.AddDate(0, 0, offset),
+		0,
+		t.Tags,
+		nil,
+		now,
+		now,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, blueprint := range t.Chapters {
+		chapter, err := NewChapter(
+			uuid.NewString(),
+			goal.ID,
+			blueprint.Title,
+			blueprint.Description,
+			false,
+			start.AddDate(0, 0, blueprint.DeadlineOffsetDays),
+			blueprint.Priority,
+			nil,
+			now,
+			now,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := goal.AddChapter(*chapter); err != nil {
+			return nil, err
+		}
+	}
+
+	return goal, nil
+}
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Trashed item types
+const (
+	TrashGoal    = "goal"
+	TrashChapter = "chapter"
+	TrashComment = "comment"
+)
+
+// TrashItem is a goal, chapter or comment in the trash. Goals are listed without the sub-goals trashed with them,
+// chapters and comments only while their goal is not in the trash.
+type TrashItem struct {
+	Type      string     `json:"type"`
+	ID        string     `json:"id"`
+	GoalID    string     `json:"goal_id"`
+	Title     string     `json:"title"` // Title is the content of comments
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgeAt   *time.Time `json:"purge_at,omitempty"` // PurgeAt is nil when the trash is never purged
+}
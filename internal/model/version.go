@@ -0,0 +1,25 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+func versionETag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ParseVersionETag returns the version behind an ETag of a goal or chapter. Weak tags are rejected,
+// If-Match compares tags strongly.
+func ParseVersionETag(etag string) (int, bool) {
+	if len(etag) < 3 || !strings.HasPrefix(etag, `"`) || !strings.HasSuffix(etag, `"`) {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(etag[1 : len(etag)-1])
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const MaxViewNameLength = 64
+
+// SavedView is a named filter expression of a user, such as "work" for `tag:work due<30d`
+type SavedView struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	Name      string    `json:"name"`
+	Filter    string    `json:"filter"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func NewSavedView(id, userID, name, filter string, createdAt time.Time) (*SavedView, error) {
+	if id == "" {
+		return nil, errors.New("id cannot be empty")
+	}
+
+	if userID == "" {
+		return nil, errors.New("user_id cannot be empty")
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	view := &SavedView{
+		ID:        id,
+		UserID:    userID,
+		CreatedAt: createdAt,
+		UpdatedAt: createdAt,
+	}
+
+	if _, err := view.SetName(name); err != nil {
+		return nil, err
+	}
+
+	if _, err := view.SetFilter(filter); err != nil {
+		return nil, err
+	}
+
+	return view, nil
+}
+
+// SetName trims the name, names are unique per user regardless of case
+func (v *SavedView) SetName(name string) (*SavedView, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, errors.New("name cannot be empty")
+	}
+
+	if utf8.RuneCountInString(name) > MaxViewNameLength {
+		return nil, errors.New("name cannot be longer than 64 characters")
+	}
+
+	v.Name = name
+	return v, nil
+}
+
+// SetFilter accepts a filter expression that ParseFilter can parse
+func (v *SavedView) SetFilter(filter string) (*SavedView, error) {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil, errors.New("filter cannot be empty")
+	}
+
+	if _, err := ParseFilter(filter); err != nil {
+		return nil, err
+	}
+
+	v.Filter = filter
+	return v, nil
+}
+
+func (v *SavedView) SetUpdatedAt(updatedAt time.Time) (*SavedView, error) {
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	if updatedAt.Before(v.CreatedAt) {
+		return nil, errors.New("updated_at cannot be before created_at")
+	}
+
+	v.UpdatedAt = updatedAt
+	return v, nil
+}
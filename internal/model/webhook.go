@@ -0,0 +1,310 @@
+package model
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"github.com/google/uuid"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WebhookEvents are the domain events users can subscribe webhooks to
+var WebhookEvents = []string{EventGoalCreated, EventGoalCompleted, EventChapterCompleted, EventDeadlineMissed, EventCommentCreated}
+
+// Delivery statuses
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+const (
+	// MaxDeliveryAttempts is the number of attempts after which a delivery is given up
+	MaxDeliveryAttempts = 8
+	// MaxWebhookFailures is the number of consecutive failed attempts after which a webhook is disabled
+	MaxWebhookFailures = 20
+
+	deliveryBaseBackoff = 30 * time.Second
+	deliveryMaxBackoff  = 6 * time.Hour
+)
+
+type (
+	// Webhook is an endpoint of a user that receives signed event payloads
+	Webhook struct {
+		ID           string     `json:"id"`
+		UserID       string     `json:"user_id"`
+		URL          string     `json:"url"`
+		Secret       string     `json:"-"` // Secret signs the payloads, it is only shown when the webhook is created
+		Events       []string   `json:"events"`
+		IsActive     bool       `json:"is_active"`
+		FailureCount int        `json:"failure_count"`
+		DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+		CreatedAt    time.Time  `json:"created_at"`
+		UpdatedAt    time.Time  `json:"updated_at"`
+	}
+
+	// WebhookDelivery is a single event sent to a webhook, retried until it succeeds or runs out of attempts
+	WebhookDelivery struct {
+		ID             string           `json:"id"`
+		WebhookID      string           `json:"webhook_id"`
+		Event          string           `json:"event"`
+		Payload        string           `json:"payload"`
+		Status         string           `json:"status"`
+		Attempts       int              `json:"attempts"`
+		NextAttemptAt  time.Time        `json:"next_attempt_at"`
+		LastStatusCode int              `json:"last_status_code,omitempty"`
+		LastError      string           `json:"last_error,omitempty"`
+		AttemptLog     []WebhookAttempt `json:"attempt_log,omitempty"`
+		CreatedAt      time.Time        `json:"created_at"`
+		UpdatedAt      time.Time        `json:"updated_at"`
+	}
+
+	WebhookAttempt struct {
+		ID          string    `json:"id"`
+		DeliveryID  string    `json:"delivery_id"`
+		StatusCode  int       `json:"status_code,omitempty"`
+		Error       string    `json:"error,omitempty"`
+		DurationMS  int64     `json:"duration_ms"`
+		AttemptedAt time.Time `json:"attempted_at"`
+	}
+)
+
+func NewWebhook(
+	id string,
+	userID string,
+	rawURL string,
+	events []string,
+	createdAt time.Time,
+	updatedAt time.Time) (*Webhook, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, errors.New("user_id must be a valid UUID")
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, errors.New("url must be an absolute https URL")
+	}
+
+	if err := ValidateWebhookURL(parsed); err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, errors.New("events cannot be empty")
+	}
+
+	for _, event := range events {
+		if !IsWebhookEvent(event) {
+			return nil, errors.New("unknown event " + event)
+		}
+	}
+
+	if createdAt.IsZero() {
+		return nil, errors.New("created_at cannot be zero")
+	}
+
+	if updatedAt.IsZero() {
+		return nil, errors.New("updated_at cannot be zero")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+
+	return &Webhook{
+		ID:        id,
+		UserID:    userID,
+		URL:       rawURL,
+		Secret:    hex.EncodeToString(secret),
+		Events:    events,
+		IsActive:  true,
+		CreatedAt: createdAt,
+		UpdatedAt: updatedAt,
+	}, nil
+}
+
+// ErrWebhookAddress is returned for webhook URLs pointing to the server itself or to a private network
+var ErrWebhookAddress = errors.New("url must not point to a private, loopback or link-local address")
+
+// ValidateWebhookURL checks that webhooks can be sent to u. Only https is allowed, and hosts must not be local
+// names or non-public addresses. Names resolving to non-public addresses are rejected when connecting.
+func ValidateWebhookURL(u *url.URL) error {
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return errors.New("url must be an absolute https URL")
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrWebhookAddress
+	}
+
+	if addr, err := netip.ParseAddr(host); err == nil && !IsPublicAddress(addr) {
+		return ErrWebhookAddress
+	}
+
+	return nil
+}
+
+// reservedPrefixes are not routable on the internet but are not covered by the netip predicates:
+// "this network" and the carrier-grade NAT range of RFC 6598
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// IsPublicAddress reports whether addr is a globally routable unicast address. Loopback, link-local,
+// private and reserved addresses are not.
+func IsPublicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+
+	for _, prefix := range reservedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func IsWebhookEvent(event string) bool {
+	for _, known := range WebhookEvents {
+		if known == event {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<payload>". Including the timestamp lets receivers reject replays.
+func (w *Webhook) Sign(timestamp time.Time, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(w.Secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RecordFailure counts a failed attempt and disables the webhook after MaxWebhookFailures in a row
+func (w *Webhook) RecordFailure(now time.Time) {
+	w.FailureCount++
+	w.UpdatedAt = now
+
+	if w.FailureCount >= MaxWebhookFailures && w.IsActive {
+		w.IsActive = false
+		w.DisabledAt = &now
+	}
+}
+
+func (w *Webhook) RecordSuccess(now time.Time) {
+	w.FailureCount = 0
+	w.UpdatedAt = now
+}
+
+// Enable reactivates a disabled webhook and resets its failure count
+func (w *Webhook) Enable(now time.Time) {
+	w.IsActive = true
+	w.FailureCount = 0
+	w.DisabledAt = nil
+	w.UpdatedAt = now
+}
+
+func NewWebhookDelivery(id, webhookID, event, payload string, now time.Time) (*WebhookDelivery, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, errors.New("id must be a valid UUID")
+	}
+
+	if _, err := uuid.Parse(webhookID); err != nil {
+		return nil, errors.New("webhook_id must be a valid UUID")
+	}
+
+	if !IsWebhookEvent(event) {
+		return nil, errors.New("unknown event " + event)
+	}
+
+	return &WebhookDelivery{
+		ID:            id,
+		WebhookID:     webhookID,
+		Event:         event,
+		Payload:       payload,
+		Status:        DeliveryPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}, nil
+}
+
+// RecordAttempt updates the delivery with the outcome of an attempt and schedules the next one with
+// exponential backoff, the delivery fails for good after MaxDeliveryAttempts
+func (d *WebhookDelivery) RecordAttempt(attempt WebhookAttempt) {
+	d.Attempts++
+	d.LastStatusCode = attempt.StatusCode
+	d.LastError = attempt.Error
+	d.UpdatedAt = attempt.AttemptedAt
+
+	switch {
+	case attempt.Succeeded():
+		d.Status = DeliverySucceeded
+	case d.Attempts >= MaxDeliveryAttempts:
+		d.Status = DeliveryFailed
+	default:
+		d.NextAttemptAt = attempt.AttemptedAt.Add(DeliveryBackoff(d.Attempts))
+	}
+}
+
+// Replay schedules a failed delivery again with a fresh set of attempts
+func (d *WebhookDelivery) Replay(now time.Time) error {
+	if d.Status != DeliveryFailed {
+		return errors.New("only failed deliveries can be replayed")
+	}
+
+	d.Status = DeliveryPending
+	d.Attempts = 0
+	d.NextAttemptAt = now
+	d.UpdatedAt = now
+
+	return nil
+}
+
+// DeliveryBackoff returns the delay after the given number of attempts: 30s, 1m, 2m, ... capped at 6h
+func DeliveryBackoff(attempts int) time.Duration {
+	backoff := deliveryBaseBackoff
+	for i := 1; i < attempts && backoff < deliveryMaxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > deliveryMaxBackoff {
+		return deliveryMaxBackoff
+	}
+
+	return backoff
+}
+
+func (a WebhookAttempt) Succeeded() bool {
+	return a.Error == "" && a.StatusCode >= 200 && a.StatusCode < 300
+}
+
+// MissedDeadline is an open goal or chapter whose deadline has passed
+type MissedDeadline struct {
+	EntityType string    `json:"entity_type"` // EntityType is "goal" or "chapter"
+	EntityID   string    `json:"entity_id"`
+	GoalID     string    `json:"goal_id"`
+	UserID     string    `json:"-"`
+	Title      string    `json:"title"`
+	Deadline   time.Time `json:"deadline"`
+}
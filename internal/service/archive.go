@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+const autoArchiveInterval = time.Hour
+
+func (s *goalService) List(ctx context.Context, userID string, filter model.GoalFilter) ([]*model.Goal, error) {
+	const op = "goalService.List"
+
+	filter, err := model.NewGoalFilter(filter.Archived, filter.Query, filter.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	goals, err := s.goalStorage.GetByUserID(ctx, userID, filter)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goals: %v", op, err)
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// Archive archives the given goals of the user. Goals that are archived already or belong to someone
+// else are skipped, the archived goals are returned.
+func (s *goalService) Archive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error) {
+	const op = "goalService.Archive"
+
+	if err := validateArchiveBatch(ids); err != nil {
+		return nil, err
+	}
+
+	var goals []*model.Goal
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		var err error
+		goals, err = s.goalStorage.Archive(ctx, userID, ids, time.Now())
+		if err != nil {
+			return err
+		}
+
+		for _, goal := range goals {
+			before := *goal
+			before.ArchivedAt = nil
+
+			if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+				return err
+			}
+
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalArchived, goal); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to archive goals: %v", op, err)
+		return nil, fmt.Errorf("failed to archive goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// Unarchive brings the given archived goals of the user back to the active ones and returns them
+func (s *goalService) Unarchive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error) {
+	const op = "goalService.Unarchive"
+
+	if err := validateArchiveBatch(ids); err != nil {
+		return nil, err
+	}
+
+	var goals []*model.Goal
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		current, err := s.goalStorage.GetByIDs(ctx, userID, ids)
+		if err != nil {
+			return err
+		}
+
+		previous := make(map[string]*model.Goal, len(current))
+		for _, goal := range current {
+			previous[goal.ID] = goal
+		}
+
+		goals, err = s.goalStorage.Unarchive(ctx, userID, ids)
+		if err != nil {
+			return err
+		}
+
+		for _, goal := range goals {
+			before := *goal
+			if goal, ok := previous[goal.ID]; ok {
+				before = *goal
+			}
+
+			if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+				return err
+			}
+
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalUnarchived, goal); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to unarchive goals: %v", op, err)
+		return nil, fmt.Errorf("failed to unarchive goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+func validateArchiveBatch(ids []string) error {
+	if len(ids) == 0 || len(ids) > model.MaxArchiveBatch {
+		return fmt.Errorf("%w: between 1 and %d goals must be given", ErrValidation, model.MaxArchiveBatch)
+	}
+
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: %s is not a valid goal id", ErrValidation, id)
+		}
+	}
+
+	return nil
+}
+
+type archiveService struct {
+	goalStorage storage.GoalStorage
+	goalService GoalService
+	after       time.Duration
+	logger      logger.Logger
+}
+
+// NewArchiveService creates an ArchiveService that archives completed goals left unchanged for after,
+// a zero after turns auto-archiving off
+func NewArchiveService(goalStorage storage.GoalStorage, goalService GoalService, after time.Duration, logger logger.Logger) ArchiveService {
+	return &archiveService{
+		goalStorage: goalStorage,
+		goalService: goalService,
+		after:       after,
+		logger:      logger,
+	}
+}
+
+func (s *archiveService) RunAutoArchive(ctx context.Context) {
+	const op = "archiveService.RunAutoArchive"
+
+	if s.after <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(autoArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		archived, err := s.archiveCompleted(ctx)
+		if err != nil {
+			s.logger.Errorf("%s: failed to archive completed goals: %v", op, err)
+		} else if archived > 0 {
+			s.logger.Infof("%s: archived %d completed goals", op, archived)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// archiveCompleted archives the goals per user in batches, so every user gets their own audit entries and events
+func (s *archiveService) archiveCompleted(ctx context.Context) (int, error) {
+	goals, err := s.goalStorage.GetArchivable(ctx, time.Now().Add(-s.after))
+	if err != nil {
+		return 0, err
+	}
+
+	ids := make(map[string][]string)
+	for _, goal := range goals {
+		ids[goal.UserID] = append(ids[goal.UserID], goal.ID)
+	}
+
+	archived := 0
+	for userID, userIDs := range ids {
+		for start := 0; start < len(userIDs); start += model.MaxArchiveBatch {
+			end := min(start+model.MaxArchiveBatch, len(userIDs))
+
+			goals, err := s.goalService.Archive(ctx, userID, userIDs[start:end])
+			if err != nil {
+				return archived, err
+			}
+
+			archived += len(goals)
+		}
+	}
+
+	return archived, nil
+}
@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+
+	auditRetentionInterval = time.Hour
+)
+
+type (
+	actorKey     struct{}
+	requestIDKey struct{}
+)
+
+// WithActor stores the ID of the user making the request for the audit log
+func WithActor(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actorID)
+}
+
+// WithRequestID stores the ID of the request for the audit log
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// ActorFromContext returns the actor stored by WithActor, changes outside of requests are made by model.ActorSystem
+func ActorFromContext(ctx context.Context) string {
+	if actorID, ok := ctx.Value(actorKey{}).(string); ok && actorID != "" {
+		return actorID
+	}
+
+	return model.ActorSystem
+}
+
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+type auditService struct {
+	auditStorage storage.AuditStorage
+	goalStorage  storage.GoalStorage
+	retention    time.Duration
+	logger       logger.Logger
+}
+
+// NewAuditService creates an AuditService that keeps entries for retention, a zero retention keeps them forever
+func NewAuditService(
+	auditStorage storage.AuditStorage,
+	goalStorage storage.GoalStorage,
+	retention time.Duration,
+	logger logger.Logger,
+) AuditService {
+	return &auditService{
+		auditStorage: auditStorage,
+		goalStorage:  goalStorage,
+		retention:    retention,
+		logger:       logger,
+	}
+}
+
+func (s *auditService) Record(ctx context.Context, entityType, entityID, goalID, action string, before, after any) error {
+	const op = "auditService.Record"
+
+	changes, err := model.Diff(before, after)
+	if err != nil {
+		s.logger.Errorf("%s: failed to diff %s %s: %v", op, entityType, entityID, err)
+		return fmt.Errorf("failed to diff %s: %w", entityType, err)
+	}
+
+	if action == model.AuditActionUpdate && len(changes) == 0 {
+		return nil
+	}
+
+	entry, err := model.NewAuditEntry(
+		uuid.NewString(),
+		ActorFromContext(ctx),
+		RequestIDFromContext(ctx),
+		entityType,
+		entityID,
+		goalID,
+		action,
+		changes,
+		time.Now(),
+	)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create audit entry: %v", op, err)
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.auditStorage.Create(ctx, entry); err != nil {
+		s.logger.Errorf("%s: failed to save audit entry: %v", op, err)
+		return fmt.Errorf("failed to save audit entry: %w", err)
+	}
+
+	return nil
+}
+
+func (s *auditService) GetGoalHistory(ctx context.Context, userID, goalID string, before time.Time, limit int) ([]*model.AuditEntry, error) {
+	const op = "auditService.GetGoalHistory"
+
+	if limit <= 0 || limit > MaxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxHistoryLimit)
+	}
+
+	if before.IsZero() {
+		before = time.Now()
+	}
+
+	if _, err := getOwnedGoal(ctx, s.goalStorage, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	entries, err := s.auditStorage.GetByGoalID(ctx, goalID, before, limit)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get audit entries: %v", op, err)
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+func (s *auditService) RunRetention(ctx context.Context) {
+	const op = "auditService.RunRetention"
+
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(auditRetentionInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.auditStorage.DeleteBefore(ctx, time.Now().Add(-s.retention))
+		if err != nil {
+			s.logger.Errorf("%s: failed to delete expired audit entries: %v", op, err)
+		} else if deleted > 0 {
+			s.logger.Infof("%s: deleted %d expired audit entries", op, deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"slices"
+	"time"
+)
+
+// Batch runs the operations on goals and chapters of the user and reports the outcome of each.
+// An error is only returned when the batch itself is invalid or could not be committed.
+func (s *goalService) Batch(ctx context.Context, userID string, batchDTO *dto.BatchDTO) ([]*model.BatchResult, error) {
+	const op = "goalService.Batch"
+
+	if len(batchDTO.Operations) == 0 || len(batchDTO.Operations) > model.MaxBatchOperations {
+		return nil, fmt.Errorf("%w: a batch must hold between 1 and %d operations", ErrValidation, model.MaxBatchOperations)
+	}
+
+	results := make([]*model.BatchResult, len(batchDTO.Operations))
+	for i, operation := range batchDTO.Operations {
+		results[i] = &model.BatchResult{Index: i, Op: operation.Op, Status: model.BatchStatusSkipped}
+	}
+
+	switch batchDTO.Mode {
+	case "", model.BatchBestEffort:
+		for i := range batchDTO.Operations {
+			s.runBatchOperation(ctx, userID, &batchDTO.Operations[i], results[i])
+		}
+
+		return results, nil
+	case model.BatchAllOrNothing:
+	default:
+		return nil, fmt.Errorf("%w: mode must be either %s or %s", ErrValidation, model.BatchAllOrNothing, model.BatchBestEffort)
+	}
+
+	failed := false
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for i := range batchDTO.Operations {
+			if err := s.runBatchOperation(ctx, userID, &batchDTO.Operations[i], results[i]); err != nil {
+				failed = true
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err == nil {
+		return results, nil
+	}
+
+	if !failed {
+		s.logger.Errorf("%s: failed to commit batch: %v", op, err)
+		return nil, fmt.Errorf("failed to commit batch: %w", err)
+	}
+
+	for _, result := range results {
+		if result.Status == model.BatchStatusOK {
+			result.Status = model.BatchStatusRolledBack
+		}
+	}
+
+	return results, nil
+}
+
+// runBatchOperation runs a single operation and records its outcome in result
+func (s *goalService) runBatchOperation(ctx context.Context, userID string, operation *dto.BatchOperationDTO, result *model.BatchResult) error {
+	err := s.batchOperation(ctx, userID, operation)
+	if err != nil {
+		result.Status = model.BatchStatusFailed
+		result.Err = err
+		return err
+	}
+
+	result.Status = model.BatchStatusOK
+	return nil
+}
+
+func (s *goalService) batchOperation(ctx context.Context, userID string, operation *dto.BatchOperationDTO) error {
+	goal, err := s.getOwnedGoal(ctx, userID, operation.GoalID)
+	if err != nil {
+		return err
+	}
+
+	switch operation.Op {
+	case model.BatchComplete:
+		if operation.ChapterID == "" {
+			return fmt.Errorf("%w: complete needs a chapter_id", ErrValidation)
+		}
+
+		_, err := s.CompleteChapter(ctx, userID, operation.GoalID, operation.ChapterID, operation.Force)
+		return err
+	case model.BatchReopen:
+		if operation.ChapterID == "" {
+			return fmt.Errorf("%w: reopen needs a chapter_id", ErrValidation)
+		}
+
+		reopen := false
+		_, err := s.UpdateChapter(ctx, userID, operation.GoalID, operation.ChapterID, &dto.UpdateChapterDTO{IsDone: &reopen})
+		return err
+	case model.BatchDelete:
+		if operation.ChapterID != "" {
+			return s.DeleteChapter(ctx, userID, operation.GoalID, operation.ChapterID)
+		}
+
+		return s.Delete(ctx, userID, operation.GoalID)
+	case model.BatchRetag:
+		if operation.ChapterID != "" {
+			return fmt.Errorf("%w: only goals can be retagged", ErrValidation)
+		}
+
+		_, err := s.updateGoal(ctx, "goalService.Batch", goal, 0, func(goal *model.Goal) error {
+			_, err := goal.SetTags(retag(goal.Tags, operation.AddTags, operation.RemoveTags))
+			return err
+		})
+		return err
+	case model.BatchMoveChapter:
+		if operation.ChapterID == "" || operation.ToGoalID == "" {
+			return fmt.Errorf("%w: move_chapter needs a chapter_id and a to_goal_id", ErrValidation)
+		}
+
+		return s.moveChapterToGoal(ctx, userID, operation.GoalID, operation.ChapterID, operation.ToGoalID)
+	case model.BatchShiftDeadline:
+		shift, err := model.ParseShift(operation.Shift)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if operation.ChapterID != "" {
+			_, err := s.updateChapter(ctx, "goalService.Batch", goal, operation.ChapterID, 0, func(chapter *model.Chapter) error {
+				_, err := chapter.SetDeadline(chapter.Deadline.Add(shift))
+				return err
+			})
+			return err
+		}
+
+		_, err = s.updateGoal(ctx, "goalService.Batch", goal, 0, func(goal *model.Goal) error {
+			_, err := goal.SetDeadline(goal.Deadline.Add(shift))
+			return err
+		})
+		return err
+	default:
+		return fmt.Errorf("%w: unknown operation %q", ErrValidation, operation.Op)
+	}
+}
+
+// moveChapterToGoal moves the chapter to the end of another goal of the user. The dependencies of the chapter
+// and those on it are dropped, since they only link chapters of the same goal.
+func (s *goalService) moveChapterToGoal(ctx context.Context, userID, goalID, chapterID, toGoalID string) error {
+	const op = "goalService.moveChapterToGoal"
+
+	if goalID == toGoalID {
+		return fmt.Errorf("%w: chapter is already in goal %s", ErrValidation, toGoalID)
+	}
+
+	source, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		return err
+	}
+
+	target, err := s.getOwnedGoal(ctx, userID, toGoalID)
+	if err != nil {
+		return err
+	}
+
+	chapter, err := s.goalStorage.GetChapterByID(ctx, chapterID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapter: %v", op, err)
+		return err
+	}
+
+	if chapter.GoalID != source.ID {
+		s.logger.Errorf("%s: chapter %s not found in goal %s", op, chapterID, goalID)
+		return storage.ErrChapterNotFound
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// both goals are locked in ID order, so two opposite moves cannot deadlock
+		first, second := source.ID, target.ID
+		if second < first {
+			first, second = second, first
+		}
+
+		for _, id := range []string{first, second} {
+			if err := s.goalStorage.LockGoalChapters(ctx, id); err != nil {
+				return err
+			}
+		}
+
+		last, err := s.goalStorage.GetLastChapterPosition(ctx, target.ID)
+		if err != nil {
+			return err
+		}
+
+		position, err := lexorank.Between(last, "")
+		if err != nil {
+			return fmt.Errorf("failed to rank chapter: %w", err)
+		}
+
+		before := *chapter
+		if _, err := chapter.SetGoalID(target.ID); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if _, err := chapter.SetPosition(position); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if _, err := chapter.SetDependsOn(nil); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if _, err := chapter.SetUpdatedAt(time.Now()); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.MoveChapterToGoal(ctx, chapter); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, target.ID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		move := model.ChapterMove{ChapterID: chapter.ID, FromGoalID: source.ID, ToGoalID: target.ID}
+		if err := s.eventBus.Publish(ctx, target.UserID, model.EventChapterMoved, move); err != nil {
+			return err
+		}
+
+		for _, goal := range []*model.Goal{source, target} {
+			if err := s.recalculateProgress(ctx, goal); err != nil {
+				return err
+			}
+
+			if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to move chapter: %v", op, err)
+		return fmt.Errorf("failed to move chapter: %w", err)
+	}
+
+	return nil
+}
+
+// retag adds and removes tags, keeping the order of the remaining ones and skipping duplicates
+func retag(tags, add, remove []string) []string {
+	remove = model.NormalizeTags(remove)
+
+	result := make([]string, 0, len(tags)+len(add))
+	for _, tag := range append(slices.Clone(tags), model.NormalizeTags(add)...) {
+		if !slices.Contains(remove, tag) && !slices.Contains(result, tag) {
+			result = append(result, tag)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+var ErrInvalidCredentials = errors.New("invalid credentials")
+
+type calDAVService struct {
+	credentialStorage storage.CalDAVCredentialStorage
+	goalStorage       storage.GoalStorage
+	goalService       GoalService
+	transactor        storage.Transactor
+	logger            logger.Logger
+}
+
+func NewCalDAVService(
+	credentialStorage storage.CalDAVCredentialStorage,
+	goalStorage storage.GoalStorage,
+	goalService GoalService,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) CalDAVService {
+	return &calDAVService{
+		credentialStorage: credentialStorage,
+		goalStorage:       goalStorage,
+		goalService:       goalService,
+		transactor:        transactor,
+		logger:            logger,
+	}
+}
+
+func (s *calDAVService) GetCredential(ctx context.Context, userID string) (*model.CalDAVCredential, error) {
+	return s.credentialStorage.GetByUserID(ctx, userID)
+}
+
+func (s *calDAVService) RegeneratePassword(ctx context.Context, userID string) (*model.CalDAVCredential, string, error) {
+	const op = "calDAVService.RegeneratePassword"
+
+	credential, password, err := model.NewCalDAVCredential(userID, time.Now())
+	if err != nil {
+		s.logger.Errorf("%s: failed to create caldav credential: %v", op, err)
+		return nil, "", fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.credentialStorage.Upsert(ctx, credential); err != nil {
+		s.logger.Errorf("%s: failed to save caldav credential: %v", op, err)
+		return nil, "", fmt.Errorf("failed to save caldav credential: %w", err)
+	}
+
+	return credential, password, nil
+}
+
+func (s *calDAVService) RevokePassword(ctx context.Context, userID string) error {
+	return s.credentialStorage.DeleteByUserID(ctx, userID)
+}
+
+func (s *calDAVService) Authenticate(ctx context.Context, userID, password string) error {
+	credential, err := s.credentialStorage.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, storage.ErrCalDAVCredentialNotFound) {
+			return ErrInvalidCredentials
+		}
+
+		return err
+	}
+
+	if !credential.Verify(password) {
+		return ErrInvalidCredentials
+	}
+
+	return nil
+}
+
+func (s *calDAVService) GetCalendars(ctx context.Context, userID string) ([]*model.Goal, error) {
+	const op = "calDAVService.GetCalendars"
+
+	goals, err := s.goalStorage.GetByUserID(ctx, userID, model.GoalFilter{Archived: model.ArchiveExclude})
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goals: %v", op, err)
+		return nil, fmt.Errorf("failed to get goals: %w", err)
+	}
+
+	open := make([]*model.Goal, 0, len(goals))
+	for _, goal := range goals {
+		if !goal.IsDone {
+			open = append(open, goal)
+		}
+	}
+
+	return open, nil
+}
+
+func (s *calDAVService) GetCalendar(ctx context.Context, userID, goalID string) (*model.Goal, []*model.Chapter, error) {
+	const op = "calDAVService.GetCalendar"
+
+	goal, err := s.getGoal(ctx, userID, goalID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	return goal, chapters, nil
+}
+
+func (s *calDAVService) PutTask(ctx context.Context, userID, goalID, chapterID string, taskDTO *dto.CalDAVTaskDTO, ifMatch, ifNoneMatch string) (bool, error) {
+	const op = "calDAVService.PutTask"
+
+	goal, err := s.getGoal(ctx, userID, goalID)
+	if err != nil {
+		return false, err
+	}
+
+	created := false
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// the row lock serializes writers of the goal, so the ETag check cannot race
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		chapter, err := s.goalStorage.GetChapterByID(ctx, chapterID)
+		switch {
+		case errors.Is(err, storage.ErrChapterNotFound):
+			if ifMatch != "" {
+				return ErrPreconditionFailed
+			}
+
+			created = true
+			return s.createTask(ctx, goal, chapterID, taskDTO)
+		case err != nil:
+			return err
+		case chapter.GoalID != goal.ID:
+			return fmt.Errorf("%w: chapter belongs to another goal", ErrValidation)
+		case ifNoneMatch == "*", ifMatch != "" && ifMatch != "*" && ifMatch != chapter.ETag():
+			return ErrPreconditionFailed
+		}
+
+		return s.updateTask(ctx, goal, chapter, taskDTO)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to put task: %v", op, err)
+		return false, err
+	}
+
+	return created, nil
+}
+
+func (s *calDAVService) DeleteTask(ctx context.Context, userID, goalID, chapterID, ifMatch string) error {
+	const op = "calDAVService.DeleteTask"
+
+	goal, err := s.getGoal(ctx, userID, goalID)
+	if err != nil {
+		return err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		chapter, err := s.goalStorage.GetChapterByID(ctx, chapterID)
+		if err != nil {
+			return err
+		}
+
+		if ifMatch != "" && ifMatch != "*" && ifMatch != chapter.ETag() {
+			return ErrPreconditionFailed
+		}
+
+		return s.goalService.DeleteChapter(ctx, userID, goal.ID, chapter.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to delete task: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// createTask creates the chapter with the ID the client chose. Task apps rarely require a description
+// or a due date, so they fall back to the title and the goal deadline.
+func (s *calDAVService) createTask(ctx context.Context, goal *model.Goal, chapterID string, taskDTO *dto.CalDAVTaskDTO) error {
+	createDTO := &dto.CreateChapterDTO{
+		ID:          chapterID,
+		Title:       taskDTO.Title,
+		Description: orTitle(taskDTO.Description, taskDTO.Title),
+		Deadline:    orDefault(taskDTO.Deadline, goal.Deadline),
+	}
+
+	if _, err := s.goalService.CreateChapter(ctx, goal.UserID, goal.ID, createDTO); err != nil {
+		return err
+	}
+
+	if taskDTO.IsDone {
+		if _, err := s.goalService.CompleteChapter(ctx, goal.UserID, goal.ID, chapterID, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// updateTask applies the changed fields, completion goes through GoalService.CompleteChapter
+func (s *calDAVService) updateTask(ctx context.Context, goal *model.Goal, chapter *model.Chapter, taskDTO *dto.CalDAVTaskDTO) error {
+	var updateDTO dto.UpdateChapterDTO
+	changed := false
+
+	if taskDTO.Title != "" && taskDTO.Title != chapter.Title {
+		updateDTO.Title = &taskDTO.Title
+		changed = true
+	}
+
+	if taskDTO.Description != "" && taskDTO.Description != chapter.Description {
+		updateDTO.Description = &taskDTO.Description
+		changed = true
+	}
+
+	// DUE carries whole seconds only
+	if !taskDTO.Deadline.IsZero() && !taskDTO.Deadline.Equal(chapter.Deadline.Truncate(time.Second)) {
+		updateDTO.Deadline = &taskDTO.Deadline
+		changed = true
+	}
+
+	if !taskDTO.IsDone && chapter.IsDone {
+		reopen := false
+		updateDTO.IsDone = &reopen
+		changed = true
+	}
+
+	if changed {
+		if _, err := s.goalService.UpdateChapter(ctx, goal.UserID, goal.ID, chapter.ID, &updateDTO); err != nil {
+			return err
+		}
+	}
+
+	if taskDTO.IsDone && !chapter.IsDone {
+		if _, err := s.goalService.CompleteChapter(ctx, goal.UserID, goal.ID, chapter.ID, false); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getGoal returns the goal if it belongs to the user, foreign goals are reported as not found
+func (s *calDAVService) getGoal(ctx context.Context, userID, goalID string) (*model.Goal, error) {
+	goal, err := s.goalStorage.GetByID(ctx, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.UserID != userID {
+		return nil, storage.ErrGoalNotFound
+	}
+
+	return goal, nil
+}
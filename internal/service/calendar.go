@@ -0,0 +1,124 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/nordew/Strive/internal/export"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"io"
+	"time"
+)
+
+type calendarService struct {
+	calendarFeedStorage storage.CalendarFeedStorage
+	goalStorage         storage.GoalStorage
+	logger              logger.Logger
+}
+
+func NewCalendarService(
+	calendarFeedStorage storage.CalendarFeedStorage,
+	goalStorage storage.GoalStorage,
+	logger logger.Logger,
+) CalendarService {
+	return &calendarService{
+		calendarFeedStorage: calendarFeedStorage,
+		goalStorage:         goalStorage,
+		logger:              logger,
+	}
+}
+
+func (s *calendarService) GetFeed(ctx context.Context, userID string) (*model.CalendarFeed, error) {
+	const op = "calendarService.GetFeed"
+
+	feed, err := s.calendarFeedStorage.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get calendar feed: %v", op, err)
+		return nil, err
+	}
+
+	return feed, nil
+}
+
+func (s *calendarService) RegenerateFeedToken(ctx context.Context, userID string) (*model.CalendarFeed, error) {
+	const op = "calendarService.RegenerateFeedToken"
+
+	feed, err := model.NewCalendarFeed(userID, time.Now())
+	if err != nil {
+		s.logger.Errorf("%s: failed to create calendar feed: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.calendarFeedStorage.Upsert(ctx, feed); err != nil {
+		s.logger.Errorf("%s: failed to save calendar feed: %v", op, err)
+		return nil, fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return feed, nil
+}
+
+func (s *calendarService) RevokeFeedToken(ctx context.Context, userID string) error {
+	const op = "calendarService.RevokeFeedToken"
+
+	if err := s.calendarFeedStorage.DeleteByUserID(ctx, userID); err != nil {
+		s.logger.Errorf("%s: failed to delete calendar feed: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *calendarService) ResolveFeed(ctx context.Context, token string) (*model.CalendarFeed, string, error) {
+	const op = "calendarService.ResolveFeed"
+
+	feed, err := s.calendarFeedStorage.GetByToken(ctx, token)
+	if err != nil {
+		return nil, "", err
+	}
+
+	version, err := s.calendarFeedStorage.GetVersion(ctx, feed.UserID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get feed version: %v", op, err)
+		return nil, "", fmt.Errorf("failed to get feed version: %w", err)
+	}
+
+	// the body changes with DTSTAMP on every request, so the tag is weak and only tracks the deadlines
+	sum := sha256.Sum256([]byte(feed.Token + ":" + version))
+	etag := `W/"` + hex.EncodeToString(sum[:16]) + `"`
+
+	return feed, etag, nil
+}
+
+func (s *calendarService) WriteFeed(ctx context.Context, userID string, w io.Writer) error {
+	const op = "calendarService.WriteFeed"
+
+	encoder := export.NewCalendarEncoder(w, time.Now(), export.ComponentEvent)
+	if err := encoder.Begin(); err != nil {
+		return fmt.Errorf("failed to write calendar: %w", err)
+	}
+
+	err := s.goalStorage.StreamByUserID(ctx, userID, func(goal *model.Goal) error {
+		if goal.IsDone {
+			return nil
+		}
+
+		open := goal.Chapters[:0]
+		for _, chapter := range goal.Chapters {
+			if !chapter.IsDone {
+				open = append(open, chapter)
+			}
+		}
+		goal.Chapters = open
+
+		return encoder.Encode(goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to write calendar feed: %v", op, err)
+		return fmt.Errorf("failed to write calendar feed: %w", err)
+	}
+
+	return encoder.End()
+}
@@ -0,0 +1,477 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"time"
+)
+
+func (s *goalService) CreateChapter(ctx context.Context, userID, goalID string, createDTO *dto.CreateChapterDTO) (*model.Chapter, error) {
+	const op = "goalService.CreateChapter"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	chapterID := createDTO.ID
+	if chapterID == "" {
+		chapterID = uuid.NewString()
+	}
+
+	now := time.Now()
+	chapter, err := model.NewChapter(
+		chapterID,
+		goal.ID,
+		createDTO.Title,
+		createDTO.Description,
+		false,
+		createDTO.Deadline,
+		createDTO.Priority,
+		nil,
+		now,
+		now,
+	)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create chapter: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	if err := validateDependencies(chapter, createDTO.DependsOn, append(chapters, chapter)); err != nil {
+		s.logger.Errorf("%s: invalid chapter dependencies: %v", op, err)
+		return nil, err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		last, err := s.goalStorage.GetLastChapterPosition(ctx, goal.ID)
+		if err != nil {
+			return err
+		}
+
+		position, err := lexorank.Between(last, "")
+		if err != nil {
+			return fmt.Errorf("failed to rank chapter: %w", err)
+		}
+
+		if _, err := chapter.SetPosition(position); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.CreateChapter(ctx, chapter); err != nil {
+			return err
+		}
+
+		if err := s.goalStorage.SetChapterDependencies(ctx, chapter.ID, chapter.DependsOn); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionCreate, nil, chapter); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterCreated, chapter); err != nil {
+			return err
+		}
+
+		if err := s.recalculateProgress(ctx, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to create chapter: %v", op, err)
+		return nil, fmt.Errorf("failed to create chapter: %w", err)
+	}
+
+	return chapter, nil
+}
+
+func (s *goalService) GetChapters(ctx context.Context, userID, goalID string) ([]*model.Chapter, error) {
+	const op = "goalService.GetChapters"
+
+	if _, err := s.getOwnedGoal(ctx, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	return chapters, nil
+}
+
+// SetChapterDependencies replaces the dependencies of the chapter. The chapters are read while the goal is locked,
+// so two concurrent changes cannot both pass the cycle check.
+func (s *goalService) SetChapterDependencies(ctx context.Context, userID, goalID, chapterID string, dependenciesDTO *dto.SetChapterDependenciesDTO) (*model.Chapter, error) {
+	const op = "goalService.SetChapterDependencies"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	var chapter *model.Chapter
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+		if err != nil {
+			return err
+		}
+
+		chapter = findChapter(chapters, chapterID)
+		if chapter == nil {
+			return storage.ErrChapterNotFound
+		}
+
+		before := *chapter
+		if err := validateDependencies(chapter, dependenciesDTO.DependsOn, chapters); err != nil {
+			return err
+		}
+
+		if _, err := chapter.SetUpdatedAt(time.Now()); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.SetChapterDependencies(ctx, chapter.ID, chapter.DependsOn); err != nil {
+			return err
+		}
+
+		// bumps the version, so If-Match sees the new dependencies
+		if err := s.goalStorage.UpdateChapter(ctx, chapter); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterUpdated, chapter); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to set chapter dependencies: %v", op, err)
+		return nil, fmt.Errorf("failed to set chapter dependencies: %w", err)
+	}
+
+	return chapter, nil
+}
+
+func (s *goalService) CompleteChapter(ctx context.Context, userID, goalID, chapterID string, force bool) (*model.Chapter, error) {
+	const op = "goalService.CompleteChapter"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	chapter := findChapter(chapters, chapterID)
+	if chapter == nil {
+		s.logger.Errorf("%s: chapter %s not found in goal %s", op, chapterID, goalID)
+		return nil, storage.ErrChapterNotFound
+	}
+
+	if unmet := model.UnmetDependencies(*chapter, derefChapters(chapters)); len(unmet) > 0 && !force {
+		s.logger.Infof("%s: chapter %s has %d unmet dependencies", op, chapter.ID, len(unmet))
+		return nil, ErrUnmetDependencies
+	}
+
+	before := *chapter
+	wasDone := chapter.IsDone
+	if _, err := chapter.SetIsDone(true); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := chapter.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.UpdateChapter(ctx, chapter); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		if !wasDone {
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterCompleted, chapter); err != nil {
+				return err
+			}
+		}
+
+		if err := s.recalculateProgress(ctx, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to complete chapter: %v", op, err)
+		return nil, fmt.Errorf("failed to complete chapter: %w", err)
+	}
+
+	return chapter, nil
+}
+
+// UpdateChapter applies the set fields of updateDTO. Setting is_done follows the rules of CompleteChapter without force.
+func (s *goalService) UpdateChapter(ctx context.Context, userID, goalID, chapterID string, updateDTO *dto.UpdateChapterDTO) (*model.Chapter, error) {
+	const op = "goalService.UpdateChapter"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	return s.updateChapter(ctx, op, goal, chapterID, updateDTO.Version, func(chapter *model.Chapter) error {
+		return applyChapterUpdate(chapter, updateDTO)
+	})
+}
+
+// updateChapter changes the chapter with apply and stores it, a non-zero version must match the current one
+func (s *goalService) updateChapter(
+	ctx context.Context,
+	op string,
+	goal *model.Goal,
+	chapterID string,
+	version int,
+	apply func(chapter *model.Chapter) error,
+) (*model.Chapter, error) {
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	chapter := findChapter(chapters, chapterID)
+	if chapter == nil {
+		s.logger.Errorf("%s: chapter %s not found in goal %s", op, chapterID, goal.ID)
+		return nil, storage.ErrChapterNotFound
+	}
+
+	if version != 0 && version != chapter.Version {
+		s.logger.Infof("%s: chapter %s is at version %d, not %d", op, chapter.ID, chapter.Version, version)
+		return nil, ErrPreconditionFailed
+	}
+
+	before := *chapter
+	wasDone := chapter.IsDone
+	if err := apply(chapter); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+
+	// only completing the chapter is blocked, a done chapter can still be edited after a dependency is reopened
+	if chapter.IsDone && !wasDone && len(model.UnmetDependencies(*chapter, derefChapters(chapters))) > 0 {
+		s.logger.Infof("%s: chapter %s has unmet dependencies", op, chapter.ID)
+		return nil, ErrUnmetDependencies
+	}
+
+	if _, err := chapter.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.UpdateChapter(ctx, chapter); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterUpdated, chapter); err != nil {
+			return err
+		}
+
+		if chapter.IsDone && !wasDone {
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterCompleted, chapter); err != nil {
+				return err
+			}
+		}
+
+		if err := s.recalculateProgress(ctx, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to update chapter: %v", op, err)
+		return nil, fmt.Errorf("failed to update chapter: %w", err)
+	}
+
+	return chapter, nil
+}
+
+func (s *goalService) DeleteChapter(ctx context.Context, userID, goalID, chapterID string) error {
+	const op = "goalService.DeleteChapter"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return err
+	}
+
+	chapter, err := s.goalStorage.GetChapterByID(ctx, chapterID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapter: %v", op, err)
+		return err
+	}
+
+	if chapter.GoalID != goal.ID {
+		s.logger.Errorf("%s: chapter %s not found in goal %s", op, chapterID, goalID)
+		return storage.ErrChapterNotFound
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.TrashChapter(ctx, chapter.ID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionDelete, chapter, nil); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterDeleted, chapter); err != nil {
+			return err
+		}
+
+		if err := s.recalculateProgress(ctx, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to delete chapter: %v", op, err)
+		return fmt.Errorf("failed to delete chapter: %w", err)
+	}
+
+	return nil
+}
+
+func (s *goalService) GetCriticalPath(ctx context.Context, userID, goalID string) (*model.CriticalPath, error) {
+	const op = "goalService.GetCriticalPath"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapters: %v", op, err)
+		return nil, fmt.Errorf("failed to get chapters: %w", err)
+	}
+
+	path, err := model.NewCriticalPath(*goal, derefChapters(chapters), time.Now())
+	if err != nil {
+		s.logger.Errorf("%s: failed to compute critical path: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return path, nil
+}
+
+// validateDependencies sets dependsOn on the chapter and checks that the chapters still form a DAG
+func validateDependencies(chapter *model.Chapter, dependsOn []string, chapters []*model.Chapter) error {
+	if _, err := chapter.SetDependsOn(dependsOn); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := model.SortChaptersByDependencies(derefChapters(chapters)); err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	return nil
+}
+
+func applyChapterUpdate(chapter *model.Chapter, updateDTO *dto.UpdateChapterDTO) error {
+	if updateDTO.Title != nil {
+		if _, err := chapter.SetTitle(*updateDTO.Title); err != nil {
+			return err
+		}
+	}
+
+	if updateDTO.Description != nil {
+		if _, err := chapter.SetDescription(*updateDTO.Description); err != nil {
+			return err
+		}
+	}
+
+	// an unchanged deadline is skipped, so overdue chapters can still be edited
+	if updateDTO.Deadline != nil && !updateDTO.Deadline.Equal(chapter.Deadline) {
+		if _, err := chapter.SetDeadline(*updateDTO.Deadline); err != nil {
+			return err
+		}
+	}
+
+	if updateDTO.Priority != nil {
+		if _, err := chapter.SetPriority(*updateDTO.Priority); err != nil {
+			return err
+		}
+	}
+
+	if updateDTO.IsDone != nil {
+		if _, err := chapter.SetIsDone(*updateDTO.IsDone); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findChapter(chapters []*model.Chapter, id string) *model.Chapter {
+	for _, chapter := range chapters {
+		if chapter.ID == id {
+			return chapter
+		}
+	}
+
+	return nil
+}
+
+func derefChapters(chapters []*model.Chapter) []model.Chapter {
+	result := make([]model.Chapter, 0, len(chapters))
+	for _, chapter := range chapters {
+		result = append(result, *chapter)
+	}
+
+	return result
+}
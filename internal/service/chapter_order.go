@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+)
+
+// ReorderChapter rewrites only the position of the moved chapter. Neighbours are resolved
+// while the goal is locked, so concurrent reorders are applied one after another.
+func (s *goalService) ReorderChapter(ctx context.Context, userID, goalID string, moveDTO *dto.MoveChapterDTO) ([]*model.Chapter, error) {
+	const op = "goalService.ReorderChapter"
+
+	if moveDTO.AfterID != "" && moveDTO.BeforeID != "" {
+		return nil, fmt.Errorf("%w: only one of after_id and before_id can be set", ErrValidation)
+	}
+
+	if moveDTO.ChapterID == moveDTO.AfterID || moveDTO.ChapterID == moveDTO.BeforeID {
+		return nil, fmt.Errorf("%w: chapter cannot be moved relative to itself", ErrValidation)
+	}
+
+	if _, err := s.getOwnedGoal(ctx, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	var chapters []*model.Chapter
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goalID); err != nil {
+			return err
+		}
+
+		var err error
+		chapters, err = s.goalStorage.GetChaptersByGoalID(ctx, goalID)
+		if err != nil {
+			return err
+		}
+
+		chapter := findChapter(chapters, moveDTO.ChapterID)
+		if chapter == nil {
+			return storage.ErrChapterNotFound
+		}
+
+		others := make([]*model.Chapter, 0, len(chapters))
+		for _, other := range chapters {
+			if other.ID != chapter.ID {
+				others = append(others, other)
+			}
+		}
+
+		index, err := insertionIndex(others, moveDTO)
+		if err != nil {
+			return err
+		}
+
+		var lower, upper string
+		if index > 0 {
+			lower = others[index-1].Position
+		}
+		if index < len(others) {
+			upper = others[index].Position
+		}
+
+		position, err := lexorank.Between(lower, upper)
+		if err != nil {
+			return fmt.Errorf("failed to rank chapter: %w", err)
+		}
+
+		before := *chapter
+		if _, err := chapter.SetPosition(position); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.UpdateChapterPosition(ctx, chapter.ID, chapter.Position); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goalID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		chapters = append(others[:index], append([]*model.Chapter{chapter}, others[index:]...)...)
+		return s.revisionService.Record(ctx, goalID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to reorder chapter: %v", op, err)
+		return nil, fmt.Errorf("failed to reorder chapter: %w", err)
+	}
+
+	return chapters, nil
+}
+
+// insertionIndex returns the index in others at which the moved chapter is inserted
+func insertionIndex(others []*model.Chapter, moveDTO *dto.MoveChapterDTO) (int, error) {
+	if moveDTO.AfterID == "" && moveDTO.BeforeID == "" {
+		return 0, nil
+	}
+
+	for i, other := range others {
+		switch other.ID {
+		case moveDTO.AfterID:
+			return i + 1, nil
+		case moveDTO.BeforeID:
+			return i, nil
+		}
+	}
+
+	return 0, storage.ErrChapterNotFound
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"time"
+)
+
+func (s *goalService) CreateComment(ctx context.Context, userID, goalID string, createDTO *dto.CreateCommentDTO) (*model.Comment, error) {
+	const op = "goalService.CreateComment"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	if createDTO.ChapterID != "" {
+		chapter, err := s.goalStorage.GetChapterByID(ctx, createDTO.ChapterID)
+		if err != nil {
+			s.logger.Errorf("%s: failed to get chapter: %v", op, err)
+			return nil, err
+		}
+
+		if chapter.GoalID != goal.ID {
+			s.logger.Errorf("%s: chapter %s not found in goal %s", op, chapter.ID, goal.ID)
+			return nil, storage.ErrChapterNotFound
+		}
+	}
+
+	now := time.Now()
+	comment, err := model.NewComment(uuid.NewString(), goal.ID, createDTO.ChapterID, createDTO.Content, now, now)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create comment: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.CreateComment(ctx, comment); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityComment, comment.ID, goal.ID, model.AuditActionCreate, nil, comment); err != nil {
+			return err
+		}
+
+		return s.eventBus.Publish(ctx, goal.UserID, model.EventCommentCreated, comment)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to create comment: %v", op, err)
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	return comment, nil
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"sync"
+	"time"
+)
+
+const (
+	eventDispatchInterval = time.Second
+	eventLease            = time.Minute
+	eventBatch            = 50
+	eventRetention        = 7 * 24 * time.Hour
+	eventCleanupInterval  = time.Hour
+)
+
+type subscription struct {
+	subscriber Subscriber
+	eventTypes map[string]bool // eventTypes is empty when the subscriber receives all events
+}
+
+type eventBus struct {
+	outboxStorage storage.OutboxStorage
+	logger        logger.Logger
+
+	mu            sync.RWMutex
+	subscriptions []subscription
+}
+
+func NewEventBus(outboxStorage storage.OutboxStorage, logger logger.Logger) EventBus {
+	return &eventBus{
+		outboxStorage: outboxStorage,
+		logger:        logger,
+	}
+}
+
+func (b *eventBus) Publish(ctx context.Context, userID, eventType string, data any) error {
+	const op = "eventBus.Publish"
+
+	event, err := model.NewEvent(uuid.NewString(), eventType, userID, data, time.Now())
+	if err != nil {
+		b.logger.Errorf("%s: failed to create event: %v", op, err)
+		return fmt.Errorf("failed to create event: %w", err)
+	}
+
+	if err := b.outboxStorage.Create(ctx, event); err != nil {
+		b.logger.Errorf("%s: failed to save event: %v", op, err)
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+
+	return nil
+}
+
+func (b *eventBus) Subscribe(subscriber Subscriber, eventTypes ...string) {
+	types := make(map[string]bool, len(eventTypes))
+	for _, eventType := range eventTypes {
+		types[eventType] = true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.subscriptions = append(b.subscriptions, subscription{subscriber: subscriber, eventTypes: types})
+}
+
+func (b *eventBus) Run(ctx context.Context) {
+	ticker := time.NewTicker(eventDispatchInterval)
+	defer ticker.Stop()
+
+	var lastCleanup time.Time
+	for {
+		b.dispatchDue(ctx)
+
+		if now := time.Now(); now.Sub(lastCleanup) >= eventCleanupInterval {
+			b.cleanup(ctx, now)
+			lastCleanup = now
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (b *eventBus) dispatchDue(ctx context.Context) {
+	const op = "eventBus.dispatchDue"
+
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		events, err := b.outboxStorage.ClaimDue(ctx, now, now.Add(eventLease), eventBatch)
+		if err != nil {
+			b.logger.Errorf("%s: failed to claim events: %v", op, err)
+			return
+		}
+
+		for _, event := range events {
+			if err := b.dispatch(ctx, event); err != nil {
+				b.logger.Errorf("%s: failed to dispatch event %s: %v", op, event.ID, err)
+			}
+		}
+
+		if len(events) < eventBatch {
+			return
+		}
+	}
+}
+
+// dispatch hands the event to every subscriber of its type. If one of them fails the event is retried
+// for all of them, so subscribers see it at least once, until it runs out of attempts.
+func (b *eventBus) dispatch(ctx context.Context, event *model.Event) error {
+	b.mu.RLock()
+	subscriptions := b.subscriptions
+	b.mu.RUnlock()
+
+	var errs []error
+	for _, subscription := range subscriptions {
+		if len(subscription.eventTypes) > 0 && !subscription.eventTypes[event.Type] {
+			continue
+		}
+
+		if err := handleEvent(ctx, subscription.subscriber, event); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	now := time.Now()
+	if err := errors.Join(errs...); err != nil {
+		event.RecordFailure(err, now)
+		if event.IsDead() {
+			b.logger.Errorf("eventBus.dispatch: %s event %s failed %d times, giving up: %v", event.Type, event.ID, event.Attempts, err)
+		} else {
+			b.logger.Errorf("eventBus.dispatch: %s event %s failed, attempt %d: %v", event.Type, event.ID, event.Attempts, err)
+		}
+	} else {
+		event.MarkDispatched(now)
+	}
+
+	return b.outboxStorage.Update(ctx, event)
+}
+
+func (b *eventBus) cleanup(ctx context.Context, now time.Time) {
+	const op = "eventBus.cleanup"
+
+	if _, err := b.outboxStorage.DeleteDispatchedBefore(ctx, now.Add(-eventRetention)); err != nil {
+		b.logger.Errorf("%s: failed to delete dispatched events: %v", op, err)
+	}
+
+	if _, err := b.outboxStorage.DeleteDeadBefore(ctx, now.Add(-eventRetention)); err != nil {
+		b.logger.Errorf("%s: failed to delete dead events: %v", op, err)
+	}
+}
+
+// handleEvent turns a panicking subscriber into a failed delivery, so it cannot stop the dispatcher
+func handleEvent(ctx context.Context, subscriber Subscriber, event *model.Event) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("subscriber panicked: %v", r)
+		}
+	}()
+
+	return subscriber.HandleEvent(ctx, event)
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/export"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"io"
+	"time"
+)
+
+type exportService struct {
+	goalStorage storage.GoalStorage
+	logger      logger.Logger
+}
+
+func NewExportService(goalStorage storage.GoalStorage, logger logger.Logger) ExportService {
+	return &exportService{
+		goalStorage: goalStorage,
+		logger:      logger,
+	}
+}
+
+func (s *exportService) Export(ctx context.Context, userID string, format export.Format, w io.Writer) error {
+	const op = "exportService.Export"
+
+	encoder, err := export.NewEncoder(format, w, time.Now())
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	// key results are few compared to chapters, so they are loaded up front instead of joined into the stream
+	keyResults, err := s.goalStorage.GetKeyResultsByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get key results: %v", op, err)
+		return fmt.Errorf("failed to get key results: %w", err)
+	}
+
+	keyResultsByGoal := make(map[string][]model.KeyResult)
+	for _, keyResult := range keyResults {
+		keyResultsByGoal[keyResult.GoalID] = append(keyResultsByGoal[keyResult.GoalID], *keyResult)
+	}
+
+	if err := encoder.Begin(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	err = s.goalStorage.StreamByUserID(ctx, userID, func(goal *model.Goal) error {
+		goal.KeyResults = keyResultsByGoal[goal.ID]
+		return encoder.Encode(goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to export goals: %v", op, err)
+		return fmt.Errorf("failed to export goals: %w", err)
+	}
+
+	if err := encoder.End(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+// focusTimerInterval is how often sessions past their end are ended, pomodoros end at most this late
+const focusTimerInterval = 15 * time.Second
+
+// ErrFocusState is returned when a session cannot be paused, resumed or stopped in its current state
+var ErrFocusState = errors.New("invalid focus session state")
+
+type focusService struct {
+	focusStorage storage.FocusStorage
+	goalStorage  storage.GoalStorage
+	eventBus     EventBus
+	transactor   storage.Transactor
+	logger       logger.Logger
+}
+
+func NewFocusService(
+	focusStorage storage.FocusStorage,
+	goalStorage storage.GoalStorage,
+	eventBus EventBus,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) FocusService {
+	return &focusService{
+		focusStorage: focusStorage,
+		goalStorage:  goalStorage,
+		eventBus:     eventBus,
+		transactor:   transactor,
+		logger:       logger,
+	}
+}
+
+func (s *focusService) Start(ctx context.Context, userID string, startDTO *dto.StartFocusDTO) (*model.FocusSession, error) {
+	const op = "focusService.Start"
+
+	chapter, err := s.getOwnedChapter(ctx, userID, startDTO.ChapterID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get chapter: %v", op, err)
+		return nil, err
+	}
+
+	session, err := model.NewFocusSession(uuid.NewString(), userID, chapter.GoalID, chapter.ID, startDTO.Mode, startDTO.Minutes, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+	session.ChapterTitle = chapter.Title
+
+	if err := s.focusStorage.Create(ctx, session); err != nil {
+		s.logger.Errorf("%s: failed to create focus session: %v", op, err)
+		return nil, fmt.Errorf("failed to create focus session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *focusService) Active(ctx context.Context, userID string) (*model.FocusSession, error) {
+	const op = "focusService.Active"
+
+	session, err := s.focusStorage.GetActive(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get active focus session: %v", op, err)
+		return nil, fmt.Errorf("failed to get active focus session: %w", err)
+	}
+
+	return session, nil
+}
+
+func (s *focusService) Pause(ctx context.Context, userID, id string) (*model.FocusSession, error) {
+	return s.change(ctx, "focusService.Pause", userID, id, (*model.FocusSession).Pause)
+}
+
+func (s *focusService) Resume(ctx context.Context, userID, id string) (*model.FocusSession, error) {
+	return s.change(ctx, "focusService.Resume", userID, id, (*model.FocusSession).Resume)
+}
+
+func (s *focusService) Stop(ctx context.Context, userID, id string) (*model.FocusSession, error) {
+	return s.change(ctx, "focusService.Stop", userID, id, (*model.FocusSession).Stop)
+}
+
+func (s *focusService) Totals(ctx context.Context, userID, goalID string) (*model.FocusTotals, error) {
+	const op = "focusService.Totals"
+
+	goal, err := s.goalStorage.GetByID(ctx, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	if goal.UserID != userID {
+		return nil, storage.ErrGoalNotFound
+	}
+
+	totals, err := s.focusStorage.GetTotals(ctx, goal.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get focus totals: %v", op, err)
+		return nil, fmt.Errorf("failed to get focus totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+func (s *focusService) RunTimer(ctx context.Context) {
+	const op = "focusService.RunTimer"
+
+	ticker := time.NewTicker(focusTimerInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.endDue(ctx); err != nil {
+			s.logger.Errorf("%s: failed to end due focus sessions: %v", op, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// endDue ends the running sessions that reached their end, at their end rather than now
+func (s *focusService) endDue(ctx context.Context) error {
+	now := time.Now()
+
+	sessions, err := s.focusStorage.GetDue(ctx, now)
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := session.Stop(now); err != nil {
+			return err
+		}
+
+		// sessions stopped by their user in the meantime are not found and skipped
+		if err := s.save(ctx, session); err != nil && !errors.Is(err, storage.ErrFocusSessionNotFound) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// change applies the transition to the session of the user and stores it
+func (s *focusService) change(ctx context.Context, op, userID, id string, transition func(*model.FocusSession, time.Time) error) (*model.FocusSession, error) {
+	session, err := s.getOwnedSession(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get focus session: %v", op, err)
+		return nil, err
+	}
+
+	if err := transition(session, time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrFocusState, err)
+	}
+
+	if err := s.save(ctx, session); err != nil {
+		s.logger.Errorf("%s: failed to update focus session: %v", op, err)
+		return nil, fmt.Errorf("failed to update focus session: %w", err)
+	}
+
+	return session, nil
+}
+
+// save stores the session and publishes focus.ended together with the change that ended it
+func (s *focusService) save(ctx context.Context, session *model.FocusSession) error {
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.focusStorage.Update(ctx, session); err != nil {
+			return err
+		}
+
+		if session.State != model.FocusEnded {
+			return nil
+		}
+
+		return s.eventBus.Publish(ctx, session.UserID, model.EventFocusEnded, session)
+	})
+}
+
+// getOwnedSession returns the session if it belongs to the user, sessions of other users are not found
+func (s *focusService) getOwnedSession(ctx context.Context, userID, id string) (*model.FocusSession, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, storage.ErrFocusSessionNotFound
+	}
+
+	session, err := s.focusStorage.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get focus session: %w", err)
+	}
+
+	if session.UserID != userID {
+		return nil, storage.ErrFocusSessionNotFound
+	}
+
+	return session, nil
+}
+
+// getOwnedChapter returns the chapter if its goal belongs to the user, chapters of other users are not found
+func (s *focusService) getOwnedChapter(ctx context.Context, userID, chapterID string) (*model.Chapter, error) {
+	if _, err := uuid.Parse(chapterID); err != nil {
+		return nil, storage.ErrChapterNotFound
+	}
+
+	chapter, err := s.goalStorage.GetChapterByID(ctx, chapterID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chapter: %w", err)
+	}
+
+	goal, err := s.goalStorage.GetByID(ctx, chapter.GoalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	if goal.UserID != userID {
+		return nil, storage.ErrChapterNotFound
+	}
+
+	return chapter, nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+var ErrSelfInvite = errors.New("you cannot accept your own invite")
+
+type friendService struct {
+	friendStorage storage.FriendStorage
+	userStorage   storage.UserStorage
+	transactor    storage.Transactor
+	botUsername   string
+	logger        logger.Logger
+}
+
+// NewFriendService creates a FriendService, invites carry a deep link when the bot username is known
+func NewFriendService(
+	friendStorage storage.FriendStorage,
+	userStorage storage.UserStorage,
+	transactor storage.Transactor,
+	botUsername string,
+	logger logger.Logger,
+) FriendService {
+	return &friendService{
+		friendStorage: friendStorage,
+		userStorage:   userStorage,
+		transactor:    transactor,
+		botUsername:   botUsername,
+		logger:        logger,
+	}
+}
+
+func (s *friendService) CreateInvite(ctx context.Context, userID string) (*model.FriendInvite, error) {
+	const op = "friendService.CreateInvite"
+
+	now := time.Now()
+	invite, err := model.NewFriendInvite(userID, now)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create invite: %v", op, err)
+		return nil, fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.friendStorage.DeleteExpiredInvites(ctx, userID, now); err != nil {
+			return err
+		}
+
+		return s.friendStorage.CreateInvite(ctx, invite)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to save invite: %v", op, err)
+		return nil, fmt.Errorf("failed to save invite: %w", err)
+	}
+
+	if s.botUsername != "" {
+		invite.Link = invite.DeepLink(s.botUsername)
+	}
+
+	return invite, nil
+}
+
+func (s *friendService) AcceptInvite(ctx context.Context, userID, code string) (*model.Friend, error) {
+	const op = "friendService.AcceptInvite"
+
+	var friend *model.Friend
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		invite, err := s.friendStorage.TakeInvite(ctx, code)
+		if err != nil {
+			return err
+		}
+
+		// expired invites are not found, whether they ever existed is nobody's business
+		now := time.Now()
+		if invite.Expired(now) {
+			return storage.ErrInviteNotFound
+		}
+
+		if invite.UserID == userID {
+			return ErrSelfInvite
+		}
+
+		inviter, err := s.userStorage.GetByID(ctx, invite.UserID)
+		if err != nil {
+			return fmt.Errorf("failed to get inviter: %w", err)
+		}
+
+		if err := s.friendStorage.CreateFriendship(ctx, userID, inviter.ID, now); err != nil {
+			return err
+		}
+
+		friend = &model.Friend{ID: inviter.ID, FirstName: inviter.FirstName, LastName: inviter.LastName, Since: now}
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to accept invite: %v", op, err)
+		return nil, err
+	}
+
+	return friend, nil
+}
+
+func (s *friendService) List(ctx context.Context, userID string) ([]*model.Friend, error) {
+	const op = "friendService.List"
+
+	friends, err := s.friendStorage.GetFriends(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get friends: %v", op, err)
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+
+	return friends, nil
+}
+
+func (s *friendService) Remove(ctx context.Context, userID, friendID string) error {
+	const op = "friendService.Remove"
+
+	if _, err := uuid.Parse(friendID); err != nil {
+		return storage.ErrFriendNotFound
+	}
+
+	if err := s.friendStorage.DeleteFriendship(ctx, userID, friendID); err != nil {
+		s.logger.Errorf("%s: failed to delete friendship: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *friendService) GetPrivacy(ctx context.Context, userID string) (*model.PrivacySettings, error) {
+	const op = "friendService.GetPrivacy"
+
+	settings, err := s.friendStorage.GetPrivacySettings(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get privacy settings: %v", op, err)
+		return nil, fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (s *friendService) UpdatePrivacy(ctx context.Context, userID string, updateDTO *dto.UpdatePrivacyDTO) (*model.PrivacySettings, error) {
+	const op = "friendService.UpdatePrivacy"
+
+	settings, err := s.GetPrivacy(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if updateDTO.HideFromLeaderboards != nil {
+		settings.SetHideFromLeaderboards(*updateDTO.HideFromLeaderboards)
+	}
+
+	if _, err := settings.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.friendStorage.SavePrivacySettings(ctx, settings); err != nil {
+		s.logger.Errorf("%s: failed to save privacy settings: %v", op, err)
+		return nil, fmt.Errorf("failed to save privacy settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+func (s *friendService) Leaderboard(ctx context.Context, userID, period, metric string) (*model.Leaderboard, error) {
+	const op = "friendService.Leaderboard"
+
+	leaderboard, err := model.NewLeaderboard(period, metric, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.friendStorage.GetLeaderboard(ctx, userID, leaderboard); err != nil {
+		s.logger.Errorf("%s: failed to get leaderboard: %v", op, err)
+		return nil, fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+
+	return leaderboard, nil
+}
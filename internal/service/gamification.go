@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+type gamificationService struct {
+	gamificationStorage storage.GamificationStorage
+	eventBus            EventBus
+	transactor          storage.Transactor
+	logger              logger.Logger
+}
+
+func NewGamificationService(
+	gamificationStorage storage.GamificationStorage,
+	eventBus EventBus,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) GamificationService {
+	return &gamificationService{
+		gamificationStorage: gamificationStorage,
+		eventBus:            eventBus,
+		transactor:          transactor,
+		logger:              logger,
+	}
+}
+
+func (s *gamificationService) Profile(ctx context.Context, userID string) (*model.Profile, error) {
+	const op = "gamificationService.Profile"
+
+	progress, err := s.progress(ctx, userID, time.Now())
+	if err != nil {
+		s.logger.Errorf("%s: failed to get progress: %v", op, err)
+		return nil, err
+	}
+
+	unlocked, err := s.gamificationStorage.GetAchievements(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get achievements: %v", op, err)
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+
+	profile := &model.Profile{
+		Level:        model.LevelFor(progress.XP),
+		Streak:       progress.Streak,
+		Achievements: []*model.UnlockedAchievement{},
+	}
+
+	for _, rule := range model.AchievementRules {
+		if unlockedAt, ok := unlocked[rule.Code]; ok {
+			profile.Achievements = append(profile.Achievements, &model.UnlockedAchievement{Achievement: rule.Achievement, UnlockedAt: unlockedAt})
+		}
+	}
+
+	return profile, nil
+}
+
+func (s *gamificationService) HandleEvent(ctx context.Context, event *model.Event) error {
+	const op = "gamificationService.HandleEvent"
+
+	award, err := s.newAward(event)
+	if err != nil {
+		s.logger.Errorf("%s: failed to award xp for event %s: %v", op, event.ID, err)
+		return fmt.Errorf("failed to award xp: %w", err)
+	}
+
+	if award == nil {
+		return nil
+	}
+
+	// events are delivered at least once, the award and the unlocks are only stored the first time
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if _, err := s.gamificationStorage.CreateXPAward(ctx, award); err != nil {
+			s.logger.Errorf("%s: failed to create xp award: %v", op, err)
+			return err
+		}
+
+		return s.unlockAchievements(ctx, award.UserID, time.Now())
+	})
+}
+
+// newAward returns the XP award for a completion event, other events award nothing
+func (s *gamificationService) newAward(event *model.Event) (*model.XPAward, error) {
+	switch event.Type {
+	case model.EventGoalCompleted:
+		var goal model.Goal
+		if err := event.Decode(&goal); err != nil {
+			return nil, err
+		}
+
+		return model.NewXPAward(uuid.NewString(), event.UserID, model.XPSourceGoal, goal.ID, goal.Priority, goal.Deadline, event.CreatedAt)
+	case model.EventChapterCompleted:
+		var chapter model.Chapter
+		if err := event.Decode(&chapter); err != nil {
+			return nil, err
+		}
+
+		completedAt := event.CreatedAt
+		if chapter.CompletedAt != nil {
+			completedAt = *chapter.CompletedAt
+		}
+
+		return model.NewXPAward(uuid.NewString(), event.UserID, model.XPSourceChapter, chapter.ID, chapter.Priority, chapter.Deadline, completedAt)
+	default:
+		return nil, nil
+	}
+}
+
+// unlockAchievements records the achievements the user reached and publishes achievement.unlocked for new ones
+func (s *gamificationService) unlockAchievements(ctx context.Context, userID string, now time.Time) error {
+	const op = "gamificationService.unlockAchievements"
+
+	progress, err := s.progress(ctx, userID, now)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get progress: %v", op, err)
+		return err
+	}
+
+	for _, achievement := range progress.Reached() {
+		unlocked, err := s.gamificationStorage.UnlockAchievement(ctx, userID, achievement.Code, now)
+		if err != nil {
+			s.logger.Errorf("%s: failed to unlock achievement %s: %v", op, achievement.Code, err)
+			return err
+		}
+
+		if !unlocked {
+			continue
+		}
+
+		payload := model.UnlockedAchievement{Achievement: achievement, UnlockedAt: now}
+		if err := s.eventBus.Publish(ctx, userID, model.EventAchievementUnlocked, payload); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// progress returns the totals of the user's awards with the streak counted in UTC days
+func (s *gamificationService) progress(ctx context.Context, userID string, now time.Time) (*model.AchievementProgress, error) {
+	progress, err := s.gamificationStorage.GetAchievementProgress(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement progress: %w", err)
+	}
+
+	today := now.UTC()
+	days, err := s.gamificationStorage.GetActivityDays(ctx, userID, today.AddDate(0, 0, -model.MaxStreakDays))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity days: %w", err)
+	}
+
+	progress.Streak = model.Streak(days, today)
+
+	return progress, nil
+}
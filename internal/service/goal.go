@@ -12,21 +12,33 @@ import (
 )
 
 type goalService struct {
-	goalStorage storage.GoalStorage
-	logger      logger.Logger
+	goalStorage     storage.GoalStorage
+	eventBus        EventBus
+	auditService    AuditService
+	revisionService RevisionService
+	transactor      storage.Transactor
+	logger          logger.Logger
 }
 
 func NewGoalService(
 	goalStorage storage.GoalStorage,
+	eventBus EventBus,
+	auditService AuditService,
+	revisionService RevisionService,
+	transactor storage.Transactor,
 	logger logger.Logger,
 ) GoalService {
 	return &goalService{
-		goalStorage: goalStorage,
-		logger:      logger,
+		goalStorage:     goalStorage,
+		eventBus:        eventBus,
+		auditService:    auditService,
+		revisionService: revisionService,
+		transactor:      transactor,
+		logger:          logger,
 	}
 }
 
-func (s *goalService) Create(ctx context.Context, createDTO *dto.CreateGoalDTO) error {
+func (s *goalService) Create(ctx context.Context, userID string, createDTO *dto.CreateGoalDTO) error {
 	const op = "goalService.Create"
 
 	now := time.Now()
@@ -34,7 +46,7 @@ func (s *goalService) Create(ctx context.Context, createDTO *dto.CreateGoalDTO)
 	goalID := uuid.NewString()
 	goal, err := model.NewGoal(
 		goalID,
-		createDTO.UserID,
+		userID,
 		createDTO.Title,
 		createDTO.Description,
 		nil,
@@ -53,10 +65,218 @@ func (s *goalService) Create(ctx context.Context, createDTO *dto.CreateGoalDTO)
 		return fmt.Errorf("failed to create goal: %w", err)
 	}
 
-	if err := s.goalStorage.Create(ctx, goal); err != nil {
+	if createDTO.Type != "" {
+		if _, err := goal.SetType(createDTO.Type); err != nil {
+			s.logger.Errorf("%s: failed to set goal type: %v", op, err)
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+
+	if createDTO.ParentID != "" {
+		parent, err := s.goalStorage.GetByID(ctx, createDTO.ParentID)
+		if err != nil {
+			s.logger.Errorf("%s: failed to get parent goal: %v", op, err)
+			return fmt.Errorf("failed to get parent goal: %w", err)
+		}
+
+		if parent.UserID != goal.UserID {
+			s.logger.Errorf("%s: parent goal %s belongs to another user", op, parent.ID)
+			return ErrForeignParentGoal
+		}
+
+		if _, err := goal.SetParentID(parent.ID); err != nil {
+			s.logger.Errorf("%s: failed to set parent goal: %v", op, err)
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Create(ctx, goal); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionCreate, nil, goal); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalCreated, goal); err != nil {
+			return err
+		}
+
+		if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		return s.rollUpProgress(ctx, goal.ParentID)
+	})
+	if err != nil {
 		s.logger.Errorf("%s: failed to create goal: %v", op, err)
 		return fmt.Errorf("failed to create goal: %w", err)
 	}
 
 	return nil
 }
+
+func (s *goalService) Update(ctx context.Context, userID, id string, updateDTO *dto.UpdateGoalDTO) (*model.Goal, error) {
+	const op = "goalService.Update"
+
+	goal, err := s.getOwnedGoal(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	return s.updateGoal(ctx, op, goal, updateDTO.Version, func(goal *model.Goal) error {
+		return applyGoalUpdate(goal, updateDTO)
+	})
+}
+
+// updateGoal changes the goal with apply and stores it, a non-zero version must match the current one
+func (s *goalService) updateGoal(ctx context.Context, op string, goal *model.Goal, version int, apply func(goal *model.Goal) error) (*model.Goal, error) {
+	if version != 0 && version != goal.Version {
+		s.logger.Infof("%s: goal %s is at version %d, not %d", op, goal.ID, goal.Version, version)
+		return nil, ErrPreconditionFailed
+	}
+
+	before := *goal
+	if err := apply(goal); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrValidation, err)
+	}
+
+	if _, err := goal.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Update(ctx, goal); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalUpdated, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to update goal: %v", op, err)
+		return nil, fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// getOwnedGoal returns the goal if it belongs to the user, goals of other users are not found
+func (s *goalService) getOwnedGoal(ctx context.Context, userID, goalID string) (*model.Goal, error) {
+	return getOwnedGoal(ctx, s.goalStorage, userID, goalID)
+}
+
+// getOwnedGoal is shared by the services reading goals on behalf of a user
+func getOwnedGoal(ctx context.Context, goalStorage storage.GoalStorage, userID, goalID string) (*model.Goal, error) {
+	goal, err := goalStorage.GetByID(ctx, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	if goal.UserID != userID {
+		return nil, fmt.Errorf("failed to get goal: %w", storage.ErrGoalNotFound)
+	}
+
+	return goal, nil
+}
+
+// recalculateProgress stores the goal progress computed from its chapters or key results, or from its
+// sub-goals if it has any, and rolls it up to the ancestors
+func (s *goalService) recalculateProgress(ctx context.Context, goal *model.Goal) error {
+	switch goal.Type {
+	case model.GoalTypeOKR:
+		keyResults, err := s.goalStorage.GetKeyResultsByGoalID(ctx, goal.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get key results: %w", err)
+		}
+
+		goal.KeyResults = goal.KeyResults[:0]
+		for _, keyResult := range keyResults {
+			goal.KeyResults = append(goal.KeyResults, *keyResult)
+		}
+	default:
+		chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get chapters: %w", err)
+		}
+
+		goal.Chapters = derefChapters(chapters)
+	}
+
+	children, err := s.goalStorage.GetChildren(ctx, goal.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get goal children: %w", err)
+	}
+
+	previous := goal.Progress
+	goal.CalculateProgress()
+	goal.Children = children
+	goal.RollUpProgress()
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.updateProgress(ctx, goal, previous); err != nil {
+			return err
+		}
+
+		return s.rollUpProgress(ctx, goal.ParentID)
+	})
+}
+
+// updateProgress stores the progress of the goal and publishes goal.progress_changed when it differs
+// from previous, and goal.completed when it reached 100
+func (s *goalService) updateProgress(ctx context.Context, goal *model.Goal, previous int) error {
+	if err := s.goalStorage.UpdateProgress(ctx, goal.ID, goal.Progress); err != nil {
+		return fmt.Errorf("failed to update goal progress: %w", err)
+	}
+
+	if previous == goal.Progress {
+		return nil
+	}
+
+	change := model.ProgressChange{GoalID: goal.ID, Previous: previous, Progress: goal.Progress}
+	if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalProgressChanged, change); err != nil {
+		return err
+	}
+
+	if previous < 100 && goal.Progress >= 100 {
+		return s.eventBus.Publish(ctx, goal.UserID, model.EventGoalCompleted, goal)
+	}
+
+	return nil
+}
+
+func applyGoalUpdate(goal *model.Goal, updateDTO *dto.UpdateGoalDTO) error {
+	if _, err := goal.SetTitle(updateDTO.Title); err != nil {
+		return err
+	}
+
+	if _, err := goal.SetDescription(updateDTO.Description); err != nil {
+		return err
+	}
+
+	// an unchanged deadline is skipped, so overdue goals can still be edited
+	if !updateDTO.Deadline.Equal(goal.Deadline) {
+		if _, err := goal.SetDeadline(updateDTO.Deadline); err != nil {
+			return err
+		}
+	}
+
+	if _, err := goal.SetPriority(updateDTO.Priority); err != nil {
+		return err
+	}
+
+	if _, err := goal.SetTags(updateDTO.Tags); err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const (
+	DefaultTreeDepth = 3
+	MaxTreeDepth     = 10
+)
+
+func (s *goalService) GetTree(ctx context.Context, userID, id string, depth int) (*model.Goal, error) {
+	const op = "goalService.GetTree"
+
+	if depth < 0 || depth > MaxTreeDepth {
+		return nil, fmt.Errorf("%w: depth must be between 0 and %d", ErrValidation, MaxTreeDepth)
+	}
+
+	// sub-goals always belong to the user of their parent, so checking the root covers the tree
+	if _, err := s.getOwnedGoal(ctx, userID, id); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	goals, err := s.goalStorage.GetTree(ctx, id, depth)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal tree: %v", op, err)
+		return nil, fmt.Errorf("failed to get goal tree: %w", err)
+	}
+
+	// goals are ordered by depth, so every parent is indexed before its children
+	byID := make(map[string]*model.Goal, len(goals))
+	for _, goal := range goals {
+		byID[goal.ID] = goal
+
+		if parent, ok := byID[goal.ParentID]; ok {
+			parent.Children = append(parent.Children, goal)
+		}
+	}
+
+	return byID[id], nil
+}
+
+func (s *goalService) Move(ctx context.Context, userID, id string, moveDTO *dto.MoveGoalDTO) error {
+	const op = "goalService.Move"
+
+	goal, err := s.getOwnedGoal(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return err
+	}
+
+	oldParentID := goal.ParentID
+
+	if moveDTO.ParentID != "" {
+		parent, err := s.goalStorage.GetByID(ctx, moveDTO.ParentID)
+		if err != nil {
+			s.logger.Errorf("%s: failed to get parent goal: %v", op, err)
+			return fmt.Errorf("failed to get parent goal: %w", err)
+		}
+
+		if parent.UserID != goal.UserID {
+			s.logger.Errorf("%s: parent goal %s belongs to another user", op, parent.ID)
+			return ErrForeignParentGoal
+		}
+	}
+
+	before := *goal
+	if _, err := goal.SetParentID(moveDTO.ParentID); err != nil {
+		s.logger.Errorf("%s: failed to set parent goal: %v", op, err)
+		return fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Move(ctx, goal.ID, goal.ParentID); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+			return err
+		}
+
+		move := model.GoalMove{GoalID: goal.ID, OldParentID: oldParentID, ParentID: goal.ParentID}
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalMoved, move); err != nil {
+			return err
+		}
+
+		if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		// the old parent may have lost its last sub-goal, so it is recalculated from its own chapters or key results
+		if err := s.recalculateParentProgress(ctx, oldParentID); err != nil {
+			return err
+		}
+
+		return s.rollUpProgress(ctx, goal.ParentID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to move goal: %v", op, err)
+		return fmt.Errorf("failed to move goal: %w", err)
+	}
+
+	return nil
+}
+
+// rollUpProgress recomputes the progress of the goal and all of its ancestors from their children.
+// Goals without children keep their own chapter or key result progress.
+func (s *goalService) rollUpProgress(ctx context.Context, goalID string) error {
+	if goalID == "" {
+		return nil
+	}
+
+	ancestors, err := s.goalStorage.GetAncestors(ctx, goalID)
+	if err != nil {
+		return fmt.Errorf("failed to get goal ancestors: %w", err)
+	}
+
+	for _, goal := range ancestors {
+		children, err := s.goalStorage.GetChildren(ctx, goal.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get goal children: %w", err)
+		}
+
+		if len(children) == 0 {
+			continue
+		}
+
+		previous := goal.Progress
+		goal.Children = children
+		goal.RollUpProgress()
+
+		if err := s.updateProgress(ctx, goal, previous); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+const idempotencyPurgeInterval = time.Hour
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used for a different request")
+	// ErrIdempotencyKeyInProgress is returned when a retry arrives while the first request is still being handled
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is still being processed")
+)
+
+type idempotencyService struct {
+	idempotencyStorage storage.IdempotencyStorage
+	ttl                time.Duration
+	logger             logger.Logger
+}
+
+// NewIdempotencyService creates an IdempotencyService that replays responses for ttl, a zero ttl turns
+// idempotency keys off and every request is handled as new
+func NewIdempotencyService(idempotencyStorage storage.IdempotencyStorage, ttl time.Duration, logger logger.Logger) IdempotencyService {
+	return &idempotencyService{
+		idempotencyStorage: idempotencyStorage,
+		ttl:                ttl,
+		logger:             logger,
+	}
+}
+
+func (s *idempotencyService) Begin(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyRecord, error) {
+	const op = "idempotencyService.Begin"
+
+	if s.ttl <= 0 {
+		return nil, nil
+	}
+
+	now := time.Now()
+	record, err := model.NewIdempotencyRecord(scope, key, fingerprint, now, s.ttl)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.idempotencyStorage.Create(ctx, record)
+	if err == nil {
+		return nil, nil
+	}
+
+	if !errors.Is(err, storage.ErrIdempotencyKeyExists) {
+		s.logger.Errorf("%s: failed to create idempotency key: %v", op, err)
+		return nil, fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	stored, err := s.idempotencyStorage.Get(ctx, scope, key, now)
+	if err != nil {
+		// the first request failed and released the key in the meantime, the client may simply retry
+		if errors.Is(err, storage.ErrIdempotencyKeyNotFound) {
+			return nil, ErrIdempotencyKeyInProgress
+		}
+
+		s.logger.Errorf("%s: failed to get idempotency key: %v", op, err)
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if stored.Fingerprint != fingerprint {
+		return nil, ErrIdempotencyKeyReused
+	}
+
+	if !stored.Completed() {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+
+	return stored, nil
+}
+
+func (s *idempotencyService) Complete(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error {
+	const op = "idempotencyService.Complete"
+
+	if s.ttl <= 0 {
+		return nil
+	}
+
+	if statusCode >= 500 {
+		if err := s.idempotencyStorage.Delete(ctx, scope, key); err != nil {
+			s.logger.Errorf("%s: failed to release idempotency key: %v", op, err)
+			return fmt.Errorf("failed to release idempotency key: %w", err)
+		}
+
+		return nil
+	}
+
+	record := &model.IdempotencyRecord{
+		Scope:       scope,
+		Key:         key,
+		StatusCode:  statusCode,
+		ContentType: contentType,
+		Body:        body,
+	}
+	if err := s.idempotencyStorage.Complete(ctx, record); err != nil {
+		s.logger.Errorf("%s: failed to store response: %v", op, err)
+		return fmt.Errorf("failed to store response: %w", err)
+	}
+
+	return nil
+}
+
+func (s *idempotencyService) RunPurge(ctx context.Context) {
+	const op = "idempotencyService.RunPurge"
+
+	if s.ttl <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(idempotencyPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := s.idempotencyStorage.DeleteExpired(ctx, time.Now())
+		if err != nil {
+			s.logger.Errorf("%s: failed to delete expired idempotency keys: %v", op, err)
+		} else if deleted > 0 {
+			s.logger.Infof("%s: deleted %d expired idempotency keys", op, deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
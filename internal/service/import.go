@@ -0,0 +1,326 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/importer"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"github.com/nordew/Strive/pkg/logger"
+	"io"
+	"time"
+)
+
+var ErrInvalidImport = errors.New("import contains invalid items")
+
+const (
+	ImportItemGoal      = "goal"
+	ImportItemChapter   = "chapter"
+	ImportItemKeyResult = "key_result"
+)
+
+type (
+	// ImportReport lists every imported item with its validation result
+	ImportReport struct {
+		DryRun     bool         `json:"dry_run"`
+		Committed  bool         `json:"committed"`
+		Goals      int          `json:"goals"`
+		Chapters   int          `json:"chapters"`
+		KeyResults int          `json:"key_results"`
+		Invalid    int          `json:"invalid"`
+		Items      []ImportItem `json:"items"`
+	}
+
+	ImportItem struct {
+		Kind  string `json:"kind"`
+		Path  string `json:"path"` // Path locates the item in the import, e.g. goals[0].chapters[2]
+		Title string `json:"title"`
+		Valid bool   `json:"valid"`
+		Error string `json:"error,omitempty"`
+	}
+)
+
+type importService struct {
+	goalStorage     storage.GoalStorage
+	eventBus        EventBus
+	auditService    AuditService
+	revisionService RevisionService
+	transactor      storage.Transactor
+	logger          logger.Logger
+}
+
+func NewImportService(goalStorage storage.GoalStorage, eventBus EventBus, auditService AuditService, revisionService RevisionService, transactor storage.Transactor, logger logger.Logger) ImportService {
+	return &importService{
+		goalStorage:     goalStorage,
+		eventBus:        eventBus,
+		auditService:    auditService,
+		revisionService: revisionService,
+		transactor:      transactor,
+		logger:          logger,
+	}
+}
+
+func (s *importService) Import(ctx context.Context, userID string, importDTO *dto.ImportDTO, r io.Reader) (*ImportReport, error) {
+	const op = "importService.Import"
+
+	drafts, err := importer.Parse(importer.Source(importDTO.Source), r)
+	if err != nil {
+		s.logger.Errorf("%s: failed to parse import: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	report := &ImportReport{DryRun: importDTO.DryRun}
+	goals := buildImportGoals(userID, drafts, importDTO.DefaultDeadline, time.Now(), report)
+
+	if report.Invalid > 0 {
+		return report, ErrInvalidImport
+	}
+
+	if importDTO.DryRun {
+		return report, nil
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		for _, goal := range goals {
+			if err := s.goalStorage.Create(ctx, goal); err != nil {
+				return err
+			}
+
+			if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionCreate, nil, goal); err != nil {
+				return err
+			}
+
+			for i := range goal.Chapters {
+				if err := s.goalStorage.CreateChapter(ctx, &goal.Chapters[i]); err != nil {
+					return err
+				}
+
+				if err := s.auditService.Record(ctx, model.AuditEntityChapter, goal.Chapters[i].ID, goal.ID, model.AuditActionCreate, nil, &goal.Chapters[i]); err != nil {
+					return err
+				}
+			}
+
+			for i := range goal.Chapters {
+				if len(goal.Chapters[i].DependsOn) == 0 {
+					continue
+				}
+
+				if err := s.goalStorage.SetChapterDependencies(ctx, goal.Chapters[i].ID, goal.Chapters[i].DependsOn); err != nil {
+					return err
+				}
+			}
+
+			for i := range goal.KeyResults {
+				if err := s.goalStorage.CreateKeyResult(ctx, &goal.KeyResults[i]); err != nil {
+					return err
+				}
+			}
+
+			if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+				return err
+			}
+
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalCreated, goal); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to commit import: %v", op, err)
+		return nil, fmt.Errorf("failed to commit import: %w", err)
+	}
+
+	report.Committed = true
+	return report, nil
+}
+
+// buildImportGoals validates the drafts with the model constructors and records the result of every
+// item in the report. The goals are returned parents first, so they can be inserted in order.
+func buildImportGoals(userID string, drafts []importer.Goal, defaultDeadline, now time.Time, report *ImportReport) []*model.Goal {
+	goalIDs := make(map[string]string, len(drafts))
+	for _, draft := range drafts {
+		if draft.Ref != "" {
+			goalIDs[draft.Ref] = uuid.NewString()
+		}
+	}
+
+	goals := make([]*model.Goal, 0, len(drafts))
+	byID := make(map[string]*model.Goal, len(drafts))
+	for i, draft := range drafts {
+		path := fmt.Sprintf("goals[%d]", i)
+		if draft.Err != nil {
+			report.add(ImportItemGoal, path, draft.Title, draft.Err)
+			continue
+		}
+
+		id := goalIDs[draft.Ref]
+		if id == "" {
+			id = uuid.NewString()
+		}
+
+		deadline := orDefault(draft.Deadline, defaultDeadline)
+		goal, err := model.NewGoal(id, userID, draft.Title, orTitle(draft.Description, draft.Title), nil, 0, draft.IsDone, deadline, draft.Priority, draft.Tags, nil, now, now)
+		if err == nil && draft.Type != "" {
+			_, err = goal.SetType(draft.Type)
+		}
+		if err == nil && draft.ParentRef != "" && goalIDs[draft.ParentRef] != "" {
+			_, err = goal.SetParentID(goalIDs[draft.ParentRef])
+		}
+
+		chapters, chaptersValid := buildImportChapters(id, path, draft, deadline, now, report)
+		keyResults, keyResultsValid := buildImportKeyResults(id, path, draft, now, report)
+
+		if err == nil && chaptersValid {
+			goal.Chapters = chapters
+			if _, sortErr := model.SortChaptersByDependencies(chapters); sortErr != nil {
+				err = sortErr
+			}
+		}
+
+		report.add(ImportItemGoal, path, draft.Title, err)
+		if err != nil || !chaptersValid || !keyResultsValid {
+			continue
+		}
+
+		goal.KeyResults = keyResults
+		goal.CalculateProgress()
+		goals = append(goals, goal)
+		byID[goal.ID] = goal
+	}
+
+	for _, goal := range goals {
+		if parent := byID[goal.ParentID]; parent != nil {
+			parent.Children = append(parent.Children, goal)
+		}
+	}
+
+	ordered := make([]*model.Goal, 0, len(goals))
+	var visit func(goal *model.Goal)
+	visit = func(goal *model.Goal) {
+		ordered = append(ordered, goal)
+		for _, child := range goal.Children {
+			visit(child)
+		}
+	}
+
+	for _, goal := range goals {
+		if byID[goal.ParentID] == nil {
+			visit(goal)
+		}
+	}
+
+	// goals left over reference each other in a cycle
+	if len(ordered) != len(goals) {
+		report.add(ImportItemGoal, "goals", "", errors.New("goals reference each other as parents"))
+	}
+
+	for i := len(ordered) - 1; i >= 0; i-- {
+		ordered[i].RollUpProgress()
+	}
+
+	return ordered
+}
+
+func buildImportChapters(goalID, goalPath string, draft importer.Goal, goalDeadline, now time.Time, report *ImportReport) ([]model.Chapter, bool) {
+	chapterIDs := make(map[string]string, len(draft.Chapters))
+	for _, chapter := range draft.Chapters {
+		if chapter.Ref != "" {
+			chapterIDs[chapter.Ref] = uuid.NewString()
+		}
+	}
+
+	valid := true
+	position := ""
+	chapters := make([]model.Chapter, 0, len(draft.Chapters))
+	for i, chapterDraft := range draft.Chapters {
+		id := chapterIDs[chapterDraft.Ref]
+		if id == "" {
+			id = uuid.NewString()
+		}
+
+		chapter, err := model.NewChapter(id, goalID, chapterDraft.Title, orTitle(chapterDraft.Description, chapterDraft.Title), chapterDraft.IsDone, orDefault(chapterDraft.Deadline, goalDeadline), chapterDraft.Priority, nil, now, now)
+		if err == nil {
+			position, err = lexorank.Between(position, "")
+		}
+		if err == nil {
+			_, err = chapter.SetPosition(position)
+		}
+		if err == nil && len(chapterDraft.DependsOn) > 0 {
+			dependsOn := make([]string, 0, len(chapterDraft.DependsOn))
+			for _, ref := range chapterDraft.DependsOn {
+				dependsOn = append(dependsOn, chapterIDs[ref])
+			}
+			_, err = chapter.SetDependsOn(dependsOn)
+		}
+
+		report.add(ImportItemChapter, fmt.Sprintf("%s.chapters[%d]", goalPath, i), chapterDraft.Title, err)
+		if err != nil {
+			valid = false
+			continue
+		}
+
+		chapters = append(chapters, *chapter)
+	}
+
+	return chapters, valid
+}
+
+func buildImportKeyResults(goalID, goalPath string, draft importer.Goal, now time.Time, report *ImportReport) ([]model.KeyResult, bool) {
+	valid := true
+	keyResults := make([]model.KeyResult, 0, len(draft.KeyResults))
+	for i, keyResultDraft := range draft.KeyResults {
+		keyResult, err := model.NewKeyResult(uuid.NewString(), goalID, keyResultDraft.Title, keyResultDraft.StartValue, keyResultDraft.TargetValue, keyResultDraft.CurrentValue, keyResultDraft.Unit, now, now)
+
+		report.add(ImportItemKeyResult, fmt.Sprintf("%s.key_results[%d]", goalPath, i), keyResultDraft.Title, err)
+		if err != nil {
+			valid = false
+			continue
+		}
+
+		keyResults = append(keyResults, *keyResult)
+	}
+
+	return keyResults, valid
+}
+
+func (r *ImportReport) add(kind, path, title string, err error) {
+	item := ImportItem{Kind: kind, Path: path, Title: title, Valid: err == nil}
+	if err != nil {
+		item.Error = err.Error()
+		r.Invalid++
+	} else {
+		switch kind {
+		case ImportItemGoal:
+			r.Goals++
+		case ImportItemChapter:
+			r.Chapters++
+		case ImportItemKeyResult:
+			r.KeyResults++
+		}
+	}
+
+	r.Items = append(r.Items, item)
+}
+
+// orTitle falls back to the title, most sources have no mandatory description
+func orTitle(description, title string) string {
+	if description == "" {
+		return title
+	}
+
+	return description
+}
+
+func orDefault(t, fallback time.Time) time.Time {
+	if t.IsZero() {
+		return fallback
+	}
+
+	return t
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"time"
+)
+
+func (s *goalService) CreateKeyResult(ctx context.Context, userID, goalID string, createDTO *dto.CreateKeyResultDTO) (*model.KeyResult, error) {
+	const op = "goalService.CreateKeyResult"
+
+	goal, err := s.getOKRGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	now := time.Now()
+	keyResult, err := model.NewKeyResult(
+		uuid.NewString(),
+		goal.ID,
+		createDTO.Title,
+		createDTO.StartValue,
+		createDTO.TargetValue,
+		createDTO.CurrentValue,
+		createDTO.Unit,
+		now,
+		now,
+	)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create key result: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.CreateKeyResult(ctx, keyResult); err != nil {
+			return fmt.Errorf("failed to create key result: %w", err)
+		}
+
+		return s.recalculateProgress(ctx, goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to create key result: %v", op, err)
+		return nil, err
+	}
+
+	return keyResult, nil
+}
+
+func (s *goalService) GetKeyResults(ctx context.Context, userID, goalID string) ([]*model.KeyResult, error) {
+	const op = "goalService.GetKeyResults"
+
+	if _, err := s.getOKRGoal(ctx, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	keyResults, err := s.goalStorage.GetKeyResultsByGoalID(ctx, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get key results: %v", op, err)
+		return nil, fmt.Errorf("failed to get key results: %w", err)
+	}
+
+	return keyResults, nil
+}
+
+func (s *goalService) DeleteKeyResult(ctx context.Context, userID, goalID, keyResultID string) error {
+	const op = "goalService.DeleteKeyResult"
+
+	goal, err := s.getOKRGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return err
+	}
+
+	if _, err := s.getGoalKeyResult(ctx, goalID, keyResultID); err != nil {
+		s.logger.Errorf("%s: failed to get key result: %v", op, err)
+		return err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.DeleteKeyResult(ctx, keyResultID); err != nil {
+			return fmt.Errorf("failed to delete key result: %w", err)
+		}
+
+		return s.recalculateProgress(ctx, goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to delete key result: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+// CheckIn records a new value of the key result and recalculates the goal progress
+func (s *goalService) CheckIn(ctx context.Context, userID, goalID, keyResultID string, checkInDTO *dto.CheckInKeyResultDTO) (*model.KeyResult, error) {
+	const op = "goalService.CheckIn"
+
+	goal, err := s.getOKRGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	keyResult, err := s.getGoalKeyResult(ctx, goalID, keyResultID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get key result: %v", op, err)
+		return nil, err
+	}
+
+	checkIn, err := model.NewCheckIn(uuid.NewString(), keyResult.ID, checkInDTO.Value, checkInDTO.Note, time.Now())
+	if err != nil {
+		s.logger.Errorf("%s: failed to create check-in: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := keyResult.AddCheckIn(*checkIn); err != nil {
+		s.logger.Errorf("%s: failed to add check-in: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.CreateCheckIn(ctx, checkIn); err != nil {
+			return fmt.Errorf("failed to create check-in: %w", err)
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventKeyResultCheckedIn, keyResult); err != nil {
+			return err
+		}
+
+		return s.recalculateProgress(ctx, goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to check in: %v", op, err)
+		return nil, err
+	}
+
+	return keyResult, nil
+}
+
+func (s *goalService) GetCheckIns(ctx context.Context, userID, goalID, keyResultID string) ([]*model.CheckIn, error) {
+	const op = "goalService.GetCheckIns"
+
+	if _, err := s.getOKRGoal(ctx, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	if _, err := s.getGoalKeyResult(ctx, goalID, keyResultID); err != nil {
+		s.logger.Errorf("%s: failed to get key result: %v", op, err)
+		return nil, err
+	}
+
+	checkIns, err := s.goalStorage.GetCheckInsByKeyResultID(ctx, keyResultID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get check-ins: %v", op, err)
+		return nil, fmt.Errorf("failed to get check-ins: %w", err)
+	}
+
+	return checkIns, nil
+}
+
+// getOKRGoal returns the goal if it is an OKR goal of the user
+func (s *goalService) getOKRGoal(ctx context.Context, userID, goalID string) (*model.Goal, error) {
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if goal.Type != model.GoalTypeOKR {
+		return nil, ErrGoalNotOKR
+	}
+
+	return goal, nil
+}
+
+func (s *goalService) getGoalKeyResult(ctx context.Context, goalID, keyResultID string) (*model.KeyResult, error) {
+	keyResult, err := s.goalStorage.GetKeyResultByID(ctx, keyResultID)
+	if err != nil {
+		return nil, err
+	}
+
+	if keyResult.GoalID != goalID {
+		return nil, storage.ErrKeyResultNotFound
+	}
+
+	return keyResult, nil
+}
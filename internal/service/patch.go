@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"sort"
+	"strings"
+	"time"
+)
+
+var errNotNullable = errors.New("cannot be null")
+
+// FieldErrors maps the fields of a patch to the reasons they were rejected
+type FieldErrors map[string]string
+
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	messages := make([]string, 0, len(fields))
+	for _, field := range fields {
+		messages = append(messages, field+": "+e[field])
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e FieldErrors) add(field string, err error) {
+	if err != nil {
+		e[field] = err.Error()
+	}
+}
+
+// PatchGoal applies an RFC 7396 merge patch to the goal. Priority and tags are cleared by null.
+func (s *goalService) PatchGoal(ctx context.Context, userID, id string, patchDTO *dto.PatchDTO) (*model.Goal, error) {
+	const op = "goalService.PatchGoal"
+
+	goal, err := s.getOwnedGoal(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	return s.updateGoal(ctx, op, goal, patchDTO.Version, func(goal *model.Goal) error {
+		return applyGoalPatch(goal, patchDTO.Fields)
+	})
+}
+
+// PatchChapter applies an RFC 7396 merge patch to the chapter. Priority is cleared by null,
+// setting is_done follows the rules of UpdateChapter.
+func (s *goalService) PatchChapter(ctx context.Context, userID, goalID, chapterID string, patchDTO *dto.PatchDTO) (*model.Chapter, error) {
+	const op = "goalService.PatchChapter"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	return s.updateChapter(ctx, op, goal, chapterID, patchDTO.Version, func(chapter *model.Chapter) error {
+		return applyChapterPatch(chapter, patchDTO.Fields)
+	})
+}
+
+// applyGoalPatch passes every field of the patch to the matching setter and reports all rejected fields at once
+func applyGoalPatch(goal *model.Goal, fields map[string]json.RawMessage) error {
+	errs := make(FieldErrors)
+
+	for field, value := range fields {
+		switch field {
+		case "title":
+			errs.add(field, patchField(value, false, func(title string) error {
+				_, err := goal.SetTitle(title)
+				return err
+			}))
+		case "description":
+			errs.add(field, patchField(value, false, func(description string) error {
+				_, err := goal.SetDescription(description)
+				return err
+			}))
+		case "deadline":
+			errs.add(field, patchField(value, false, func(deadline time.Time) error {
+				// an unchanged deadline is skipped, so overdue goals can still be edited
+				if deadline.Equal(goal.Deadline) {
+					return nil
+				}
+
+				_, err := goal.SetDeadline(deadline)
+				return err
+			}))
+		case "priority":
+			errs.add(field, patchField(value, true, func(priority int) error {
+				_, err := goal.SetPriority(priority)
+				return err
+			}))
+		case "tags":
+			errs.add(field, patchField(value, true, func(tags []string) error {
+				_, err := goal.SetTags(tags)
+				return err
+			}))
+		default:
+			errs[field] = "cannot be patched"
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// applyChapterPatch passes every field of the patch to the matching setter and reports all rejected fields at once
+func applyChapterPatch(chapter *model.Chapter, fields map[string]json.RawMessage) error {
+	errs := make(FieldErrors)
+
+	for field, value := range fields {
+		switch field {
+		case "title":
+			errs.add(field, patchField(value, false, func(title string) error {
+				_, err := chapter.SetTitle(title)
+				return err
+			}))
+		case "description":
+			errs.add(field, patchField(value, false, func(description string) error {
+				_, err := chapter.SetDescription(description)
+				return err
+			}))
+		case "deadline":
+			errs.add(field, patchField(value, false, func(deadline time.Time) error {
+				// an unchanged deadline is skipped, so overdue chapters can still be edited
+				if deadline.Equal(chapter.Deadline) {
+					return nil
+				}
+
+				_, err := chapter.SetDeadline(deadline)
+				return err
+			}))
+		case "priority":
+			errs.add(field, patchField(value, true, func(priority int) error {
+				_, err := chapter.SetPriority(priority)
+				return err
+			}))
+		case "is_done":
+			errs.add(field, patchField(value, false, func(isDone bool) error {
+				_, err := chapter.SetIsDone(isDone)
+				return err
+			}))
+		default:
+			errs[field] = "cannot be patched"
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+
+	return nil
+}
+
+// patchField decodes a patch value and passes it to set. A null passes the zero value when the field
+// is nullable and is rejected otherwise.
+func patchField[T any](value json.RawMessage, nullable bool, set func(T) error) error {
+	var decoded T
+
+	if bytes.Equal(bytes.TrimSpace(value), []byte("null")) {
+		if !nullable {
+			return errNotNullable
+		}
+
+		return set(decoded)
+	}
+
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return errors.New("must be " + jsonTypeName(decoded))
+	}
+
+	return set(decoded)
+}
+
+func jsonTypeName(value any) string {
+	switch value.(type) {
+	case string:
+		return "a string"
+	case int:
+		return "an integer"
+	case bool:
+		return "a boolean"
+	case []string:
+		return "an array of strings"
+	case time.Time:
+		return "an RFC 3339 timestamp"
+	default:
+		return fmt.Sprintf("a valid %T", value)
+	}
+}
@@ -0,0 +1,430 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"github.com/nordew/Strive/pkg/logger"
+	"math"
+	"time"
+)
+
+type revisionService struct {
+	revisionStorage storage.RevisionStorage
+	goalStorage     storage.GoalStorage
+	transactor      storage.Transactor
+	logger          logger.Logger
+}
+
+func NewRevisionService(
+	revisionStorage storage.RevisionStorage,
+	goalStorage storage.GoalStorage,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) RevisionService {
+	return &revisionService{
+		revisionStorage: revisionStorage,
+		goalStorage:     goalStorage,
+		transactor:      transactor,
+		logger:          logger,
+	}
+}
+
+func (s *revisionService) Record(ctx context.Context, goalID string) error {
+	const op = "revisionService.Record"
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		// the goal row lock serializes revisions of the goal, so a number is never taken twice
+		if err := s.goalStorage.LockGoalChapters(ctx, goalID); err != nil {
+			return err
+		}
+
+		goal, err := s.goalStorage.GetByID(ctx, goalID)
+		if err != nil {
+			return err
+		}
+
+		chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goalID)
+		if err != nil {
+			return err
+		}
+
+		snapshot := model.NewGoalSnapshot(*goal, derefChapters(chapters))
+
+		number := 1
+		latest, err := s.revisionStorage.GetLatest(ctx, goalID)
+		switch {
+		case errors.Is(err, storage.ErrGoalRevisionNotFound):
+		case err != nil:
+			return err
+		default:
+			equal, err := latest.Snapshot.Equal(snapshot)
+			if err != nil {
+				return fmt.Errorf("failed to compare snapshots: %w", err)
+			}
+
+			// progress and comment changes do not touch the snapshot
+			if equal {
+				return nil
+			}
+
+			number = latest.Number + 1
+		}
+
+		revision, err := model.NewGoalRevision(uuid.NewString(), goalID, number, ActorFromContext(ctx), snapshot, time.Now())
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		return s.revisionStorage.Create(ctx, revision)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to record revision of goal %s: %v", op, goalID, err)
+		return fmt.Errorf("failed to record goal revision: %w", err)
+	}
+
+	return nil
+}
+
+func (s *revisionService) List(ctx context.Context, userID, goalID string, before, limit int) ([]*model.GoalRevision, error) {
+	const op = "revisionService.List"
+
+	if limit <= 0 || limit > MaxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, MaxHistoryLimit)
+	}
+
+	if before <= 0 {
+		before = math.MaxInt32
+	}
+
+	if _, err := getOwnedGoal(ctx, s.goalStorage, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	revisions, err := s.revisionStorage.GetByGoalID(ctx, goalID, before, limit)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get revisions: %v", op, err)
+		return nil, fmt.Errorf("failed to get revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (s *revisionService) Get(ctx context.Context, userID, goalID string, number int) (*model.GoalRevision, error) {
+	const op = "revisionService.Get"
+
+	if _, err := getOwnedGoal(ctx, s.goalStorage, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	return s.getRevision(ctx, op, goalID, number)
+}
+
+func (s *revisionService) getRevision(ctx context.Context, op, goalID string, number int) (*model.GoalRevision, error) {
+	revision, err := s.revisionStorage.GetByNumber(ctx, goalID, number)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get revision %d of goal %s: %v", op, number, goalID, err)
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	return revision, nil
+}
+
+func (s *revisionService) Diff(ctx context.Context, userID, goalID string, from, to int) (*model.RevisionDiff, error) {
+	const op = "revisionService.Diff"
+
+	if _, err := getOwnedGoal(ctx, s.goalStorage, userID, goalID); err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	fromRevision, err := s.getRevision(ctx, op, goalID, from)
+	if err != nil {
+		return nil, err
+	}
+
+	toRevision, err := s.getRevision(ctx, op, goalID, to)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, chapterChanges, err := model.DiffSnapshots(fromRevision.Snapshot, toRevision.Snapshot)
+	if err != nil {
+		s.logger.Errorf("%s: failed to diff revisions: %v", op, err)
+		return nil, fmt.Errorf("failed to diff revisions: %w", err)
+	}
+
+	return &model.RevisionDiff{
+		From:     from,
+		To:       to,
+		Changes:  changes,
+		Chapters: chapterChanges,
+	}, nil
+}
+
+// RestoreRevision writes the goal fields of the revision back, and its chapters when withChapters is set.
+// Chapters missing from the revision are moved to the trash, trashed ones are taken out of it and purged
+// ones are recreated at the end of the goal.
+// The parent and type of the goal and the order of existing chapters are kept.
+func (s *goalService) RestoreRevision(ctx context.Context, userID, goalID string, number int, withChapters bool) (*model.Goal, error) {
+	const op = "goalService.RestoreRevision"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return nil, err
+	}
+
+	revision, err := s.revisionService.Get(ctx, userID, goal.ID, number)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := revision.Snapshot
+	before := *goal
+	if err := applyGoalSnapshot(goal, snapshot); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := goal.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		if err := s.goalStorage.Update(ctx, goal); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+			return err
+		}
+
+		if withChapters {
+			if err := s.restoreChapters(ctx, goal, snapshot.Chapters); err != nil {
+				return err
+			}
+
+			if err := s.recalculateProgress(ctx, goal); err != nil {
+				return err
+			}
+		}
+
+		restore := model.GoalRestore{GoalID: goal.ID, Revision: revision.Number, Chapters: withChapters}
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalRestored, restore); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to restore goal: %v", op, err)
+		return nil, fmt.Errorf("failed to restore goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// restoreChapters makes the chapters of the goal match the snapshots. Dependencies are set once every
+// chapter exists, so they may point to chapters that are recreated later.
+func (s *goalService) restoreChapters(ctx context.Context, goal *model.Goal, snapshots []model.ChapterSnapshot) error {
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]*model.Chapter, len(chapters))
+	for _, chapter := range chapters {
+		current[chapter.ID] = chapter
+	}
+
+	kept := make(map[string]bool, len(snapshots))
+	for _, snapshot := range snapshots {
+		kept[snapshot.ID] = true
+	}
+
+	for _, chapter := range chapters {
+		if kept[chapter.ID] {
+			continue
+		}
+
+		if err := s.goalStorage.TrashChapter(ctx, chapter.ID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionDelete, chapter, nil); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterDeleted, chapter); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	restored := make([]*model.Chapter, 0, len(snapshots))
+	previous := make(map[string]model.Chapter, len(snapshots))
+	for _, snapshot := range snapshots {
+		chapter, ok := current[snapshot.ID]
+		if !ok {
+			chapter, err = s.goalStorage.GetTrashedChapterByID(ctx, snapshot.ID)
+			switch {
+			case err == nil && chapter.GoalID == goal.ID:
+				if err := s.recoverChapter(ctx, goal, chapter); err != nil {
+					return err
+				}
+
+				ok = true
+			case err == nil:
+				return fmt.Errorf("%w: chapter %s belongs to another goal", ErrValidation, snapshot.ID)
+			case !errors.Is(err, storage.ErrChapterNotFound):
+				return err
+			}
+		}
+
+		if !ok {
+			chapter, err = model.NewChapter(snapshot.ID, goal.ID, snapshot.Title, snapshot.Description, snapshot.IsDone, snapshot.Deadline, snapshot.Priority, nil, now, now)
+			if err != nil {
+				return fmt.Errorf("%w: %v", ErrValidation, err)
+			}
+
+			last, err := s.goalStorage.GetLastChapterPosition(ctx, goal.ID)
+			if err != nil {
+				return err
+			}
+
+			position, err := lexorank.Between(last, "")
+			if err != nil {
+				return fmt.Errorf("failed to rank chapter: %w", err)
+			}
+
+			if _, err := chapter.SetPosition(position); err != nil {
+				return fmt.Errorf("%w: %v", ErrValidation, err)
+			}
+
+			if err := s.goalStorage.CreateChapter(ctx, chapter); err != nil {
+				return err
+			}
+
+			restored = append(restored, chapter)
+			continue
+		}
+
+		previous[chapter.ID] = *chapter
+		if err := applyChapterSnapshot(chapter, snapshot); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if _, err := chapter.SetUpdatedAt(now); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.UpdateChapter(ctx, chapter); err != nil {
+			return err
+		}
+
+		restored = append(restored, chapter)
+	}
+
+	for i, chapter := range restored {
+		if _, err := chapter.SetDependsOn(snapshots[i].DependsOn); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.SetChapterDependencies(ctx, chapter.ID, chapter.DependsOn); err != nil {
+			return err
+		}
+
+		before, ok := previous[chapter.ID]
+		if !ok {
+			if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionCreate, nil, chapter); err != nil {
+				return err
+			}
+
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterCreated, chapter); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		changes, err := model.Diff(&before, chapter)
+		if err != nil {
+			return fmt.Errorf("failed to diff chapter: %w", err)
+		}
+
+		if len(changes) == 0 {
+			continue
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionUpdate, &before, chapter); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterUpdated, chapter); err != nil {
+			return err
+		}
+
+		if chapter.IsDone && !before.IsDone {
+			if err := s.eventBus.Publish(ctx, goal.UserID, model.EventChapterCompleted, chapter); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// applyGoalSnapshot writes the goal fields of the snapshot back as they were, so a deadline that has passed
+// since the revision is restored too
+func applyGoalSnapshot(goal *model.Goal, snapshot *model.GoalSnapshot) error {
+	if _, err := goal.SetTitle(snapshot.Title); err != nil {
+		return err
+	}
+
+	if _, err := goal.SetDescription(snapshot.Description); err != nil {
+		return err
+	}
+
+	if _, err := goal.RestoreDeadline(snapshot.Deadline); err != nil {
+		return err
+	}
+
+	if _, err := goal.SetPriority(snapshot.Priority); err != nil {
+		return err
+	}
+
+	_, err := goal.SetTags(snapshot.Tags)
+	return err
+}
+
+// applyChapterSnapshot is applyGoalSnapshot for the chapters of the revision
+func applyChapterSnapshot(chapter *model.Chapter, snapshot model.ChapterSnapshot) error {
+	if _, err := chapter.SetTitle(snapshot.Title); err != nil {
+		return err
+	}
+
+	if _, err := chapter.SetDescription(snapshot.Description); err != nil {
+		return err
+	}
+
+	if _, err := chapter.RestoreDeadline(snapshot.Deadline); err != nil {
+		return err
+	}
+
+	if _, err := chapter.SetPriority(snapshot.Priority); err != nil {
+		return err
+	}
+
+	_, err := chapter.SetIsDone(snapshot.IsDone)
+	return err
+}
@@ -4,11 +4,20 @@ import (
 	"context"
 	"errors"
 	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/export"
 	"github.com/nordew/Strive/internal/model"
+	"io"
+	"time"
 )
 
 var (
-	ErrValidation = errors.New("validation error")
+	ErrValidation        = errors.New("validation error")
+	ErrGoalNotOKR        = errors.New("goal is not an okr goal")
+	ErrForeignParentGoal = errors.New("parent goal belongs to another user")
+	ErrUnmetDependencies = errors.New("chapter has unmet dependencies")
+	ErrForbidden         = errors.New("access denied")
+	// ErrPreconditionFailed is returned when the version a client expects is not the current one
+	ErrPreconditionFailed = errors.New("resource was modified")
 )
 
 type AuthResponse struct {
@@ -22,13 +31,280 @@ type (
 		Login(ctx context.Context, loginDTO *dto.LoginUserDTO) (*AuthResponse, error)
 		Authorize(ctx context.Context, telegramID int64, authDTO *dto.AuthorizeUserRequest) error
 
-		// Get supports id and telegramID
-		Get(ctx context.Context, id int) (*model.User, error)
+		Get(ctx context.Context, id string) (*model.User, error)
+		GetByTelegramID(ctx context.Context, telegramID int64) (*model.User, error)
 		Update(ctx context.Context, user *model.User) error
 		Delete(ctx context.Context, id int) error
 	}
 
 	GoalService interface {
-		Create(ctx context.Context, createDTO *dto.CreateGoalDTO) error
+		Create(ctx context.Context, userID string, createDTO *dto.CreateGoalDTO) error
+		// Update replaces the editable fields of the goal
+		Update(ctx context.Context, userID, id string, updateDTO *dto.UpdateGoalDTO) (*model.Goal, error)
+		// PatchGoal applies an RFC 7396 merge patch, all rejected fields are reported together as FieldErrors
+		PatchGoal(ctx context.Context, userID, id string, patchDTO *dto.PatchDTO) (*model.Goal, error)
+		// List returns the goals of the user matching the filter, archived goals are left out by default
+		List(ctx context.Context, userID string, filter model.GoalFilter) ([]*model.Goal, error)
+		// Archive and Unarchive change the goals of the user that are not in the requested state yet and return them
+		Archive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error)
+		Unarchive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error)
+		// Delete moves the goal and its sub-goals to the trash
+		Delete(ctx context.Context, userID, id string) error
+		// Batch runs up to model.MaxBatchOperations operations on goals and chapters of the user.
+		// Failed operations are reported in their results, in all_or_nothing mode the other ones are undone.
+		Batch(ctx context.Context, userID string, batchDTO *dto.BatchDTO) ([]*model.BatchResult, error)
+
+		// GetTree returns the goal with its sub-goals nested up to depth levels below it
+		GetTree(ctx context.Context, userID, id string, depth int) (*model.Goal, error)
+		// Move re-parents the goal with its whole subtree, an empty parentID makes it a root goal
+		Move(ctx context.Context, userID, id string, moveDTO *dto.MoveGoalDTO) error
+
+		CreateChapter(ctx context.Context, userID, goalID string, createDTO *dto.CreateChapterDTO) (*model.Chapter, error)
+		GetChapters(ctx context.Context, userID, goalID string) ([]*model.Chapter, error)
+		// ReorderChapter moves a single chapter and returns the chapters in their new order
+		ReorderChapter(ctx context.Context, userID, goalID string, moveDTO *dto.MoveChapterDTO) ([]*model.Chapter, error)
+		SetChapterDependencies(ctx context.Context, userID, goalID, chapterID string, dependenciesDTO *dto.SetChapterDependenciesDTO) (*model.Chapter, error)
+		// CompleteChapter marks the chapter done, unless one of its dependencies is still open and force is false
+		CompleteChapter(ctx context.Context, userID, goalID, chapterID string, force bool) (*model.Chapter, error)
+		UpdateChapter(ctx context.Context, userID, goalID, chapterID string, updateDTO *dto.UpdateChapterDTO) (*model.Chapter, error)
+		// PatchChapter applies an RFC 7396 merge patch, all rejected fields are reported together as FieldErrors
+		PatchChapter(ctx context.Context, userID, goalID, chapterID string, patchDTO *dto.PatchDTO) (*model.Chapter, error)
+		DeleteChapter(ctx context.Context, userID, goalID, chapterID string) error
+		GetCriticalPath(ctx context.Context, userID, goalID string) (*model.CriticalPath, error)
+
+		// RestoreRevision writes the goal fields of the revision back, and its chapters when withChapters is set.
+		// The result is recorded as a new revision.
+		RestoreRevision(ctx context.Context, userID, goalID string, number int, withChapters bool) (*model.Goal, error)
+
+		// CreateComment comments on the goal, or on one of its chapters when createDTO.ChapterID is set
+		CreateComment(ctx context.Context, userID, goalID string, createDTO *dto.CreateCommentDTO) (*model.Comment, error)
+		DeleteComment(ctx context.Context, userID, goalID, commentID string) error
+
+		// RestoreGoal, RestoreChapter and RestoreComment take items of the user out of the trash.
+		// Chapters and comments cannot be restored while their goal or chapter is in the trash.
+		RestoreGoal(ctx context.Context, userID, id string) (*model.Goal, error)
+		RestoreChapter(ctx context.Context, userID, id string) (*model.Chapter, error)
+		RestoreComment(ctx context.Context, userID, id string) (*model.Comment, error)
+
+		CreateKeyResult(ctx context.Context, userID, goalID string, createDTO *dto.CreateKeyResultDTO) (*model.KeyResult, error)
+		GetKeyResults(ctx context.Context, userID, goalID string) ([]*model.KeyResult, error)
+		DeleteKeyResult(ctx context.Context, userID, goalID, keyResultID string) error
+		CheckIn(ctx context.Context, userID, goalID, keyResultID string, checkInDTO *dto.CheckInKeyResultDTO) (*model.KeyResult, error)
+		GetCheckIns(ctx context.Context, userID, goalID, keyResultID string) ([]*model.CheckIn, error)
+	}
+
+	TemplateService interface {
+		Create(ctx context.Context, userID string, createDTO *dto.CreateTemplateDTO) (*model.GoalTemplate, error)
+		Get(ctx context.Context, userID, id string) (*model.GoalTemplate, error)
+		// List returns built-in, public and the user's own templates
+		List(ctx context.Context, userID string) ([]*model.GoalTemplate, error)
+		Delete(ctx context.Context, userID, id string) error
+		// Instantiate creates a goal with its chapters from the template in one transaction
+		Instantiate(ctx context.Context, userID, id string, instantiateDTO *dto.InstantiateTemplateDTO) (*model.Goal, error)
+	}
+
+	ExportService interface {
+		// Export streams all goals of the user to w in the given format
+		Export(ctx context.Context, userID string, format export.Format, w io.Writer) error
+	}
+
+	ImportService interface {
+		// Import parses r and creates all of its goals in one transaction. Nothing is stored when
+		// importDTO.DryRun is set or when an item is invalid, in which case ErrInvalidImport is returned with the report.
+		Import(ctx context.Context, userID string, importDTO *dto.ImportDTO, r io.Reader) (*ImportReport, error)
+	}
+
+	CalendarService interface {
+		GetFeed(ctx context.Context, userID string) (*model.CalendarFeed, error)
+		// RegenerateFeedToken creates the feed or replaces its token, the old feed URL stops working
+		RegenerateFeedToken(ctx context.Context, userID string) (*model.CalendarFeed, error)
+		RevokeFeedToken(ctx context.Context, userID string) error
+		// ResolveFeed returns the feed behind the token and the ETag of its current content
+		ResolveFeed(ctx context.Context, token string) (*model.CalendarFeed, string, error)
+		// WriteFeed streams the open goal and chapter deadlines of the user as an iCalendar
+		WriteFeed(ctx context.Context, userID string, w io.Writer) error
+	}
+
+	// CalDAVService backs the CalDAV endpoint, goals are exposed as task lists and chapters as tasks
+	CalDAVService interface {
+		GetCredential(ctx context.Context, userID string) (*model.CalDAVCredential, error)
+		// RegeneratePassword replaces the app password of the user and returns it in plain text once
+		RegeneratePassword(ctx context.Context, userID string) (*model.CalDAVCredential, string, error)
+		RevokePassword(ctx context.Context, userID string) error
+		Authenticate(ctx context.Context, userID, password string) error
+
+		// GetCalendars returns the open goals of the user
+		GetCalendars(ctx context.Context, userID string) ([]*model.Goal, error)
+		GetCalendar(ctx context.Context, userID, goalID string) (*model.Goal, []*model.Chapter, error)
+		// PutTask creates or updates the chapter, ifMatch and ifNoneMatch are compared with model.Chapter.ETag.
+		// It reports whether the chapter was created.
+		PutTask(ctx context.Context, userID, goalID, chapterID string, taskDTO *dto.CalDAVTaskDTO, ifMatch, ifNoneMatch string) (bool, error)
+		DeleteTask(ctx context.Context, userID, goalID, chapterID, ifMatch string) error
+	}
+
+	// WebhookService manages the webhooks of users and delivers signed event payloads to them
+	WebhookService interface {
+		// Create registers the webhook, its secret is only returned here
+		Create(ctx context.Context, userID string, createDTO *dto.CreateWebhookDTO) (*model.Webhook, error)
+		List(ctx context.Context, userID string) ([]*model.Webhook, error)
+		Delete(ctx context.Context, userID, id string) error
+		// Enable reactivates a webhook that was disabled after repeated failures
+		Enable(ctx context.Context, userID, id string) (*model.Webhook, error)
+		ListDeliveries(ctx context.Context, userID, webhookID string) ([]*model.WebhookDelivery, error)
+		// GetDelivery returns the delivery with its attempts
+		GetDelivery(ctx context.Context, userID, webhookID, deliveryID string) (*model.WebhookDelivery, error)
+		// Replay schedules a failed delivery again
+		Replay(ctx context.Context, userID, webhookID, deliveryID string) (*model.WebhookDelivery, error)
+
+		// HandleEvent queues deliveries of webhook events for the user's webhooks
+		HandleEvent(ctx context.Context, event *model.Event) error
+		// RunDispatcher sends queued deliveries with retries until ctx is cancelled
+		RunDispatcher(ctx context.Context)
+	}
+
+	// Subscriber reacts to domain events. Events are delivered at least once, so HandleEvent must
+	// tolerate duplicates. A returned error makes the bus retry the event later.
+	Subscriber interface {
+		HandleEvent(ctx context.Context, event *model.Event) error
+	}
+
+	// EventBus stores domain events in the outbox and relays them to in-process subscribers
+	EventBus interface {
+		// Publish writes the event to the outbox, within the transaction of ctx if there is one, so the
+		// event exists if and only if the change that caused it is committed
+		Publish(ctx context.Context, userID, eventType string, data any) error
+		// Subscribe registers the subscriber for the given event types, or for every event when none are given.
+		// Subscribers are registered in app.MustRun before the bus runs.
+		Subscribe(subscriber Subscriber, eventTypes ...string)
+		// Run relays outbox events to the subscribers until ctx is cancelled
+		Run(ctx context.Context)
+	}
+
+	// AuditService records who changed what. Entries are written within the transaction of the change.
+	AuditService interface {
+		// Record stores the changed fields between before and after, taking the actor and request ID from ctx.
+		// before is nil for creates and after is nil for deletes, updates without changes are not recorded.
+		Record(ctx context.Context, entityType, entityID, goalID, action string, before, after any) error
+		// GetGoalHistory returns entries of the goal, its chapters and comments created before the given time, newest first.
+		// The history of goals of other users is not found.
+		GetGoalHistory(ctx context.Context, userID, goalID string, before time.Time, limit int) ([]*model.AuditEntry, error)
+		// RunRetention deletes expired entries until ctx is cancelled
+		RunRetention(ctx context.Context)
+	}
+
+	// RevisionService keeps numbered snapshots of goals and their chapters. Revisions of goals of other
+	// users are not found.
+	RevisionService interface {
+		// Record snapshots the goal within the transaction of ctx, unless it equals the latest revision
+		Record(ctx context.Context, goalID string) error
+		// List returns revisions numbered below before without their snapshots, newest first.
+		// A zero before starts at the latest revision.
+		List(ctx context.Context, userID, goalID string, before, limit int) ([]*model.GoalRevision, error)
+		Get(ctx context.Context, userID, goalID string, number int) (*model.GoalRevision, error)
+		// Diff compares the goal fields and chapters of two revisions
+		Diff(ctx context.Context, userID, goalID string, from, to int) (*model.RevisionDiff, error)
+	}
+
+	// TrashService lists trashed items and purges them once the retention has passed
+	TrashService interface {
+		List(ctx context.Context, userID string) ([]*model.TrashItem, error)
+		// RunPurge permanently deletes expired items until ctx is cancelled
+		RunPurge(ctx context.Context)
+	}
+
+	// ArchiveService archives completed goals after a while
+	ArchiveService interface {
+		// RunAutoArchive archives completed goals left unchanged for the configured time until ctx is cancelled
+		RunAutoArchive(ctx context.Context)
+	}
+
+	// IdempotencyService lets clients retry mutating requests safely by replaying the first response
+	IdempotencyService interface {
+		// Begin claims the key for a request with the given fingerprint. It returns the stored record when
+		// the request was handled before, and nil when it should be handled now and passed to Complete.
+		Begin(ctx context.Context, scope, key, fingerprint string) (*model.IdempotencyRecord, error)
+		// Complete stores the response for replay, server errors release the key so the request can be retried
+		Complete(ctx context.Context, scope, key string, statusCode int, contentType string, body []byte) error
+		// RunPurge deletes expired keys until ctx is cancelled
+		RunPurge(ctx context.Context)
+	}
+
+	// TagService manages the tags of a user, renaming and merging them in every goal using them
+	TagService interface {
+		// List returns the tags of the user with their usage counts. A non-empty prefix turns it into
+		// autocompletion, returning up to limit matching tags with the most used first.
+		List(ctx context.Context, userID, prefix string, limit int) ([]*model.Tag, error)
+		Create(ctx context.Context, userID string, createDTO *dto.CreateTagDTO) (*model.Tag, error)
+		// Update changes the color and emoji of the tag, a new name renames it in every goal
+		Update(ctx context.Context, userID, id string, updateDTO *dto.UpdateTagDTO) (*model.Tag, error)
+		// Merge replaces the tag with the target in every goal and deletes it
+		Merge(ctx context.Context, userID, id string, mergeDTO *dto.MergeTagDTO) (*model.Tag, error)
+	}
+
+	// ViewService manages saved views, named filter expressions that list matching goals
+	ViewService interface {
+		List(ctx context.Context, userID string) ([]*model.SavedView, error)
+		Create(ctx context.Context, userID string, createDTO *dto.SaveViewDTO) (*model.SavedView, error)
+		Update(ctx context.Context, userID, id string, updateDTO *dto.SaveViewDTO) (*model.SavedView, error)
+		Delete(ctx context.Context, userID, id string) error
+		// Goals returns the goals matching the view with the given ID
+		Goals(ctx context.Context, userID, id string) ([]*model.Goal, error)
+		// GoalsByName returns the view with the given name, ignoring case, and the goals matching it
+		GoalsByName(ctx context.Context, userID, name string) (*model.SavedView, []*model.Goal, error)
+	}
+
+	// StatsService computes insights into the goals of a user. Results are cached per user until the user's
+	// goals change, it subscribes to the event bus to notice the changes and records them in the database,
+	// so the caches of all instances notice them.
+	StatsService interface {
+		// Get returns the statistics of the user, the completion rate covers the given number of weeks and
+		// weekdays and hours are in the time zone tz, an empty tz is UTC
+		Get(ctx context.Context, userID string, weeks int, tz string) (*model.Stats, error)
+
+		// HandleEvent bumps the stats version of the event's user, which invalidates the cached statistics
+		HandleEvent(ctx context.Context, event *model.Event) error
+	}
+
+	// GamificationService awards XP for completed goals and chapters and unlocks achievements. It subscribes to
+	// the event bus and publishes achievement.unlocked for every badge a user earns.
+	GamificationService interface {
+		// Profile returns the XP, level, streak and unlocked achievements of the user
+		Profile(ctx context.Context, userID string) (*model.Profile, error)
+
+		// HandleEvent awards the XP for goal.completed and chapter.completed and unlocks the achievements reached
+		HandleEvent(ctx context.Context, event *model.Event) error
+	}
+
+	// FriendService connects users through single-use invites and ranks them against their friends
+	FriendService interface {
+		// CreateInvite returns a new invite of the user, accepting it makes both users friends
+		CreateInvite(ctx context.Context, userID string) (*model.FriendInvite, error)
+		// AcceptInvite befriends the user with the inviter and returns the inviter
+		AcceptInvite(ctx context.Context, userID, code string) (*model.Friend, error)
+		List(ctx context.Context, userID string) ([]*model.Friend, error)
+		// Remove disconnects the users on both sides
+		Remove(ctx context.Context, userID, friendID string) error
+
+		GetPrivacy(ctx context.Context, userID string) (*model.PrivacySettings, error)
+		UpdatePrivacy(ctx context.Context, userID string, updateDTO *dto.UpdatePrivacyDTO) (*model.PrivacySettings, error)
+
+		// Leaderboard ranks the user and their friends by xp or chapters in the current week or month
+		Leaderboard(ctx context.Context, userID, period, metric string) (*model.Leaderboard, error)
+	}
+
+	// FocusService times focus sessions on chapters. The server keeps the time, clients only start, pause,
+	// resume and stop sessions. Ended sessions publish focus.ended.
+	FocusService interface {
+		// Start returns ErrFocusSessionActive while the user has a session that has not ended
+		Start(ctx context.Context, userID string, startDTO *dto.StartFocusDTO) (*model.FocusSession, error)
+		// Active returns the session of the user that has not ended
+		Active(ctx context.Context, userID string) (*model.FocusSession, error)
+		Pause(ctx context.Context, userID, id string) (*model.FocusSession, error)
+		Resume(ctx context.Context, userID, id string) (*model.FocusSession, error)
+		Stop(ctx context.Context, userID, id string) (*model.FocusSession, error)
+		// Totals returns the time focused on the goal and each of its chapters
+		Totals(ctx context.Context, userID, goalID string) (*model.FocusTotals, error)
+		// RunTimer ends pomodoros and forgotten sessions once they reach their end, until ctx is cancelled
+		RunTimer(ctx context.Context)
 	}
 )
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"sync"
+	"time"
+)
+
+// statsCacheTTL bounds how long cached statistics are served, changes that publish no events show up after
+// it at the latest
+const statsCacheTTL = 10 * time.Minute
+
+// statsKey includes the stats version of the user, which every change of the user's goals bumps in the
+// database. Entries of older versions are never served again, no matter which instance handled the change.
+type statsKey struct {
+	userID  string
+	weeks   int
+	tz      string
+	version int64
+}
+
+type cachedStats struct {
+	stats     *model.Stats
+	expiresAt time.Time
+}
+
+type statsService struct {
+	goalStorage  storage.GoalStorage
+	focusStorage storage.FocusStorage
+	logger       logger.Logger
+
+	mu    sync.Mutex
+	cache map[statsKey]cachedStats
+}
+
+func NewStatsService(goalStorage storage.GoalStorage, focusStorage storage.FocusStorage, logger logger.Logger) StatsService {
+	return &statsService{
+		goalStorage:  goalStorage,
+		focusStorage: focusStorage,
+		logger:       logger,
+		cache:        make(map[statsKey]cachedStats),
+	}
+}
+
+func (s *statsService) Get(ctx context.Context, userID string, weeks int, tz string) (*model.Stats, error) {
+	const op = "statsService.Get"
+
+	weeks, err := model.ParseStatsWeeks(weeks)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown time zone %q", ErrValidation, tz)
+	}
+
+	// the version is read before the statistics, so a change committed while they are computed bumps it
+	// past the key they are stored under
+	version, err := s.goalStorage.GetStatsVersion(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get stats version: %v", op, err)
+		return nil, fmt.Errorf("failed to get stats version: %w", err)
+	}
+
+	key := statsKey{userID: userID, weeks: weeks, tz: loc.String(), version: version}
+	now := time.Now()
+
+	if stats, ok := s.cached(key, now); ok {
+		return stats, nil
+	}
+
+	records, err := s.goalStorage.GetCompletionRecords(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get completion records: %v", op, err)
+		return nil, fmt.Errorf("failed to get completion records: %w", err)
+	}
+
+	stats := model.ComputeStats(records, now, weeks, loc)
+
+	focus, err := s.focusStorage.GetGoalTotals(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get focus totals: %v", op, err)
+		return nil, fmt.Errorf("failed to get focus totals: %w", err)
+	}
+
+	if focus != nil {
+		stats.Focus = focus
+	}
+	s.store(key, stats, now)
+
+	return stats, nil
+}
+
+func (s *statsService) HandleEvent(ctx context.Context, event *model.Event) error {
+	if err := s.goalStorage.BumpStatsVersion(ctx, event.UserID); err != nil {
+		return err
+	}
+
+	// entries of the old version are never hit again, dropping them only frees memory early
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key := range s.cache {
+		if key.userID == event.UserID {
+			delete(s.cache, key)
+		}
+	}
+
+	return nil
+}
+
+func (s *statsService) cached(key statsKey, now time.Time) (*model.Stats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+
+	return entry.stats, true
+}
+
+func (s *statsService) store(key statsKey, stats *model.Stats, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// drop expired entries so users who stop asking do not keep their statistics in memory
+	for k, entry := range s.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(s.cache, k)
+		}
+	}
+
+	s.cache[key] = cachedStats{stats: stats, expiresAt: now.Add(statsCacheTTL)}
+}
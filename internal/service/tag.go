@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+type tagService struct {
+	tagStorage      storage.TagStorage
+	goalStorage     storage.GoalStorage
+	eventBus        EventBus
+	auditService    AuditService
+	revisionService RevisionService
+	transactor      storage.Transactor
+	logger          logger.Logger
+}
+
+func NewTagService(
+	tagStorage storage.TagStorage,
+	goalStorage storage.GoalStorage,
+	eventBus EventBus,
+	auditService AuditService,
+	revisionService RevisionService,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) TagService {
+	return &tagService{
+		tagStorage:      tagStorage,
+		goalStorage:     goalStorage,
+		eventBus:        eventBus,
+		auditService:    auditService,
+		revisionService: revisionService,
+		transactor:      transactor,
+		logger:          logger,
+	}
+}
+
+func (s *tagService) List(ctx context.Context, userID, prefix string, limit int) ([]*model.Tag, error) {
+	const op = "tagService.List"
+
+	var (
+		tags []*model.Tag
+		err  error
+	)
+
+	prefix = model.NormalizeTag(prefix)
+	if prefix == "" {
+		tags, err = s.tagStorage.GetByUserID(ctx, userID)
+	} else {
+		if limit <= 0 || limit > model.MaxTagSuggestions {
+			return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrValidation, model.MaxTagSuggestions)
+		}
+
+		tags, err = s.tagStorage.Suggest(ctx, userID, prefix, limit)
+	}
+	if err != nil {
+		s.logger.Errorf("%s: failed to get tags: %v", op, err)
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func (s *tagService) Create(ctx context.Context, userID string, createDTO *dto.CreateTagDTO) (*model.Tag, error) {
+	const op = "tagService.Create"
+
+	tag, err := model.NewTag(uuid.NewString(), userID, createDTO.Name, createDTO.Color, createDTO.Emoji, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.tagStorage.Create(ctx, tag); err != nil {
+		s.logger.Errorf("%s: failed to create tag: %v", op, err)
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (s *tagService) Update(ctx context.Context, userID, id string, updateDTO *dto.UpdateTagDTO) (*model.Tag, error) {
+	const op = "tagService.Update"
+
+	tag, err := s.getOwnedTag(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get tag: %v", op, err)
+		return nil, err
+	}
+
+	previousName := tag.Name
+	if err := applyTagUpdate(tag, updateDTO); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.tagStorage.Update(ctx, tag); err != nil {
+			return err
+		}
+
+		if tag.Name == previousName {
+			return nil
+		}
+
+		return s.replaceGoalTag(ctx, userID, previousName, tag.Name)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to update tag: %v", op, err)
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+func (s *tagService) Merge(ctx context.Context, userID, id string, mergeDTO *dto.MergeTagDTO) (*model.Tag, error) {
+	const op = "tagService.Merge"
+
+	if id == mergeDTO.IntoID {
+		return nil, fmt.Errorf("%w: a tag cannot be merged into itself", ErrValidation)
+	}
+
+	source, err := s.getOwnedTag(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get tag: %v", op, err)
+		return nil, err
+	}
+
+	target, err := s.getOwnedTag(ctx, userID, mergeDTO.IntoID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get target tag: %v", op, err)
+		return nil, err
+	}
+
+	if _, err := target.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.replaceGoalTag(ctx, userID, source.Name, target.Name); err != nil {
+			return err
+		}
+
+		return s.tagStorage.Merge(ctx, source, target)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to merge tags: %v", op, err)
+		return nil, fmt.Errorf("failed to merge tags: %w", err)
+	}
+
+	// the usage count changed with the merge, so the target is read again
+	merged, err := s.tagStorage.GetByID(ctx, target.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get merged tag: %v", op, err)
+		return nil, fmt.Errorf("failed to get merged tag: %w", err)
+	}
+
+	return merged, nil
+}
+
+// replaceGoalTag replaces the tag from with to in every goal of the user using it, keeping the order and
+// dropping the duplicate when a goal has both. Each goal is recorded and announced like any other update,
+// goals in the trash only get the new name.
+func (s *tagService) replaceGoalTag(ctx context.Context, userID, from, to string) error {
+	if err := s.goalStorage.ReplaceTrashedTag(ctx, userID, from, to); err != nil {
+		return err
+	}
+
+	goals, err := s.goalStorage.LockByTag(ctx, userID, from)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, goal := range goals {
+		before := *goal
+
+		tags := make([]string, len(goal.Tags))
+		for i, tag := range goal.Tags {
+			if tag == from {
+				tag = to
+			}
+
+			tags[i] = tag
+		}
+
+		if _, err := goal.SetTags(tags); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if _, err := goal.SetUpdatedAt(now); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.Update(ctx, goal); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionUpdate, &before, goal); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalUpdated, goal); err != nil {
+			return err
+		}
+
+		if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// getOwnedTag returns the tag if it belongs to the user, tags of other users are not found
+func (s *tagService) getOwnedTag(ctx context.Context, userID, id string) (*model.Tag, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, storage.ErrTagNotFound
+	}
+
+	tag, err := s.tagStorage.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	if tag.UserID != userID {
+		return nil, storage.ErrTagNotFound
+	}
+
+	return tag, nil
+}
+
+func applyTagUpdate(tag *model.Tag, updateDTO *dto.UpdateTagDTO) error {
+	if updateDTO.Name != nil {
+		if _, err := tag.SetName(*updateDTO.Name); err != nil {
+			return err
+		}
+	}
+
+	if updateDTO.Color != nil {
+		if _, err := tag.SetColor(*updateDTO.Color); err != nil {
+			return err
+		}
+	}
+
+	if updateDTO.Emoji != nil {
+		if _, err := tag.SetEmoji(*updateDTO.Emoji); err != nil {
+			return err
+		}
+	}
+
+	_, err := tag.SetUpdatedAt(time.Now())
+	return err
+}
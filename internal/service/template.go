@@ -0,0 +1,209 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+type templateService struct {
+	templateStorage storage.TemplateStorage
+	goalStorage     storage.GoalStorage
+	eventBus        EventBus
+	auditService    AuditService
+	revisionService RevisionService
+	transactor      storage.Transactor
+	logger          logger.Logger
+}
+
+func NewTemplateService(
+	templateStorage storage.TemplateStorage,
+	goalStorage storage.GoalStorage,
+	eventBus EventBus,
+	auditService AuditService,
+	revisionService RevisionService,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) TemplateService {
+	return &templateService{
+		templateStorage: templateStorage,
+		goalStorage:     goalStorage,
+		eventBus:        eventBus,
+		auditService:    auditService,
+		revisionService: revisionService,
+		transactor:      transactor,
+		logger:          logger,
+	}
+}
+
+func (s *templateService) Create(ctx context.Context, userID string, createDTO *dto.CreateTemplateDTO) (*model.GoalTemplate, error) {
+	const op = "templateService.Create"
+
+	if userID == "" {
+		return nil, fmt.Errorf("%w: user_id cannot be empty", ErrValidation)
+	}
+
+	templateID := uuid.NewString()
+	chapters := make([]model.ChapterBlueprint, 0, len(createDTO.Chapters))
+	for _, chapterDTO := range createDTO.Chapters {
+		chapter, err := model.NewChapterBlueprint(
+			uuid.NewString(),
+			templateID,
+			chapterDTO.Title,
+			chapterDTO.Description,
+			chapterDTO.DeadlineOffsetDays,
+			chapterDTO.Priority,
+		)
+		if err != nil {
+			s.logger.Errorf("%s: failed to create chapter blueprint: %v", op, err)
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		chapters = append(chapters, *chapter)
+	}
+
+	now := time.Now()
+	template, err := model.NewGoalTemplate(
+		templateID,
+		userID,
+		createDTO.Title,
+		createDTO.Description,
+		createDTO.Tags,
+		createDTO.IsPublic,
+		createDTO.DeadlineOffsetDays,
+		chapters,
+		now,
+		now,
+	)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create template: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.templateStorage.Create(ctx, template); err != nil {
+		s.logger.Errorf("%s: failed to create template: %v", op, err)
+		return nil, fmt.Errorf("failed to create template: %w", err)
+	}
+
+	return template, nil
+}
+
+func (s *templateService) Get(ctx context.Context, userID, id string) (*model.GoalTemplate, error) {
+	const op = "templateService.Get"
+
+	template, err := s.templateStorage.GetByID(ctx, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get template: %v", op, err)
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+
+	// private templates of other users are reported as missing
+	if !template.IsVisibleTo(userID) {
+		return nil, storage.ErrTemplateNotFound
+	}
+
+	return template, nil
+}
+
+func (s *templateService) List(ctx context.Context, userID string) ([]*model.GoalTemplate, error) {
+	const op = "templateService.List"
+
+	templates, err := s.templateStorage.GetAvailable(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get templates: %v", op, err)
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	return templates, nil
+}
+
+func (s *templateService) Delete(ctx context.Context, userID, id string) error {
+	const op = "templateService.Delete"
+
+	template, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return err
+	}
+
+	if template.UserID != userID {
+		s.logger.Infof("%s: user %s cannot delete template %s", op, userID, id)
+		return ErrForbidden
+	}
+
+	if err := s.templateStorage.Delete(ctx, id); err != nil {
+		s.logger.Errorf("%s: failed to delete template: %v", op, err)
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	return nil
+}
+
+func (s *templateService) Instantiate(ctx context.Context, userID, id string, instantiateDTO *dto.InstantiateTemplateDTO) (*model.Goal, error) {
+	const op = "templateService.Instantiate"
+
+	template, err := s.Get(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	start := instantiateDTO.StartDate
+	if start.IsZero() {
+		start = now
+	}
+
+	goal, err := template.Instantiate(userID, start, now)
+	if err != nil {
+		s.logger.Errorf("%s: failed to instantiate template: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	position := ""
+	for i := range goal.Chapters {
+		if position, err = lexorank.Between(position, ""); err != nil {
+			return nil, fmt.Errorf("failed to rank chapter: %w", err)
+		}
+
+		if _, err := goal.Chapters[i].SetPosition(position); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Create(ctx, goal); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionCreate, nil, goal); err != nil {
+			return err
+		}
+
+		for i := range goal.Chapters {
+			if err := s.goalStorage.CreateChapter(ctx, &goal.Chapters[i]); err != nil {
+				return err
+			}
+
+			if err := s.auditService.Record(ctx, model.AuditEntityChapter, goal.Chapters[i].ID, goal.ID, model.AuditActionCreate, nil, &goal.Chapters[i]); err != nil {
+				return err
+			}
+		}
+
+		if err := s.revisionService.Record(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		return s.eventBus.Publish(ctx, goal.UserID, model.EventGoalCreated, goal)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to create goal from template: %v", op, err)
+		return nil, fmt.Errorf("failed to create goal from template: %w", err)
+	}
+
+	return goal, nil
+}
@@ -0,0 +1,389 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/lexorank"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+const trashPurgeInterval = time.Hour
+
+var ErrParentTrashed = errors.New("parent is in the trash")
+
+func (s *goalService) Delete(ctx context.Context, userID, id string) error {
+	const op = "goalService.Delete"
+
+	goal, err := s.getOwnedGoal(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Trash(ctx, goal.ID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionDelete, goal, nil); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalDeleted, goal); err != nil {
+			return err
+		}
+
+		return s.recalculateParentProgress(ctx, goal.ParentID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to delete goal: %v", op, err)
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	return nil
+}
+
+func (s *goalService) DeleteComment(ctx context.Context, userID, goalID, commentID string) error {
+	const op = "goalService.DeleteComment"
+
+	goal, err := s.getOwnedGoal(ctx, userID, goalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		return err
+	}
+
+	comment, err := s.goalStorage.GetCommentByID(ctx, commentID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get comment: %v", op, err)
+		return err
+	}
+
+	if comment.GoalID != goal.ID {
+		s.logger.Errorf("%s: comment %s not found in goal %s", op, commentID, goalID)
+		return storage.ErrCommentNotFound
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.TrashComment(ctx, comment.ID, time.Now()); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityComment, comment.ID, goal.ID, model.AuditActionDelete, comment, nil); err != nil {
+			return err
+		}
+
+		return s.eventBus.Publish(ctx, goal.UserID, model.EventCommentDeleted, comment)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to delete comment: %v", op, err)
+		return fmt.Errorf("failed to delete comment: %w", err)
+	}
+
+	return nil
+}
+
+// RestoreGoal takes the goal and the sub-goals trashed together with it out of the trash.
+// A goal whose parent is still in the trash comes back as a root goal.
+func (s *goalService) RestoreGoal(ctx context.Context, userID, id string) (*model.Goal, error) {
+	const op = "goalService.RestoreGoal"
+
+	goal, err := s.goalStorage.GetTrashedByID(ctx, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get trashed goal: %v", op, err)
+		return nil, fmt.Errorf("failed to get trashed goal: %w", err)
+	}
+
+	if goal.UserID != userID {
+		s.logger.Errorf("%s: goal %s belongs to another user", op, goal.ID)
+		return nil, storage.ErrGoalNotFound
+	}
+
+	before := *goal
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.Restore(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		if goal.ParentID != "" {
+			_, err := s.goalStorage.GetByID(ctx, goal.ParentID)
+			switch {
+			case errors.Is(err, storage.ErrGoalNotFound):
+				if err := s.goalStorage.Move(ctx, goal.ID, ""); err != nil {
+					return err
+				}
+
+				if _, err := goal.SetParentID(""); err != nil {
+					return fmt.Errorf("%w: %v", ErrValidation, err)
+				}
+			case err != nil:
+				return err
+			}
+		}
+
+		goal.DeletedAt = nil
+		if err := s.auditService.Record(ctx, model.AuditEntityGoal, goal.ID, goal.ID, model.AuditActionRestore, &before, goal); err != nil {
+			return err
+		}
+
+		if err := s.eventBus.Publish(ctx, goal.UserID, model.EventGoalRecovered, goal); err != nil {
+			return err
+		}
+
+		return s.recalculateParentProgress(ctx, goal.ParentID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to restore goal: %v", op, err)
+		return nil, fmt.Errorf("failed to restore goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+// RestoreChapter takes the chapter out of the trash, its goal must not be in the trash
+func (s *goalService) RestoreChapter(ctx context.Context, userID, id string) (*model.Chapter, error) {
+	const op = "goalService.RestoreChapter"
+
+	chapter, err := s.goalStorage.GetTrashedChapterByID(ctx, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get trashed chapter: %v", op, err)
+		return nil, err
+	}
+
+	goal, err := s.getTrashOwner(ctx, userID, chapter.GoalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		if errors.Is(err, storage.ErrGoalNotFound) {
+			return nil, storage.ErrChapterNotFound
+		}
+
+		return nil, err
+	}
+
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.LockGoalChapters(ctx, goal.ID); err != nil {
+			return err
+		}
+
+		if err := s.recoverChapter(ctx, goal, chapter); err != nil {
+			return err
+		}
+
+		if err := s.recalculateProgress(ctx, goal); err != nil {
+			return err
+		}
+
+		return s.revisionService.Record(ctx, goal.ID)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to restore chapter: %v", op, err)
+		return nil, fmt.Errorf("failed to restore chapter: %w", err)
+	}
+
+	return chapter, nil
+}
+
+// RestoreComment takes the comment out of the trash, its goal and chapter must not be in the trash
+func (s *goalService) RestoreComment(ctx context.Context, userID, id string) (*model.Comment, error) {
+	const op = "goalService.RestoreComment"
+
+	comment, err := s.goalStorage.GetTrashedCommentByID(ctx, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get trashed comment: %v", op, err)
+		return nil, err
+	}
+
+	goal, err := s.getTrashOwner(ctx, userID, comment.GoalID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get goal: %v", op, err)
+		if errors.Is(err, storage.ErrGoalNotFound) {
+			return nil, storage.ErrCommentNotFound
+		}
+
+		return nil, err
+	}
+
+	if comment.ChapterID != "" {
+		if _, err := s.goalStorage.GetChapterByID(ctx, comment.ChapterID); err != nil {
+			s.logger.Errorf("%s: failed to get chapter: %v", op, err)
+			if errors.Is(err, storage.ErrChapterNotFound) {
+				return nil, ErrParentTrashed
+			}
+
+			return nil, err
+		}
+	}
+
+	before := *comment
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.goalStorage.RestoreComment(ctx, comment.ID); err != nil {
+			return err
+		}
+
+		comment.DeletedAt = nil
+		if err := s.auditService.Record(ctx, model.AuditEntityComment, comment.ID, goal.ID, model.AuditActionRestore, &before, comment); err != nil {
+			return err
+		}
+
+		return s.eventBus.Publish(ctx, goal.UserID, model.EventCommentRecovered, comment)
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to restore comment: %v", op, err)
+		return nil, fmt.Errorf("failed to restore comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// getTrashOwner returns the live goal of a trashed chapter or comment owned by the user. A goal of the user
+// that is itself in the trash gives ErrParentTrashed, any other goal storage.ErrGoalNotFound.
+func (s *goalService) getTrashOwner(ctx context.Context, userID, goalID string) (*model.Goal, error) {
+	goal, err := s.goalStorage.GetByID(ctx, goalID)
+	if err == nil {
+		if goal.UserID != userID {
+			return nil, storage.ErrGoalNotFound
+		}
+
+		return goal, nil
+	}
+
+	if !errors.Is(err, storage.ErrGoalNotFound) {
+		return nil, err
+	}
+
+	trashed, err := s.goalStorage.GetTrashedByID(ctx, goalID)
+	if err != nil {
+		return nil, err
+	}
+
+	if trashed.UserID != userID {
+		return nil, storage.ErrGoalNotFound
+	}
+
+	return nil, ErrParentTrashed
+}
+
+// recoverChapter takes the chapter out of the trash and records it. The chapter goes to the end of the goal
+// when another chapter took its position in the meantime. The goal chapters must be locked.
+func (s *goalService) recoverChapter(ctx context.Context, goal *model.Goal, chapter *model.Chapter) error {
+	chapters, err := s.goalStorage.GetChaptersByGoalID(ctx, goal.ID)
+	if err != nil {
+		return err
+	}
+
+	before := *chapter
+	for _, other := range chapters {
+		if other.Position != chapter.Position {
+			continue
+		}
+
+		last, err := s.goalStorage.GetLastChapterPosition(ctx, goal.ID)
+		if err != nil {
+			return err
+		}
+
+		position, err := lexorank.Between(last, "")
+		if err != nil {
+			return fmt.Errorf("failed to rank chapter: %w", err)
+		}
+
+		if _, err := chapter.SetPosition(position); err != nil {
+			return fmt.Errorf("%w: %v", ErrValidation, err)
+		}
+
+		if err := s.goalStorage.UpdateChapterPosition(ctx, chapter.ID, chapter.Position); err != nil {
+			return err
+		}
+
+		break
+	}
+
+	if err := s.goalStorage.RestoreChapter(ctx, chapter.ID); err != nil {
+		return err
+	}
+
+	chapter.DeletedAt = nil
+	if err := s.auditService.Record(ctx, model.AuditEntityChapter, chapter.ID, goal.ID, model.AuditActionRestore, &before, chapter); err != nil {
+		return err
+	}
+
+	return s.eventBus.Publish(ctx, goal.UserID, model.EventChapterRecovered, chapter)
+}
+
+// recalculateParentProgress recalculates the parent after one of its sub-goals left or came back
+func (s *goalService) recalculateParentProgress(ctx context.Context, parentID string) error {
+	if parentID == "" {
+		return nil
+	}
+
+	parent, err := s.goalStorage.GetByID(ctx, parentID)
+	if err != nil {
+		return fmt.Errorf("failed to get parent goal: %w", err)
+	}
+
+	return s.recalculateProgress(ctx, parent)
+}
+
+type trashService struct {
+	goalStorage storage.GoalStorage
+	retention   time.Duration
+	logger      logger.Logger
+}
+
+// NewTrashService creates a TrashService that purges items trashed longer than retention ago,
+// a zero retention keeps them forever
+func NewTrashService(goalStorage storage.GoalStorage, retention time.Duration, logger logger.Logger) TrashService {
+	return &trashService{
+		goalStorage: goalStorage,
+		retention:   retention,
+		logger:      logger,
+	}
+}
+
+func (s *trashService) List(ctx context.Context, userID string) ([]*model.TrashItem, error) {
+	const op = "trashService.List"
+
+	items, err := s.goalStorage.GetTrash(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get trash: %v", op, err)
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+
+	if s.retention > 0 {
+		for _, item := range items {
+			purgeAt := item.DeletedAt.Add(s.retention)
+			item.PurgeAt = &purgeAt
+		}
+	}
+
+	return items, nil
+}
+
+func (s *trashService) RunPurge(ctx context.Context) {
+	const op = "trashService.RunPurge"
+
+	if s.retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(trashPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := s.goalStorage.Purge(ctx, time.Now().Add(-s.retention))
+		if err != nil {
+			s.logger.Errorf("%s: failed to purge trash: %v", op, err)
+		} else if purged > 0 {
+			s.logger.Infof("%s: purged %d trashed items", op, purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
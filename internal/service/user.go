@@ -14,16 +14,28 @@ import (
 )
 
 type userService struct {
-	userStorage storage.UserStorage
-	auth        auth.Authenticator
-	logger      logger.Logger
+	userStorage  storage.UserStorage
+	eventBus     EventBus
+	auditService AuditService
+	transactor   storage.Transactor
+	auth         auth.Authenticator
+	logger       logger.Logger
 }
 
 // NewUserService creates a new UserService instance.
-func NewUserService(userStorage storage.UserStorage, auth auth.Authenticator, logger logger.Logger) UserService {
+func NewUserService(userStorage storage.UserStorage, eventBus EventBus, auditService AuditService, transactor storage.Transactor, auth auth.Authenticator, logger logger.Logger) UserService {
 	if userStorage == nil {
 		panic("userStorage cannot be nil")
 	}
+	if eventBus == nil {
+		panic("eventBus cannot be nil")
+	}
+	if auditService == nil {
+		panic("auditService cannot be nil")
+	}
+	if transactor == nil {
+		panic("transactor cannot be nil")
+	}
 	if auth == nil {
 		panic("auth cannot be nil")
 	}
@@ -32,9 +44,12 @@ func NewUserService(userStorage storage.UserStorage, auth auth.Authenticator, lo
 	}
 
 	return &userService{
-		userStorage: userStorage,
-		auth:        auth,
-		logger:      logger,
+		userStorage:  userStorage,
+		eventBus:     eventBus,
+		auditService: auditService,
+		transactor:   transactor,
+		auth:         auth,
+		logger:       logger,
 	}
 }
 
@@ -46,13 +61,24 @@ func (s *userService) Login(ctx context.Context, loginDTO *dto.LoginUserDTO) (*A
 		if errors.Is(err, storage.ErrorUserNotFound) {
 			now := time.Now()
 
-			user, err := model.NewUser(uuid.NewString(), loginDTO.TelegramID, "", "", 0, now, now)
+			user, err = model.NewUser(uuid.NewString(), loginDTO.TelegramID, "", "", 0, now, now)
 			if err != nil {
 				s.logger.Errorf("[%s] failed to create new user: %v", op, err)
 				return nil, fmt.Errorf("failed to create new user: %w", err)
 			}
 
-			if err := s.userStorage.Create(ctx, user); err != nil {
+			err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+				if err := s.userStorage.Create(ctx, user); err != nil {
+					return err
+				}
+
+				if err := s.auditService.Record(ctx, model.AuditEntityUser, user.ID, "", model.AuditActionCreate, nil, user); err != nil {
+					return err
+				}
+
+				return s.eventBus.Publish(ctx, user.ID, model.EventUserRegistered, user)
+			})
+			if err != nil {
 				s.logger.Errorf("[%s] failed to create new user: %v", op, err)
 				return nil, fmt.Errorf("failed to create new user: %w", err)
 			}
@@ -92,6 +118,7 @@ func (s *userService) Authorize(ctx context.Context, telegramID int64, authDTO *
 		return fmt.Errorf("failed to get user by bots id: %w", err)
 	}
 
+	before := *user
 	_, err = user.SetFirstName(authDTO.FirstName)
 	if err != nil {
 		s.logger.Errorf("[%s] failed to set first name: %v", op, err)
@@ -116,7 +143,18 @@ func (s *userService) Authorize(ctx context.Context, telegramID int64, authDTO *
 		return fmt.Errorf("failed to set updated at: %w", err)
 	}
 
-	if err := s.userStorage.Update(ctx, user); err != nil {
+	err = s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.userStorage.Update(ctx, user); err != nil {
+			return err
+		}
+
+		if err := s.auditService.Record(ctx, model.AuditEntityUser, user.ID, "", model.AuditActionUpdate, &before, user); err != nil {
+			return err
+		}
+
+		return s.eventBus.Publish(ctx, user.ID, model.EventUserAuthorized, user)
+	})
+	if err != nil {
 		s.logger.Errorf("[%s] failed to update user: %v", op, err)
 		return fmt.Errorf("failed to update user: %w", err)
 	}
@@ -129,9 +167,38 @@ func (s *userService) RefreshTokens(ctx context.Context, refreshToken string) (*
 	return nil, nil
 }
 
-// Get returns user by id or telegramID
-func (s *userService) Get(ctx context.Context, id int) (*model.User, error) {
-	return nil, nil
+// Get returns user by id
+func (s *userService) Get(ctx context.Context, id string) (*model.User, error) {
+	const op = "userService.Get"
+
+	user, err := s.userStorage.GetByID(ctx, id)
+	if err != nil {
+		if errors.Is(err, storage.ErrorUserNotFound) {
+			return nil, err
+		}
+
+		s.logger.Errorf("[%s] failed to get user: %v", op, err)
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
+
+// GetByTelegramID returns user by telegramID
+func (s *userService) GetByTelegramID(ctx context.Context, telegramID int64) (*model.User, error) {
+	const op = "userService.GetByTelegramID"
+
+	user, err := s.userStorage.GetByTelegramID(ctx, telegramID)
+	if err != nil {
+		if errors.Is(err, storage.ErrorUserNotFound) {
+			return nil, err
+		}
+
+		s.logger.Errorf("[%s] failed to get user by bots id: %v", op, err)
+		return nil, fmt.Errorf("failed to get user by bots id: %w", err)
+	}
+
+	return user, nil
 }
 
 // Update updates user data
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"time"
+)
+
+type viewService struct {
+	viewStorage storage.ViewStorage
+	goalService GoalService
+	logger      logger.Logger
+}
+
+func NewViewService(viewStorage storage.ViewStorage, goalService GoalService, logger logger.Logger) ViewService {
+	return &viewService{
+		viewStorage: viewStorage,
+		goalService: goalService,
+		logger:      logger,
+	}
+}
+
+func (s *viewService) List(ctx context.Context, userID string) ([]*model.SavedView, error) {
+	const op = "viewService.List"
+
+	views, err := s.viewStorage.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get views: %v", op, err)
+		return nil, fmt.Errorf("failed to get views: %w", err)
+	}
+
+	return views, nil
+}
+
+func (s *viewService) Create(ctx context.Context, userID string, createDTO *dto.SaveViewDTO) (*model.SavedView, error) {
+	const op = "viewService.Create"
+
+	view, err := model.NewSavedView(uuid.NewString(), userID, createDTO.Name, createDTO.Filter, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.viewStorage.Create(ctx, view); err != nil {
+		s.logger.Errorf("%s: failed to create view: %v", op, err)
+		return nil, fmt.Errorf("failed to create view: %w", err)
+	}
+
+	return view, nil
+}
+
+func (s *viewService) Update(ctx context.Context, userID, id string, updateDTO *dto.SaveViewDTO) (*model.SavedView, error) {
+	const op = "viewService.Update"
+
+	view, err := s.getOwnedView(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get view: %v", op, err)
+		return nil, err
+	}
+
+	if _, err := view.SetName(updateDTO.Name); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := view.SetFilter(updateDTO.Filter); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if _, err := view.SetUpdatedAt(time.Now()); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.viewStorage.Update(ctx, view); err != nil {
+		s.logger.Errorf("%s: failed to update view: %v", op, err)
+		return nil, fmt.Errorf("failed to update view: %w", err)
+	}
+
+	return view, nil
+}
+
+func (s *viewService) Delete(ctx context.Context, userID, id string) error {
+	const op = "viewService.Delete"
+
+	if _, err := s.getOwnedView(ctx, userID, id); err != nil {
+		s.logger.Errorf("%s: failed to get view: %v", op, err)
+		return err
+	}
+
+	if err := s.viewStorage.Delete(ctx, id); err != nil {
+		s.logger.Errorf("%s: failed to delete view: %v", op, err)
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	return nil
+}
+
+func (s *viewService) Goals(ctx context.Context, userID, id string) ([]*model.Goal, error) {
+	const op = "viewService.Goals"
+
+	view, err := s.getOwnedView(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get view: %v", op, err)
+		return nil, err
+	}
+
+	return s.goalService.List(ctx, userID, model.GoalFilter{Filter: view.Filter})
+}
+
+func (s *viewService) GoalsByName(ctx context.Context, userID, name string) (*model.SavedView, []*model.Goal, error) {
+	const op = "viewService.GoalsByName"
+
+	view, err := s.viewStorage.GetByName(ctx, userID, name)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get view: %v", op, err)
+		return nil, nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	goals, err := s.goalService.List(ctx, userID, model.GoalFilter{Filter: view.Filter})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return view, goals, nil
+}
+
+// getOwnedView returns the view if it belongs to the user, views of other users are not found
+func (s *viewService) getOwnedView(ctx context.Context, userID, id string) (*model.SavedView, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, storage.ErrViewNotFound
+	}
+
+	view, err := s.viewStorage.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	if view.UserID != userID {
+		return nil, storage.ErrViewNotFound
+	}
+
+	return view, nil
+}
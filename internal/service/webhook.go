@@ -0,0 +1,481 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/nordew/Strive/internal/dto"
+	"github.com/nordew/Strive/internal/model"
+	"github.com/nordew/Strive/internal/storage"
+	"github.com/nordew/Strive/pkg/logger"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+)
+
+var (
+	ErrWebhookDisabled       = errors.New("webhook is disabled")
+	ErrDeliveryNotReplayable = errors.New("only failed deliveries can be replayed")
+)
+
+const (
+	webhookDispatchInterval     = 5 * time.Second
+	webhookDeliveryLease        = time.Minute
+	webhookDeliveryBatch        = 20
+	webhookRequestTimeout       = 10 * time.Second
+	webhookDeliveriesLimit      = 100
+	missedDeadlineScanInterval  = time.Minute
+	missedDeadlineLookback      = 7 * 24 * time.Hour
+	webhookResponseBodyMaxBytes = 64 << 10
+	webhookMaxRedirects         = 5
+)
+
+// webhookPayload is the JSON body posted to webhooks
+type webhookPayload struct {
+	ID        string          `json:"id"`
+	EventID   string          `json:"event_id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+type webhookService struct {
+	webhookStorage storage.WebhookStorage
+	eventBus       EventBus
+	transactor     storage.Transactor
+	client         *http.Client
+	logger         logger.Logger
+}
+
+func NewWebhookService(
+	webhookStorage storage.WebhookStorage,
+	eventBus EventBus,
+	transactor storage.Transactor,
+	logger logger.Logger,
+) WebhookService {
+	return &webhookService{
+		webhookStorage: webhookStorage,
+		eventBus:       eventBus,
+		transactor:     transactor,
+		client:         newWebhookClient(),
+		logger:         logger,
+	}
+}
+
+func (s *webhookService) Create(ctx context.Context, userID string, createDTO *dto.CreateWebhookDTO) (*model.Webhook, error) {
+	const op = "webhookService.Create"
+
+	now := time.Now()
+	webhook, err := model.NewWebhook(uuid.NewString(), userID, createDTO.URL, createDTO.Events, now, now)
+	if err != nil {
+		s.logger.Errorf("%s: failed to create webhook: %v", op, err)
+		return nil, fmt.Errorf("%w: %v", ErrValidation, err)
+	}
+
+	if err := s.webhookStorage.Create(ctx, webhook); err != nil {
+		s.logger.Errorf("%s: failed to save webhook: %v", op, err)
+		return nil, fmt.Errorf("failed to save webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) List(ctx context.Context, userID string) ([]*model.Webhook, error) {
+	const op = "webhookService.List"
+
+	webhooks, err := s.webhookStorage.GetByUserID(ctx, userID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhooks: %v", op, err)
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookService) Delete(ctx context.Context, userID, id string) error {
+	const op = "webhookService.Delete"
+
+	webhook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhook: %v", op, err)
+		return err
+	}
+
+	if err := s.webhookStorage.Delete(ctx, webhook.ID); err != nil {
+		s.logger.Errorf("%s: failed to delete webhook: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookService) Enable(ctx context.Context, userID, id string) (*model.Webhook, error) {
+	const op = "webhookService.Enable"
+
+	webhook, err := s.getWebhook(ctx, userID, id)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhook: %v", op, err)
+		return nil, err
+	}
+
+	webhook.Enable(time.Now())
+
+	if err := s.webhookStorage.Update(ctx, webhook); err != nil {
+		s.logger.Errorf("%s: failed to update webhook: %v", op, err)
+		return nil, fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) ListDeliveries(ctx context.Context, userID, webhookID string) ([]*model.WebhookDelivery, error) {
+	const op = "webhookService.ListDeliveries"
+
+	webhook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhook: %v", op, err)
+		return nil, err
+	}
+
+	deliveries, err := s.webhookStorage.GetDeliveriesByWebhookID(ctx, webhook.ID, webhookDeliveriesLimit)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get deliveries: %v", op, err)
+		return nil, fmt.Errorf("failed to get deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (s *webhookService) GetDelivery(ctx context.Context, userID, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	const op = "webhookService.GetDelivery"
+
+	delivery, err := s.getDelivery(ctx, userID, webhookID, deliveryID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get delivery: %v", op, err)
+		return nil, err
+	}
+
+	attempts, err := s.webhookStorage.GetAttemptsByDeliveryID(ctx, delivery.ID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get attempts: %v", op, err)
+		return nil, fmt.Errorf("failed to get attempts: %w", err)
+	}
+
+	delivery.AttemptLog = attempts
+	return delivery, nil
+}
+
+func (s *webhookService) Replay(ctx context.Context, userID, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	const op = "webhookService.Replay"
+
+	webhook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhook: %v", op, err)
+		return nil, err
+	}
+
+	if !webhook.IsActive {
+		return nil, ErrWebhookDisabled
+	}
+
+	delivery, err := s.getDelivery(ctx, userID, webhookID, deliveryID)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get delivery: %v", op, err)
+		return nil, err
+	}
+
+	if err := delivery.Replay(time.Now()); err != nil {
+		return nil, ErrDeliveryNotReplayable
+	}
+
+	if err := s.webhookStorage.UpdateDelivery(ctx, delivery); err != nil {
+		s.logger.Errorf("%s: failed to update delivery: %v", op, err)
+		return nil, fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	return delivery, nil
+}
+
+// HandleEvent queues a delivery of the event for every active webhook of the user that listens to it.
+// Delivery IDs are derived from the event and the webhook, so a redelivered event is not sent twice.
+func (s *webhookService) HandleEvent(ctx context.Context, event *model.Event) error {
+	const op = "webhookService.HandleEvent"
+
+	if !model.IsWebhookEvent(event.Type) {
+		return nil
+	}
+
+	webhooks, err := s.webhookStorage.GetSubscribed(ctx, event.UserID, event.Type)
+	if err != nil {
+		s.logger.Errorf("%s: failed to get webhooks: %v", op, err)
+		return fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	now := time.Now()
+	for _, webhook := range webhooks {
+		deliveryID := uuid.NewSHA1(uuid.MustParse(event.ID), []byte(webhook.ID)).String()
+
+		payload, err := json.Marshal(webhookPayload{
+			ID:        deliveryID,
+			EventID:   event.ID,
+			Event:     event.Type,
+			CreatedAt: event.CreatedAt,
+			Data:      event.Payload,
+		})
+		if err != nil {
+			s.logger.Errorf("%s: failed to encode payload: %v", op, err)
+			return fmt.Errorf("failed to encode payload: %w", err)
+		}
+
+		delivery, err := model.NewWebhookDelivery(deliveryID, webhook.ID, event.Type, string(payload), now)
+		if err != nil {
+			s.logger.Errorf("%s: failed to create delivery: %v", op, err)
+			return fmt.Errorf("failed to create delivery: %w", err)
+		}
+
+		if err := s.webhookStorage.CreateDelivery(ctx, delivery); err != nil {
+			s.logger.Errorf("%s: failed to save delivery: %v", op, err)
+			return fmt.Errorf("failed to save delivery: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// RunDispatcher sends due deliveries and reports missed deadlines until ctx is cancelled
+func (s *webhookService) RunDispatcher(ctx context.Context) {
+	ticker := time.NewTicker(webhookDispatchInterval)
+	defer ticker.Stop()
+
+	var lastScan time.Time
+	for {
+		if now := time.Now(); now.Sub(lastScan) >= missedDeadlineScanInterval {
+			since := lastScan
+			if since.IsZero() {
+				since = now.Add(-missedDeadlineLookback)
+			}
+
+			if err := s.publishMissedDeadlines(ctx, since, now); err == nil {
+				lastScan = now
+			}
+		}
+
+		s.dispatchDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *webhookService) publishMissedDeadlines(ctx context.Context, since, now time.Time) error {
+	const op = "webhookService.publishMissedDeadlines"
+
+	err := s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		missed, err := s.webhookStorage.RecordMissedDeadlines(ctx, since, now)
+		if err != nil {
+			return err
+		}
+
+		for _, deadline := range missed {
+			if err := s.eventBus.Publish(ctx, deadline.UserID, model.EventDeadlineMissed, deadline); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		s.logger.Errorf("%s: failed to publish missed deadlines: %v", op, err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *webhookService) dispatchDue(ctx context.Context) {
+	const op = "webhookService.dispatchDue"
+
+	for ctx.Err() == nil {
+		now := time.Now()
+
+		deliveries, err := s.webhookStorage.ClaimDueDeliveries(ctx, now, now.Add(webhookDeliveryLease), webhookDeliveryBatch)
+		if err != nil {
+			s.logger.Errorf("%s: failed to claim deliveries: %v", op, err)
+			return
+		}
+
+		for _, delivery := range deliveries {
+			if err := s.deliver(ctx, delivery); err != nil {
+				s.logger.Errorf("%s: failed to deliver %s: %v", op, delivery.ID, err)
+			}
+		}
+
+		if len(deliveries) < webhookDeliveryBatch {
+			return
+		}
+	}
+}
+
+// deliver makes one attempt to send the delivery and records its outcome
+func (s *webhookService) deliver(ctx context.Context, delivery *model.WebhookDelivery) error {
+	webhook, err := s.webhookStorage.GetByID(ctx, delivery.WebhookID)
+	if err != nil {
+		return err
+	}
+
+	if !webhook.IsActive {
+		delivery.Status = model.DeliveryFailed
+		delivery.LastError = ErrWebhookDisabled.Error()
+		delivery.UpdatedAt = time.Now()
+
+		return s.webhookStorage.UpdateDelivery(ctx, delivery)
+	}
+
+	attempt := s.send(ctx, webhook, delivery)
+	delivery.RecordAttempt(attempt)
+
+	if attempt.Succeeded() {
+		webhook.RecordSuccess(attempt.AttemptedAt)
+	} else {
+		webhook.RecordFailure(attempt.AttemptedAt)
+		if !webhook.IsActive {
+			s.logger.Infof("webhook %s disabled after %d failed attempts", webhook.ID, webhook.FailureCount)
+		}
+	}
+
+	return s.transactor.WithinTx(ctx, func(ctx context.Context) error {
+		if err := s.webhookStorage.CreateAttempt(ctx, &attempt); err != nil {
+			return err
+		}
+
+		if err := s.webhookStorage.UpdateDelivery(ctx, delivery); err != nil {
+			return err
+		}
+
+		return s.webhookStorage.Update(ctx, webhook)
+	})
+}
+
+// send posts the payload signed with the webhook secret. Receivers verify X-Strive-Signature, the hex
+// HMAC-SHA256 of "<X-Strive-Timestamp>.<body>".
+func (s *webhookService) send(ctx context.Context, webhook *model.Webhook, delivery *model.WebhookDelivery) model.WebhookAttempt {
+	now := time.Now()
+	attempt := model.WebhookAttempt{
+		ID:          uuid.NewString(),
+		DeliveryID:  delivery.ID,
+		AttemptedAt: now,
+	}
+
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	// webhooks created before plain http was rejected are checked here too
+	if err := model.ValidateWebhookURL(req.URL); err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Strive-Webhooks/1.0")
+	req.Header.Set("X-Strive-Event", delivery.Event)
+	req.Header.Set("X-Strive-Delivery", delivery.ID)
+	req.Header.Set("X-Strive-Timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("X-Strive-Signature", "sha256="+webhook.Sign(now, body))
+
+	resp, err := s.client.Do(req)
+	attempt.DurationMS = time.Since(now).Milliseconds()
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, webhookResponseBodyMaxBytes))
+
+	attempt.StatusCode = resp.StatusCode
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		attempt.Error = fmt.Sprintf("unexpected status code %d", resp.StatusCode)
+	}
+
+	return attempt
+}
+
+// newWebhookClient returns a client that only connects to public addresses. The check runs on the resolved
+// address of every connection, so DNS names and redirects cannot reach the server itself or a private network.
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookRequestTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil || !model.IsPublicAddress(addrPort.Addr()) {
+				return model.ErrWebhookAddress
+			}
+
+			return nil
+		},
+	}
+
+	return &http.Client{
+		Timeout: webhookRequestTimeout,
+		// no proxy, the dialer has to see the address of the receiver
+		Transport: &http.Transport{
+			DialContext:         dialer.DialContext,
+			TLSHandshakeTimeout: webhookRequestTimeout,
+			MaxIdleConns:        10,
+			IdleConnTimeout:     90 * time.Second,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= webhookMaxRedirects {
+				return fmt.Errorf("stopped after %d redirects", webhookMaxRedirects)
+			}
+
+			return model.ValidateWebhookURL(req.URL)
+		},
+	}
+}
+
+func (s *webhookService) getWebhook(ctx context.Context, userID, id string) (*model.Webhook, error) {
+	webhook, err := s.webhookStorage.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if webhook.UserID != userID {
+		return nil, storage.ErrWebhookNotFound
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookService) getDelivery(ctx context.Context, userID, webhookID, deliveryID string) (*model.WebhookDelivery, error) {
+	webhook, err := s.getWebhook(ctx, userID, webhookID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery, err := s.webhookStorage.GetDeliveryByID(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	if delivery.WebhookID != webhook.ID {
+		return nil, storage.ErrWebhookDeliveryNotFound
+	}
+
+	return delivery, nil
+}
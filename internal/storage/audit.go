@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const auditLogTable = "audit_log"
+
+type auditStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewAuditStorage(db *pgxpool.Pool) AuditStorage {
+	return &auditStorage{db: db}
+}
+
+func (s *auditStorage) Create(ctx context.Context, entry *model.AuditEntry) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, actor_id, request_id, entity_type, entity_id, goal_id, action, changes, created_at) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')::uuid, $7, $8, $9)", auditLogTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, entry.ID, entry.ActorID, entry.RequestID, entry.EntityType, entry.EntityID, entry.GoalID, entry.Action, entry.Changes, entry.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create audit entry: %w", err)
+	}
+
+	return nil
+}
+
+// GetByGoalID returns up to limit entries of the goal and its chapters and comments created before the given
+// time, newest first
+func (s *auditStorage) GetByGoalID(ctx context.Context, goalID string, before time.Time, limit int) ([]*model.AuditEntry, error) {
+	query := fmt.Sprintf(`SELECT id, actor_id, request_id, entity_type, entity_id::text, COALESCE(goal_id::text, ''), action, changes, created_at
+	FROM %s WHERE goal_id = $1 AND created_at < $2 ORDER BY created_at DESC LIMIT $3`, auditLogTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, goalID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*model.AuditEntry
+	for rows.Next() {
+		var entry model.AuditEntry
+
+		err := rows.Scan(&entry.ID, &entry.ActorID, &entry.RequestID, &entry.EntityType, &entry.EntityID, &entry.GoalID, &entry.Action, &entry.Changes, &entry.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+		}
+
+		entries = append(entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get audit entries: %w", err)
+	}
+
+	return entries, nil
+}
+
+// DeleteBefore removes entries created before the given time and returns how many were removed
+func (s *auditStorage) DeleteBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE created_at < $1", auditLogTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit entries: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
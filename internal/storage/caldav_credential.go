@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const calDAVCredentialsTable = "caldav_credentials"
+
+var ErrCalDAVCredentialNotFound = fmt.Errorf("caldav credential not found")
+
+type calDAVCredentialStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewCalDAVCredentialStorage(db *pgxpool.Pool) CalDAVCredentialStorage {
+	return &calDAVCredentialStorage{db: db}
+}
+
+// Upsert stores the credential, replacing the previous password of the user
+func (s *calDAVCredentialStorage) Upsert(ctx context.Context, credential *model.CalDAVCredential) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, password_hash, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET password_hash = EXCLUDED.password_hash, created_at = EXCLUDED.created_at", calDAVCredentialsTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, credential.UserID, credential.PasswordHash, credential.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save caldav credential: %w", err)
+	}
+
+	return nil
+}
+
+func (s *calDAVCredentialStorage) GetByUserID(ctx context.Context, userID string) (*model.CalDAVCredential, error) {
+	query := fmt.Sprintf("SELECT user_id, password_hash, created_at FROM %s WHERE user_id = $1", calDAVCredentialsTable)
+
+	var credential model.CalDAVCredential
+	if err := conn(ctx, s.db).QueryRow(ctx, query, userID).Scan(&credential.UserID, &credential.PasswordHash, &credential.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCalDAVCredentialNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get caldav credential: %w", err)
+	}
+
+	return &credential, nil
+}
+
+func (s *calDAVCredentialStorage) DeleteByUserID(ctx context.Context, userID string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", calDAVCredentialsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete caldav credential: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrCalDAVCredentialNotFound
+	}
+
+	return nil
+}
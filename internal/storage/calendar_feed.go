@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const calendarFeedsTable = "calendar_feeds"
+
+var ErrCalendarFeedNotFound = fmt.Errorf("calendar feed not found")
+
+type calendarFeedStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewCalendarFeedStorage(db *pgxpool.Pool) CalendarFeedStorage {
+	return &calendarFeedStorage{db: db}
+}
+
+// Upsert stores the feed, replacing and thereby revoking the previous token of the user
+func (s *calendarFeedStorage) Upsert(ctx context.Context, feed *model.CalendarFeed) error {
+	query := fmt.Sprintf("INSERT INTO %s (user_id, token, created_at) VALUES ($1, $2, $3) ON CONFLICT (user_id) DO UPDATE SET token = EXCLUDED.token, created_at = EXCLUDED.created_at", calendarFeedsTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, feed.UserID, feed.Token, feed.CreatedAt); err != nil {
+		return fmt.Errorf("failed to save calendar feed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *calendarFeedStorage) GetByUserID(ctx context.Context, userID string) (*model.CalendarFeed, error) {
+	query := fmt.Sprintf("SELECT user_id, token, created_at FROM %s WHERE user_id = $1", calendarFeedsTable)
+
+	return s.get(ctx, query, userID)
+}
+
+func (s *calendarFeedStorage) GetByToken(ctx context.Context, token string) (*model.CalendarFeed, error) {
+	query := fmt.Sprintf("SELECT user_id, token, created_at FROM %s WHERE token = $1", calendarFeedsTable)
+
+	return s.get(ctx, query, token)
+}
+
+func (s *calendarFeedStorage) DeleteByUserID(ctx context.Context, userID string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", calendarFeedsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete calendar feed: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrCalendarFeedNotFound
+	}
+
+	return nil
+}
+
+// GetVersion returns a value that changes whenever a goal or chapter of the user is created, updated or deleted
+func (s *calendarFeedStorage) GetVersion(ctx context.Context, userID string) (string, error) {
+	query := fmt.Sprintf(`SELECT COUNT(DISTINCT g.id), COUNT(c.id), COALESCE(MAX(GREATEST(g.updated_at, c.updated_at)), 'epoch')
+	FROM %s g LEFT JOIN %s c ON c.goal_id = g.id AND c.deleted_at IS NULL
+	WHERE g.user_id = $1 AND g.deleted_at IS NULL`, goalsTable, chaptersTable)
+
+	var (
+		goals       int
+		chapters    int
+		lastUpdated time.Time
+	)
+
+	if err := conn(ctx, s.db).QueryRow(ctx, query, userID).Scan(&goals, &chapters, &lastUpdated); err != nil {
+		return "", fmt.Errorf("failed to get calendar feed version: %w", err)
+	}
+
+	return fmt.Sprintf("%d-%d-%d", goals, chapters, lastUpdated.UnixNano()), nil
+}
+
+func (s *calendarFeedStorage) get(ctx context.Context, query string, arg string) (*model.CalendarFeed, error) {
+	var feed model.CalendarFeed
+
+	if err := conn(ctx, s.db).QueryRow(ctx, query, arg).Scan(&feed.UserID, &feed.Token, &feed.CreatedAt); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCalendarFeedNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get calendar feed: %w", err)
+	}
+
+	return &feed, nil
+}
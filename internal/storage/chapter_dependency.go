@@ -0,0 +1,35 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+)
+
+const chapterDependenciesTable = "chapter_dependencies"
+
+// SetChapterDependencies replaces the set of chapters the given chapter depends on
+func (s *goalStorage) SetChapterDependencies(ctx context.Context, chapterID string, dependsOn []string) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE chapter_id = $1", chapterDependenciesTable)
+
+	if _, err := tx.Exec(ctx, query, chapterID); err != nil {
+		return fmt.Errorf("failed to clear chapter dependencies: %w", err)
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (chapter_id, depends_on_id) SELECT $1, unnest($2::uuid[])", chapterDependenciesTable)
+
+	if _, err := tx.Exec(ctx, query, chapterID, dependsOn); err != nil {
+		return fmt.Errorf("failed to set chapter dependencies: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit chapter dependencies: %w", err)
+	}
+
+	return nil
+}
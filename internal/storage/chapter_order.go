@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/nordew/Strive/internal/model"
+)
+
+// LockGoalChapters locks the goal row until the end of the surrounding transaction,
+// serializing chapter inserts and reorders of the goal
+func (s *goalStorage) LockGoalChapters(ctx context.Context, goalID string) error {
+	query := fmt.Sprintf("SELECT id FROM %s WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", goalsTable)
+
+	var id string
+	if err := conn(ctx, s.db).QueryRow(ctx, query, goalID).Scan(&id); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return ErrGoalNotFound
+		}
+
+		return fmt.Errorf("failed to lock goal chapters: %w", err)
+	}
+
+	return nil
+}
+
+// GetLastChapterPosition returns the greatest position of the goal's chapters outside the trash or an empty
+// string if it has none
+func (s *goalStorage) GetLastChapterPosition(ctx context.Context, goalID string) (string, error) {
+	query := fmt.Sprintf("SELECT COALESCE(MAX(position), '') FROM %s WHERE goal_id = $1 AND deleted_at IS NULL", chaptersTable)
+
+	var position string
+	if err := conn(ctx, s.db).QueryRow(ctx, query, goalID).Scan(&position); err != nil {
+		return "", fmt.Errorf("failed to get last chapter position: %w", err)
+	}
+
+	return position, nil
+}
+
+func (s *goalStorage) UpdateChapterPosition(ctx context.Context, id, position string) error {
+	query := fmt.Sprintf("UPDATE %s SET position = $1, updated_at = now() WHERE id = $2", chaptersTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, position, id)
+	if err != nil {
+		return fmt.Errorf("failed to update chapter position: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrChapterNotFound
+	}
+
+	return nil
+}
+
+// MoveChapterToGoal moves the chapter to chapter.GoalID at chapter.Position and sets the incremented version.
+// Dependencies only link chapters of one goal, so those of the chapter and those on it are dropped.
+// Comments on the chapter follow it.
+func (s *goalStorage) MoveChapterToGoal(ctx context.Context, chapter *model.Chapter) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf(`UPDATE %s SET goal_id = $2, position = $3, updated_at = $4, version = version + 1
+	WHERE id = $1 AND version = $5 AND deleted_at IS NULL
+	RETURNING version`, chaptersTable)
+
+	err = tx.QueryRow(ctx, query, chapter.ID, chapter.GoalID, chapter.Position, chapter.UpdatedAt, chapter.Version).Scan(&chapter.Version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return versionConflict(ctx, tx, chaptersTable, chapter.ID, ErrChapterNotFound)
+		}
+
+		return fmt.Errorf("failed to move chapter: %w", err)
+	}
+
+	query = fmt.Sprintf("DELETE FROM %s WHERE chapter_id = $1 OR depends_on_id = $1", chapterDependenciesTable)
+
+	if _, err := tx.Exec(ctx, query, chapter.ID); err != nil {
+		return fmt.Errorf("failed to drop chapter dependencies: %w", err)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET goal_id = $2 WHERE chapter_id = $1", commentsTable)
+
+	if _, err := tx.Exec(ctx, query, chapter.ID, chapter.GoalID); err != nil {
+		return fmt.Errorf("failed to move chapter comments: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit chapter move: %w", err)
+	}
+
+	return nil
+}
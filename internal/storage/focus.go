@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const focusSessionsTable = "focus_sessions"
+
+// focusColumns are selected from focus_sessions f joined with the chapter c of the session
+const focusColumns = `f.id, f.user_id, c.goal_id, f.chapter_id, c.title, f.mode, f.state, f.planned_seconds, f.focused_seconds,
+	f.started_at, f.resumed_at, f.ends_at, f.ended_at, f.completed, f.created_at, f.updated_at`
+
+var (
+	ErrFocusSessionNotFound = fmt.Errorf("focus session not found")
+	ErrFocusSessionActive   = fmt.Errorf("another focus session is active")
+)
+
+type focusStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewFocusStorage(db *pgxpool.Pool) FocusStorage {
+	return &focusStorage{db: db}
+}
+
+// Create returns ErrFocusSessionActive if the user has a session that has not ended
+func (s *focusStorage) Create(ctx context.Context, session *model.FocusSession) error {
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, chapter_id, mode, state, planned_seconds, focused_seconds,
+		started_at, resumed_at, ends_at, ended_at, completed, created_at, updated_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)`, focusSessionsTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, session.ID, session.UserID, session.ChapterID, session.Mode, session.State,
+		session.PlannedSeconds, session.FocusedSeconds, session.StartedAt, session.ResumedAt, session.EndsAt, session.EndedAt,
+		session.Completed, session.CreatedAt, session.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrFocusSessionActive
+		}
+
+		return fmt.Errorf("failed to create focus session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *focusStorage) GetByID(ctx context.Context, id string) (*model.FocusSession, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s f JOIN %s c ON c.id = f.chapter_id WHERE f.id = $1", focusColumns, focusSessionsTable, chaptersTable)
+
+	return s.getSession(ctx, query, id)
+}
+
+// GetActive returns the session of the user that has not ended
+func (s *focusStorage) GetActive(ctx context.Context, userID string) (*model.FocusSession, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s f JOIN %s c ON c.id = f.chapter_id WHERE f.user_id = $1 AND f.ended_at IS NULL",
+		focusColumns, focusSessionsTable, chaptersTable)
+
+	return s.getSession(ctx, query, userID)
+}
+
+// GetDue returns running sessions whose end passed
+func (s *focusStorage) GetDue(ctx context.Context, now time.Time) ([]*model.FocusSession, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s f JOIN %s c ON c.id = f.chapter_id
+	WHERE f.ended_at IS NULL AND f.ends_at <= $1
+	ORDER BY f.ends_at`, focusColumns, focusSessionsTable, chaptersTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due focus sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*model.FocusSession
+	for rows.Next() {
+		session, err := scanFocusSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan focus session: %w", err)
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over focus sessions: %w", err)
+	}
+
+	return sessions, nil
+}
+
+// Update stores the state of a session that has not ended, ended sessions are not found
+func (s *focusStorage) Update(ctx context.Context, session *model.FocusSession) error {
+	query := fmt.Sprintf(`UPDATE %s SET state = $1, focused_seconds = $2, resumed_at = $3, ends_at = $4, ended_at = $5,
+		completed = $6, updated_at = $7
+	WHERE id = $8 AND ended_at IS NULL`, focusSessionsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, session.State, session.FocusedSeconds, session.ResumedAt, session.EndsAt,
+		session.EndedAt, session.Completed, session.UpdatedAt, session.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update focus session: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrFocusSessionNotFound
+	}
+
+	return nil
+}
+
+// GetTotals returns the time focused in ended sessions on the goal and its chapters outside the trash
+func (s *focusStorage) GetTotals(ctx context.Context, goalID string) (*model.FocusTotals, error) {
+	query := fmt.Sprintf(`SELECT c.id, c.title, COALESCE(SUM(f.focused_seconds), 0), COUNT(f.id)
+	FROM %s c LEFT JOIN %s f ON f.chapter_id = c.id AND f.ended_at IS NOT NULL
+	WHERE c.goal_id = $1 AND c.deleted_at IS NULL
+	GROUP BY c.id, c.title, c.position
+	ORDER BY c.position`, chaptersTable, focusSessionsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get focus totals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := &model.FocusTotals{GoalID: goalID, Chapters: []*model.ChapterFocus{}}
+	for rows.Next() {
+		var chapter model.ChapterFocus
+		if err := rows.Scan(&chapter.ChapterID, &chapter.Title, &chapter.Seconds, &chapter.Sessions); err != nil {
+			return nil, fmt.Errorf("failed to scan focus totals: %w", err)
+		}
+
+		totals.Seconds += chapter.Seconds
+		totals.Chapters = append(totals.Chapters, &chapter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over focus totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetGoalTotals returns the time focused on each goal of the user that has any, most time first
+func (s *focusStorage) GetGoalTotals(ctx context.Context, userID string) ([]*model.GoalFocus, error) {
+	query := fmt.Sprintf(`SELECT g.id, g.title, COALESCE(g.progress, 0), SUM(f.focused_seconds)
+	FROM %s f
+	JOIN %s c ON c.id = f.chapter_id AND c.deleted_at IS NULL
+	JOIN %s g ON g.id = c.goal_id AND g.deleted_at IS NULL
+	WHERE f.user_id = $1 AND f.ended_at IS NOT NULL
+	GROUP BY g.id, g.title, g.progress
+	ORDER BY 4 DESC, g.title`, focusSessionsTable, chaptersTable, goalsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal focus totals: %w", err)
+	}
+	defer rows.Close()
+
+	var goals []*model.GoalFocus
+	for rows.Next() {
+		var goal model.GoalFocus
+		if err := rows.Scan(&goal.GoalID, &goal.Title, &goal.Progress, &goal.Seconds); err != nil {
+			return nil, fmt.Errorf("failed to scan goal focus totals: %w", err)
+		}
+
+		goals = append(goals, &goal)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over goal focus totals: %w", err)
+	}
+
+	return goals, nil
+}
+
+func (s *focusStorage) getSession(ctx context.Context, query string, args ...any) (*model.FocusSession, error) {
+	session, err := scanFocusSession(conn(ctx, s.db).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrFocusSessionNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get focus session: %w", err)
+	}
+
+	return session, nil
+}
+
+func scanFocusSession(row pgx.Row) (*model.FocusSession, error) {
+	var session model.FocusSession
+	err := row.Scan(&session.ID, &session.UserID, &session.GoalID, &session.ChapterID, &session.ChapterTitle, &session.Mode,
+		&session.State, &session.PlannedSeconds, &session.FocusedSeconds, &session.StartedAt, &session.ResumedAt, &session.EndsAt,
+		&session.EndedAt, &session.Completed, &session.CreatedAt, &session.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &session, nil
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const (
+	friendInvitesTable   = "friend_invites"
+	friendshipsTable     = "friendships"
+	privacySettingsTable = "privacy_settings"
+)
+
+var (
+	ErrInviteNotFound = fmt.Errorf("invite not found")
+	ErrFriendNotFound = fmt.Errorf("friend not found")
+	ErrAlreadyFriends = fmt.Errorf("already friends")
+)
+
+type friendStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewFriendStorage(db *pgxpool.Pool) FriendStorage {
+	return &friendStorage{db: db}
+}
+
+func (s *friendStorage) CreateInvite(ctx context.Context, invite *model.FriendInvite) error {
+	query := fmt.Sprintf("INSERT INTO %s (code, user_id, created_at, expires_at) VALUES ($1, $2, $3, $4)", friendInvitesTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, invite.Code, invite.UserID, invite.CreatedAt, invite.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create invite: %w", err)
+	}
+
+	return nil
+}
+
+// TakeInvite deletes the invite and returns it, so each invite is accepted at most once
+func (s *friendStorage) TakeInvite(ctx context.Context, code string) (*model.FriendInvite, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE code = $1 RETURNING code, user_id, created_at, expires_at", friendInvitesTable)
+
+	var invite model.FriendInvite
+	err := conn(ctx, s.db).QueryRow(ctx, query, code).Scan(&invite.Code, &invite.UserID, &invite.CreatedAt, &invite.ExpiresAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInviteNotFound
+		}
+
+		return nil, fmt.Errorf("failed to take invite: %w", err)
+	}
+
+	return &invite, nil
+}
+
+// DeleteExpiredInvites deletes the invites of the user that expired before now
+func (s *friendStorage) DeleteExpiredInvites(ctx context.Context, userID string, now time.Time) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1 AND expires_at <= $2", friendInvitesTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, userID, now); err != nil {
+		return fmt.Errorf("failed to delete expired invites: %w", err)
+	}
+
+	return nil
+}
+
+// CreateFriendship connects both users with each other, it returns ErrAlreadyFriends if they are connected
+func (s *friendStorage) CreateFriendship(ctx context.Context, userID, friendID string, createdAt time.Time) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, friend_id, created_at) VALUES ($1, $2, $3), ($2, $1, $3)
+	ON CONFLICT (user_id, friend_id) DO NOTHING`, friendshipsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, userID, friendID, createdAt)
+	if err != nil {
+		return fmt.Errorf("failed to create friendship: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrAlreadyFriends
+	}
+
+	return nil
+}
+
+// DeleteFriendship disconnects both users
+func (s *friendStorage) DeleteFriendship(ctx context.Context, userID, friendID string) error {
+	query := fmt.Sprintf(`DELETE FROM %s WHERE (user_id = $1 AND friend_id = $2) OR (user_id = $2 AND friend_id = $1)`, friendshipsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, userID, friendID)
+	if err != nil {
+		return fmt.Errorf("failed to delete friendship: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrFriendNotFound
+	}
+
+	return nil
+}
+
+func (s *friendStorage) GetFriends(ctx context.Context, userID string) ([]*model.Friend, error) {
+	query := fmt.Sprintf(`SELECT u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''), f.created_at
+	FROM %s f JOIN users u ON u.id = f.friend_id
+	WHERE f.user_id = $1
+	ORDER BY lower(u.first_name), lower(u.last_name)`, friendshipsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get friends: %w", err)
+	}
+	defer rows.Close()
+
+	var friends []*model.Friend
+	for rows.Next() {
+		var friend model.Friend
+		if err := rows.Scan(&friend.ID, &friend.FirstName, &friend.LastName, &friend.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan friend: %w", err)
+		}
+
+		friends = append(friends, &friend)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over friends: %w", err)
+	}
+
+	return friends, nil
+}
+
+// GetPrivacySettings returns the defaults for users who never changed their settings
+func (s *friendStorage) GetPrivacySettings(ctx context.Context, userID string) (*model.PrivacySettings, error) {
+	query := fmt.Sprintf("SELECT user_id, hide_from_leaderboards, updated_at FROM %s WHERE user_id = $1", privacySettingsTable)
+
+	var settings model.PrivacySettings
+	err := conn(ctx, s.db).QueryRow(ctx, query, userID).Scan(&settings.UserID, &settings.HideFromLeaderboards, &settings.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return model.NewPrivacySettings(userID), nil
+		}
+
+		return nil, fmt.Errorf("failed to get privacy settings: %w", err)
+	}
+
+	return &settings, nil
+}
+
+func (s *friendStorage) SavePrivacySettings(ctx context.Context, settings *model.PrivacySettings) error {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, hide_from_leaderboards, updated_at) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET hide_from_leaderboards = EXCLUDED.hide_from_leaderboards, updated_at = EXCLUDED.updated_at`, privacySettingsTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, settings.UserID, settings.HideFromLeaderboards, settings.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save privacy settings: %w", err)
+	}
+
+	return nil
+}
+
+// GetLeaderboard fills the entries of the leaderboard for the user and their friends from the XP totals of its
+// period. Friends hiding from leaderboards are left out, the user always sees themselves.
+func (s *friendStorage) GetLeaderboard(ctx context.Context, userID string, leaderboard *model.Leaderboard) error {
+	// the metric is checked by model.NewLeaderboard, it names a column of xp_totals
+	if leaderboard.Metric != model.LeaderboardByXP && leaderboard.Metric != model.LeaderboardByChapters {
+		return fmt.Errorf("invalid leaderboard metric %q", leaderboard.Metric)
+	}
+
+	query := fmt.Sprintf(`WITH members AS (
+		SELECT $1::uuid AS id
+		UNION
+		SELECT f.friend_id FROM %[1]s f
+		LEFT JOIN %[2]s p ON p.user_id = f.friend_id
+		WHERE f.user_id = $1 AND NOT COALESCE(p.hide_from_leaderboards, FALSE)
+	)
+	SELECT RANK() OVER (ORDER BY COALESCE(t.%[4]s, 0) DESC), u.id, COALESCE(u.first_name, ''), COALESCE(u.last_name, ''),
+		COALESCE(t.xp, 0), COALESCE(t.chapters, 0)
+	FROM members m
+	JOIN users u ON u.id = m.id
+	LEFT JOIN %[3]s t ON t.user_id = m.id AND t.period = $2 AND t.period_start = $3
+	ORDER BY 1, lower(u.first_name), lower(u.last_name)`, friendshipsTable, privacySettingsTable, xpTotalsTable, leaderboard.Metric)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID, leaderboard.Period, leaderboard.Start)
+	if err != nil {
+		return fmt.Errorf("failed to get leaderboard: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry model.LeaderboardEntry
+		if err := rows.Scan(&entry.Rank, &entry.UserID, &entry.FirstName, &entry.LastName, &entry.XP, &entry.Chapters); err != nil {
+			return fmt.Errorf("failed to scan leaderboard entry: %w", err)
+		}
+
+		entry.Me = entry.UserID == userID
+		leaderboard.Entries = append(leaderboard.Entries, &entry)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to iterate over leaderboard: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const (
+	xpAwardsTable         = "xp_awards"
+	userAchievementsTable = "user_achievements"
+	xpTotalsTable         = "xp_totals"
+)
+
+type gamificationStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewGamificationStorage(db *pgxpool.Pool) GamificationStorage {
+	return &gamificationStorage{db: db}
+}
+
+// CreateXPAward stores the award unless its goal or chapter awarded XP before and adds it to the weekly and monthly
+// totals of the user, it reports whether it was stored
+func (s *gamificationStorage) CreateXPAward(ctx context.Context, award *model.XPAward) (bool, error) {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, source, source_id, points, on_time, created_at)
+	VALUES ($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (source, source_id) DO NOTHING`, xpAwardsTable)
+
+	result, err := tx.Exec(ctx, query, award.ID, award.UserID, award.Source, award.SourceID, award.Points, award.OnTime, award.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create xp award: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	var goals, chapters int
+	if award.Source == model.XPSourceGoal {
+		goals = 1
+	} else {
+		chapters = 1
+	}
+
+	query = fmt.Sprintf(`INSERT INTO %s (user_id, period, period_start, xp, goals, chapters) VALUES ($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (user_id, period, period_start) DO UPDATE
+	SET xp = %[1]s.xp + EXCLUDED.xp, goals = %[1]s.goals + EXCLUDED.goals, chapters = %[1]s.chapters + EXCLUDED.chapters`, xpTotalsTable)
+
+	for _, period := range []string{model.LeaderboardWeek, model.LeaderboardMonth} {
+		start, err := model.PeriodStart(period, award.CreatedAt)
+		if err != nil {
+			return false, err
+		}
+
+		if _, err := tx.Exec(ctx, query, award.UserID, period, start, award.Points, goals, chapters); err != nil {
+			return false, fmt.Errorf("failed to update xp totals: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetAchievementProgress sums up the awards of the user, the streak is left to the caller
+func (s *gamificationStorage) GetAchievementProgress(ctx context.Context, userID string) (*model.AchievementProgress, error) {
+	query := fmt.Sprintf(`SELECT COALESCE(SUM(points), 0),
+		COUNT(*) FILTER (WHERE source = $2),
+		COUNT(*) FILTER (WHERE source = $3),
+		COUNT(*) FILTER (WHERE source = $2 AND on_time)
+	FROM %s WHERE user_id = $1`, xpAwardsTable)
+
+	var progress model.AchievementProgress
+	err := conn(ctx, s.db).QueryRow(ctx, query, userID, model.XPSourceGoal, model.XPSourceChapter).
+		Scan(&progress.XP, &progress.Goals, &progress.Chapters, &progress.OnTimeGoals)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievement progress: %w", err)
+	}
+
+	return &progress, nil
+}
+
+// GetActivityDays returns the days since the given time on which the user earned XP, newest first
+func (s *gamificationStorage) GetActivityDays(ctx context.Context, userID string, since time.Time) ([]time.Time, error) {
+	query := fmt.Sprintf(`SELECT DISTINCT created_at::date AS day FROM %s
+	WHERE user_id = $1 AND created_at >= $2
+	ORDER BY day DESC`, xpAwardsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get activity days: %w", err)
+	}
+	defer rows.Close()
+
+	var days []time.Time
+	for rows.Next() {
+		var day time.Time
+		if err := rows.Scan(&day); err != nil {
+			return nil, fmt.Errorf("failed to scan activity day: %w", err)
+		}
+
+		days = append(days, day)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over activity days: %w", err)
+	}
+
+	return days, nil
+}
+
+// UnlockAchievement records the achievement for the user unless it is unlocked already, it reports whether it was recorded
+func (s *gamificationStorage) UnlockAchievement(ctx context.Context, userID, code string, unlockedAt time.Time) (bool, error) {
+	query := fmt.Sprintf(`INSERT INTO %s (user_id, code, unlocked_at) VALUES ($1, $2, $3)
+	ON CONFLICT (user_id, code) DO NOTHING`, userAchievementsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, userID, code, unlockedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to unlock achievement: %w", err)
+	}
+
+	return result.RowsAffected() > 0, nil
+}
+
+// GetAchievements returns when each achievement of the user was unlocked by its code
+func (s *gamificationStorage) GetAchievements(ctx context.Context, userID string) (map[string]time.Time, error) {
+	query := fmt.Sprintf("SELECT code, unlocked_at FROM %s WHERE user_id = $1", userAchievementsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get achievements: %w", err)
+	}
+	defer rows.Close()
+
+	achievements := make(map[string]time.Time)
+	for rows.Next() {
+		var (
+			code       string
+			unlockedAt time.Time
+		)
+		if err := rows.Scan(&code, &unlockedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan achievement: %w", err)
+		}
+
+		achievements[code] = unlockedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over achievements: %w", err)
+	}
+
+	return achievements, nil
+}
@@ -7,6 +7,8 @@ import (
 	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/nordew/Strive/internal/model"
+	"strings"
+	"time"
 )
 
 const (
@@ -15,10 +17,20 @@ const (
 	commentsTable = "comments"
 )
 
+// goalColumns is the column list scanned by scanGoal
+const goalColumns = "id, user_id, COALESCE(parent_id::text, ''), type, title, description, COALESCE(progress, 0), COALESCE(is_done, FALSE), deadline, COALESCE(priority, 0), COALESCE(tags, '{}'), version, created_at, updated_at, deleted_at, archived_at, completed_at"
+
+// chapterColumns is the column list scanned by scanChapter, dependencies on trashed chapters are left out
+const chapterColumns = "id, goal_id, title, description, COALESCE(is_done, FALSE), deadline, COALESCE(priority, 0), position, COALESCE((SELECT array_agg(d.depends_on_id::text) FROM chapter_dependencies d JOIN chapters dc ON dc.id = d.depends_on_id WHERE d.chapter_id = chapters.id AND dc.deleted_at IS NULL), '{}'), version, created_at, updated_at, deleted_at, completed_at"
+
+// commentColumns is the column list scanned by scanComment
+const commentColumns = "id, COALESCE(goal_id::text, ''), COALESCE(chapter_id::text, ''), content, created_at, updated_at, deleted_at"
+
 var (
 	ErrGoalNotFound    = fmt.Errorf("goal not found")
 	ErrChapterNotFound = fmt.Errorf("chapter not found")
 	ErrCommentNotFound = fmt.Errorf("comment not found")
+	ErrVersionConflict = fmt.Errorf("item was changed by someone else")
 )
 
 type goalStorage struct {
@@ -30,20 +42,31 @@ func NewGoalStorage(db *pgxpool.Pool) GoalStorage {
 }
 
 func (s *goalStorage) Create(ctx context.Context, goal *model.Goal) error {
-	query := fmt.Sprintf("INSERT INTO %s (id, user_id, title, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)", goalsTable)
+	query := fmt.Sprintf("INSERT INTO %s (id, user_id, parent_id, type, title, description, progress, is_done, deadline, priority, tags, created_at, updated_at, completed_at) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14)", goalsTable)
+
+	// goals created completed, such as imported ones, count as completed when they were last changed
+	goal.CompletedAt = nil
+	if goal.IsCompleted() {
+		goal.CompletedAt = &goal.UpdatedAt
+	}
 
-	_, err := s.db.Exec(ctx, query, goal.ID, goal.UserID, goal.Title, goal.Description, goal.CreatedAt, goal.UpdatedAt)
+	_, err := conn(ctx, s.db).Exec(ctx, query, goal.ID, goal.UserID, goal.ParentID, goal.Type, goal.Title, goal.Description, goal.Progress, goal.IsDone, goal.Deadline, goal.Priority, goal.Tags, goal.CreatedAt, goal.UpdatedAt, goal.CompletedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create goal: %w", err)
 	}
 
-	return nil
+	return ensureTags(ctx, conn(ctx, s.db), goal.UserID, goal.Tags, goal.CreatedAt)
 }
 
 func (s *goalStorage) CreateChapter(ctx context.Context, chapter *model.Chapter) error {
-	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, title, description, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)", chaptersTable)
+	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, title, description, is_done, deadline, priority, position, created_at, updated_at, completed_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)", chaptersTable)
+
+	chapter.CompletedAt = nil
+	if chapter.IsDone {
+		chapter.CompletedAt = &chapter.UpdatedAt
+	}
 
-	_, err := s.db.Exec(ctx, query, chapter.ID, chapter.GoalID, chapter.Title, chapter.Description, chapter.CreatedAt, chapter.UpdatedAt)
+	_, err := conn(ctx, s.db).Exec(ctx, query, chapter.ID, chapter.GoalID, chapter.Title, chapter.Description, chapter.IsDone, chapter.Deadline, chapter.Priority, chapter.Position, chapter.CreatedAt, chapter.UpdatedAt, chapter.CompletedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create chapter: %w", err)
 	}
@@ -52,9 +75,9 @@ func (s *goalStorage) CreateChapter(ctx context.Context, chapter *model.Chapter)
 }
 
 func (s *goalStorage) CreateComment(ctx context.Context, comment *model.Comment) error {
-	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, chapter_id, content, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6)", commentsTable)
+	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, chapter_id, content, created_at, updated_at) VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, '')::uuid, $4, $5, $6)", commentsTable)
 
-	_, err := s.db.Exec(ctx, query, comment.ID, comment.GoalID, comment.ChapterID, comment.Content, comment.CreatedAt, comment.UpdatedAt)
+	_, err := conn(ctx, s.db).Exec(ctx, query, comment.ID, comment.GoalID, comment.ChapterID, comment.Content, comment.CreatedAt, comment.UpdatedAt)
 	if err != nil {
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
@@ -63,11 +86,9 @@ func (s *goalStorage) CreateComment(ctx context.Context, comment *model.Comment)
 }
 
 func (s *goalStorage) GetByID(ctx context.Context, id string) (*model.Goal, error) {
-	var goal model.Goal
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL", goalColumns, goalsTable)
 
-	query := fmt.Sprintf("SELECT id, user_id, title, description, created_at, updated_at FROM %s WHERE id = $1", goalsTable)
-
-	err := s.db.QueryRow(ctx, query, id).Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.CreatedAt, &goal.UpdatedAt)
+	goal, err := scanGoal(conn(ctx, s.db).QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrGoalNotFound
@@ -76,39 +97,62 @@ func (s *goalStorage) GetByID(ctx context.Context, id string) (*model.Goal, erro
 		return nil, fmt.Errorf("failed to get goal by id: %w", err)
 	}
 
-	return &goal, nil
+	return goal, nil
 }
 
-func (s *goalStorage) GetByUserID(ctx context.Context, userID string) ([]*model.Goal, error) {
-	var goals []*model.Goal
+// GetByUserID returns the goals of the user that match the filter, oldest first
+func (s *goalStorage) GetByUserID(ctx context.Context, userID string, filter model.GoalFilter) ([]*model.Goal, error) {
+	conditions := []string{"user_id = $1", "deleted_at IS NULL"}
+	args := []any{userID}
 
-	query := fmt.Sprintf("SELECT id, user_id, title, description, created_at, updated_at FROM %s WHERE user_id = $1", goalsTable)
+	switch filter.Archived {
+	case model.ArchiveExclude:
+		conditions = append(conditions, "archived_at IS NULL")
+	case model.ArchiveOnly:
+		conditions = append(conditions, "archived_at IS NOT NULL")
+	}
+
+	if filter.Query != "" {
+		args = append(args, filter.Query)
+		conditions = append(conditions, textSearchCondition(len(args)))
+	}
+
+	if filter.Expr != nil {
+		exprConditions, exprArgs, err := filterConditions(filter.Expr, time.Now(), args)
+		if err != nil {
+			return nil, err
+		}
 
-	rows, err := s.db.Query(ctx, query, userID)
+		conditions, args = append(conditions, exprConditions...), exprArgs
+	}
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s ORDER BY created_at, id", goalColumns, goalsTable, strings.Join(conditions, " AND "))
+
+	goals, err := s.queryGoals(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get goals by user id: %w", err)
 	}
-	defer rows.Close()
 
-	for rows.Next() {
-		var goal model.Goal
+	return goals, nil
+}
 
-		if err := rows.Scan(&goal.ID, &goal.UserID, &goal.Title, &goal.Description, &goal.CreatedAt, &goal.UpdatedAt); err != nil {
-			return nil, fmt.Errorf("failed to scan goal: %w", err)
-		}
+// LockByTag returns the goals of the user outside of the trash using the tag and locks them until the end of
+// the transaction, so the tag can be replaced in them without version conflicts
+func (s *goalStorage) LockByTag(ctx context.Context, userID, tag string) ([]*model.Goal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 AND tags @> ARRAY[$2::text] AND deleted_at IS NULL ORDER BY created_at, id FOR UPDATE", goalColumns, goalsTable)
 
-		goals = append(goals, &goal)
+	goals, err := s.queryGoals(ctx, query, userID, tag)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals by tag: %w", err)
 	}
 
 	return goals, nil
 }
 
 func (s *goalStorage) GetChapterByID(ctx context.Context, id string) (*model.Chapter, error) {
-	var chapter model.Chapter
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL", chapterColumns, chaptersTable)
 
-	query := fmt.Sprintf("SELECT id, goal_id, title, description, created_at, updated_at FROM %s WHERE id = $1", chaptersTable)
-
-	err := s.db.QueryRow(ctx, query, id).Scan(&chapter.ID, &chapter.GoalID, &chapter.Title, &chapter.Description, &chapter.CreatedAt, &chapter.UpdatedAt)
+	chapter, err := scanChapter(conn(ctx, s.db).QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrChapterNotFound
@@ -117,15 +161,24 @@ func (s *goalStorage) GetChapterByID(ctx context.Context, id string) (*model.Cha
 		return nil, fmt.Errorf("failed to get chapter by id: %w", err)
 	}
 
-	return &chapter, nil
+	return chapter, nil
 }
 
-func (s *goalStorage) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
-	var comment model.Comment
+func (s *goalStorage) GetChaptersByGoalID(ctx context.Context, goalID string) ([]*model.Chapter, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE goal_id = $1 AND deleted_at IS NULL ORDER BY position, id", chapterColumns, chaptersTable)
 
-	query := fmt.Sprintf("SELECT id, goal_id, chapter_id, content, created_at, updated_at FROM %s WHERE id = $1", commentsTable)
+	chapters, err := s.queryChapters(ctx, query, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get chapters by goal id: %w", err)
+	}
 
-	err := s.db.QueryRow(ctx, query, id).Scan(&comment.ID, &comment.GoalID, &comment.ChapterID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt)
+	return chapters, nil
+}
+
+func (s *goalStorage) GetCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NULL", commentColumns, commentsTable)
+
+	comment, err := scanComment(conn(ctx, s.db).QueryRow(ctx, query, id))
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrCommentNotFound
@@ -134,31 +187,58 @@ func (s *goalStorage) GetCommentByID(ctx context.Context, id string) (*model.Com
 		return nil, fmt.Errorf("failed to get comment by id: %w", err)
 	}
 
-	return &comment, nil
+	return comment, nil
 }
 
+// Update writes the goal if it is still at goal.Version and sets the incremented version.
+// ErrVersionConflict is returned when the goal was updated in the meantime.
 func (s *goalStorage) Update(ctx context.Context, goal *model.Goal) error {
-	query := fmt.Sprintf("UPDATE %s SET title = $1, description = $2, updated_at = $3 WHERE id = $4", goalsTable)
+	query := fmt.Sprintf(`UPDATE %s SET title = $1, description = $2, deadline = $3, priority = $4, tags = $5, updated_at = $6, version = version + 1
+	WHERE id = $7 AND version = $8 AND deleted_at IS NULL
+	RETURNING version`, goalsTable)
 
-	_, err := s.db.Exec(ctx, query, goal.Title, goal.Description, goal.UpdatedAt, goal.ID)
+	err := conn(ctx, s.db).QueryRow(ctx, query, goal.Title, goal.Description, goal.Deadline, goal.Priority, goal.Tags, goal.UpdatedAt, goal.ID, goal.Version).Scan(&goal.Version)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrGoalNotFound
+			return versionConflict(ctx, conn(ctx, s.db), goalsTable, goal.ID, ErrGoalNotFound)
 		}
 
 		return fmt.Errorf("failed to update goal: %w", err)
 	}
 
+	return ensureTags(ctx, conn(ctx, s.db), goal.UserID, goal.Tags, goal.UpdatedAt)
+}
+
+func (s *goalStorage) UpdateProgress(ctx context.Context, id string, progress int) error {
+	// completed_at keeps the first time the goal reached 100%, it is cleared when the goal drops below again
+	query := fmt.Sprintf(`UPDATE %s SET progress = $1, updated_at = now(),
+		completed_at = CASE WHEN $1 >= 100 OR COALESCE(is_done, FALSE) THEN COALESCE(completed_at, now()) END
+	WHERE id = $2`, goalsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, progress, id)
+	if err != nil {
+		return fmt.Errorf("failed to update goal progress: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+
 	return nil
 }
 
+// UpdateChapter writes the chapter if it is still at chapter.Version and sets the incremented version.
+// ErrVersionConflict is returned when the chapter was updated in the meantime.
 func (s *goalStorage) UpdateChapter(ctx context.Context, chapter *model.Chapter) error {
-	query := fmt.Sprintf("UPDATE %s SET title = $1, description = $2, updated_at = $3 WHERE id = $4", chaptersTable)
+	query := fmt.Sprintf(`UPDATE %s SET title = $1, description = $2, is_done = $3, deadline = $4, priority = $5, updated_at = $6, version = version + 1,
+		completed_at = CASE WHEN $3 THEN COALESCE(completed_at, $6) END
+	WHERE id = $7 AND version = $8 AND deleted_at IS NULL
+	RETURNING version, completed_at`, chaptersTable)
 
-	_, err := s.db.Exec(ctx, query, chapter.Title, chapter.Description, chapter.UpdatedAt, chapter.ID)
+	err := conn(ctx, s.db).QueryRow(ctx, query, chapter.Title, chapter.Description, chapter.IsDone, chapter.Deadline, chapter.Priority, chapter.UpdatedAt, chapter.ID, chapter.Version).Scan(&chapter.Version, &chapter.CompletedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrChapterNotFound
+			return versionConflict(ctx, conn(ctx, s.db), chaptersTable, chapter.ID, ErrChapterNotFound)
 		}
 
 		return fmt.Errorf("failed to update chapter: %w", err)
@@ -167,10 +247,26 @@ func (s *goalStorage) UpdateChapter(ctx context.Context, chapter *model.Chapter)
 	return nil
 }
 
+// versionConflict tells a conditional update that missed because of the version from one that missed the row
+func versionConflict(ctx context.Context, q querier, table, id string, notFound error) error {
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s WHERE id = $1 AND deleted_at IS NULL)", table)
+
+	var exists bool
+	if err := q.QueryRow(ctx, query, id).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check version: %w", err)
+	}
+
+	if !exists {
+		return notFound
+	}
+
+	return ErrVersionConflict
+}
+
 func (s *goalStorage) UpdateComment(ctx context.Context, comment *model.Comment) error {
 	query := fmt.Sprintf("UPDATE %s SET content = $1, updated_at = $2 WHERE id = $3", commentsTable)
 
-	_, err := s.db.Exec(ctx, query, comment.Content, comment.UpdatedAt, comment.ID)
+	_, err := conn(ctx, s.db).Exec(ctx, query, comment.Content, comment.UpdatedAt, comment.ID)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return ErrCommentNotFound
@@ -182,47 +278,84 @@ func (s *goalStorage) UpdateComment(ctx context.Context, comment *model.Comment)
 	return nil
 }
 
-func (s *goalStorage) Delete(ctx context.Context, id string) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", goalsTable)
+func (s *goalStorage) queryGoals(ctx context.Context, query string, args ...any) ([]*model.Goal, error) {
+	var goals []*model.Goal
 
-	_, err := s.db.Exec(ctx, query, id)
+	rows, err := conn(ctx, s.db).Query(ctx, query, args...)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrGoalNotFound
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		goal, err := scanGoal(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal: %w", err)
 		}
 
-		return fmt.Errorf("failed to delete goal: %w", err)
+		goals = append(goals, goal)
 	}
 
-	return nil
+	return goals, rows.Err()
 }
 
-func (s *goalStorage) DeleteChapter(ctx context.Context, id string) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", chaptersTable)
+func scanGoal(row pgx.Row) (*model.Goal, error) {
+	var deadline *time.Time
 
-	_, err := s.db.Exec(ctx, query, id)
+	goal := &model.Goal{}
+	err := row.Scan(&goal.ID, &goal.UserID, &goal.ParentID, &goal.Type, &goal.Title, &goal.Description, &goal.Progress, &goal.IsDone, &deadline, &goal.Priority, &goal.Tags, &goal.Version, &goal.CreatedAt, &goal.UpdatedAt, &goal.DeletedAt, &goal.ArchivedAt, &goal.CompletedAt)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrChapterNotFound
+		return nil, err
+	}
+
+	if deadline != nil {
+		goal.Deadline = *deadline
+	}
+	return goal, nil
+}
+
+func (s *goalStorage) queryChapters(ctx context.Context, query string, args ...any) ([]*model.Chapter, error) {
+	var chapters []*model.Chapter
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		chapter, err := scanChapter(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan chapter: %w", err)
 		}
 
-		return fmt.Errorf("failed to delete chapter: %w", err)
+		chapters = append(chapters, chapter)
 	}
 
-	return nil
+	return chapters, rows.Err()
 }
 
-func (s *goalStorage) DeleteComment(ctx context.Context, id string) error {
-	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", commentsTable)
+func scanChapter(row pgx.Row) (*model.Chapter, error) {
+	var deadline *time.Time
 
-	_, err := s.db.Exec(ctx, query, id)
+	chapter := &model.Chapter{}
+	err := row.Scan(&chapter.ID, &chapter.GoalID, &chapter.Title, &chapter.Description, &chapter.IsDone, &deadline, &chapter.Priority, &chapter.Position, &chapter.DependsOn, &chapter.Version, &chapter.CreatedAt, &chapter.UpdatedAt, &chapter.DeletedAt, &chapter.CompletedAt)
 	if err != nil {
-		if errors.Is(err, pgx.ErrNoRows) {
-			return ErrCommentNotFound
-		}
+		return nil, err
+	}
 
-		return fmt.Errorf("failed to delete comment: %w", err)
+	if deadline != nil {
+		chapter.Deadline = *deadline
 	}
+	return chapter, nil
+}
 
-	return nil
+func scanComment(row pgx.Row) (*model.Comment, error) {
+	comment := &model.Comment{}
+	err := row.Scan(&comment.ID, &comment.GoalID, &comment.ChapterID, &comment.Content, &comment.CreatedAt, &comment.UpdatedAt, &comment.DeletedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return comment, nil
 }
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+// Archive archives the given goals of the user that are not archived yet and returns them.
+// updated_at is set as well, so a goal is judged by the time since it was archived or unarchived.
+func (s *goalStorage) Archive(ctx context.Context, userID string, ids []string, archivedAt time.Time) ([]*model.Goal, error) {
+	query := fmt.Sprintf(`UPDATE %s SET archived_at = $3, updated_at = $3
+	WHERE user_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL AND archived_at IS NULL
+	RETURNING %s`, goalsTable, goalColumns)
+
+	goals, err := s.queryGoals(ctx, query, userID, ids, archivedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to archive goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// Unarchive brings the given archived goals of the user back to the active ones and returns them
+func (s *goalStorage) Unarchive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error) {
+	query := fmt.Sprintf(`UPDATE %s SET archived_at = NULL, updated_at = now()
+	WHERE user_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL AND archived_at IS NOT NULL
+	RETURNING %s`, goalsTable, goalColumns)
+
+	goals, err := s.queryGoals(ctx, query, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unarchive goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// GetArchivable returns active goals completed and last updated before the given time, so unarchiving a goal
+// restarts its clock
+func (s *goalStorage) GetArchivable(ctx context.Context, completedBefore time.Time) ([]*model.Goal, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s
+	WHERE deleted_at IS NULL AND archived_at IS NULL AND completed_at < $1 AND updated_at < $1
+	ORDER BY user_id, completed_at`, goalColumns, goalsTable)
+
+	goals, err := s.queryGoals(ctx, query, completedBefore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get archivable goals: %w", err)
+	}
+
+	return goals, nil
+}
+
+// GetByIDs returns the given goals of the user outside the trash, goals that are not found are left out
+func (s *goalStorage) GetByIDs(ctx context.Context, userID string, ids []string) ([]*model.Goal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 AND id = ANY($2::uuid[]) AND deleted_at IS NULL", goalColumns, goalsTable)
+
+	goals, err := s.queryGoals(ctx, query, userID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goals by ids: %w", err)
+	}
+
+	return goals, nil
+}
@@ -0,0 +1,106 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+// StreamByUserID calls fn for every goal of the user with its chapters loaded, in creation order.
+// Goals are read from a single cursor, so the whole set is never held in memory.
+func (s *goalStorage) StreamByUserID(ctx context.Context, userID string, fn func(goal *model.Goal) error) error {
+	query := fmt.Sprintf(`SELECT g.id, g.user_id, COALESCE(g.parent_id::text, ''), g.type, g.title, g.description, COALESCE(g.progress, 0),
+		COALESCE(g.is_done, FALSE), g.deadline, COALESCE(g.priority, 0), COALESCE(g.tags, '{}'), g.created_at, g.updated_at,
+		c.id, c.title, c.description, COALESCE(c.is_done, FALSE), c.deadline, COALESCE(c.priority, 0), c.position,
+		COALESCE((SELECT array_agg(d.depends_on_id::text) FROM chapter_dependencies d JOIN %[2]s dc ON dc.id = d.depends_on_id
+			WHERE d.chapter_id = c.id AND dc.deleted_at IS NULL), '{}'),
+		c.created_at, c.updated_at
+	FROM %[1]s g LEFT JOIN %[2]s c ON c.goal_id = g.id AND c.deleted_at IS NULL
+	WHERE g.user_id = $1 AND g.deleted_at IS NULL
+	ORDER BY g.created_at, g.id, c.position`, goalsTable, chaptersTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return fmt.Errorf("failed to stream goals: %w", err)
+	}
+	defer rows.Close()
+
+	var current *model.Goal
+	for rows.Next() {
+		var (
+			goal            model.Goal
+			goalDeadline    *time.Time
+			chapterID       *string
+			chapter         model.Chapter
+			chapterDeadline *time.Time
+			chapterCreated  *time.Time
+			chapterUpdated  *time.Time
+			chapterTitle    *string
+			chapterDesc     *string
+			chapterPosition *string
+		)
+
+		err := rows.Scan(
+			&goal.ID, &goal.UserID, &goal.ParentID, &goal.Type, &goal.Title, &goal.Description, &goal.Progress,
+			&goal.IsDone, &goalDeadline, &goal.Priority, &goal.Tags, &goal.CreatedAt, &goal.UpdatedAt,
+			&chapterID, &chapterTitle, &chapterDesc, &chapter.IsDone, &chapterDeadline, &chapter.Priority, &chapterPosition,
+			&chapter.DependsOn, &chapterCreated, &chapterUpdated,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to scan goal: %w", err)
+		}
+
+		if current == nil || current.ID != goal.ID {
+			if current != nil {
+				if err := fn(current); err != nil {
+					return err
+				}
+			}
+
+			if goalDeadline != nil {
+				goal.Deadline = *goalDeadline
+			}
+			current = &goal
+		}
+
+		if chapterID == nil {
+			continue
+		}
+
+		chapter.ID = *chapterID
+		chapter.GoalID = current.ID
+		chapter.Title = deref(chapterTitle)
+		chapter.Description = deref(chapterDesc)
+		chapter.Position = deref(chapterPosition)
+		if chapterDeadline != nil {
+			chapter.Deadline = *chapterDeadline
+		}
+		if chapterCreated != nil {
+			chapter.CreatedAt = *chapterCreated
+		}
+		if chapterUpdated != nil {
+			chapter.UpdatedAt = *chapterUpdated
+		}
+
+		current.Chapters = append(current.Chapters, chapter)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to stream goals: %w", err)
+	}
+
+	if current != nil {
+		return fn(current)
+	}
+
+	return nil
+}
+
+func deref(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	return *value
+}
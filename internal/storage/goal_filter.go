@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+// goalCompletedCondition matches goals that model.Goal.IsCompleted reports as completed
+const goalCompletedCondition = "(COALESCE(is_done, FALSE) OR COALESCE(progress, 0) >= 100)"
+
+// textSearchCondition matches goals whose title, description or tags contain the argument $n
+func textSearchCondition(n int) string {
+	return fmt.Sprintf(`(strpos(lower(title), lower($%[1]d)) > 0 OR strpos(lower(description), lower($%[1]d)) > 0
+			OR EXISTS (SELECT 1 FROM unnest(tags) tag WHERE strpos(lower(tag), lower($%[1]d)) > 0))`, n)
+}
+
+// filterConditions compiles the terms of a filter expression to SQL conditions on the goals table. Values are
+// appended to args and referenced as parameters, relative due dates are counted from the start of today.
+func filterConditions(expr *model.FilterExpr, now time.Time, args []any) ([]string, []any, error) {
+	today := now.UTC().Truncate(24 * time.Hour)
+
+	arg := func(value any) int {
+		args = append(args, value)
+		return len(args)
+	}
+
+	conditions := make([]string, 0, len(expr.Terms))
+	for _, term := range expr.Terms {
+		if !validFilterOp(term) {
+			return nil, nil, fmt.Errorf("invalid operator %q for filter field %q", term.Op, term.Field)
+		}
+
+		var condition string
+
+		switch term.Field {
+		case model.FilterTag:
+			// goals without tags store NULL, which would make a negated term drop them
+			condition = fmt.Sprintf("COALESCE(tags, '{}') @> ARRAY[$%d::text]", arg(term.Text))
+		case model.FilterText:
+			condition = textSearchCondition(arg(term.Text))
+		case model.FilterPriority:
+			condition = fmt.Sprintf("COALESCE(priority, 0) %s $%d", term.Op, arg(term.Number))
+		case model.FilterProgress:
+			condition = fmt.Sprintf("COALESCE(progress, 0) %s $%d", term.Op, arg(term.Number))
+		case model.FilterDue:
+			day := term.Date
+			if day.IsZero() {
+				day = today.AddDate(0, 0, term.Days)
+			}
+
+			if term.Op == model.FilterEq {
+				condition = fmt.Sprintf("(deadline >= $%d AND deadline < $%d)", arg(day), arg(day.AddDate(0, 0, 1)))
+			} else {
+				condition = fmt.Sprintf("deadline %s $%d", term.Op, arg(day))
+			}
+		case model.FilterDone:
+			condition = goalCompletedCondition
+		case model.FilterOverdue:
+			condition = fmt.Sprintf("(deadline < $%d AND NOT %s)", arg(now), goalCompletedCondition)
+		case model.FilterArchived:
+			condition = "archived_at IS NOT NULL"
+		default:
+			return nil, nil, fmt.Errorf("unknown filter field %q", term.Field)
+		}
+
+		if term.Negate {
+			condition = "NOT (" + condition + ")"
+		}
+
+		conditions = append(conditions, condition)
+	}
+
+	return conditions, args, nil
+}
+
+// validFilterOp guards the operators written into the query, only known comparisons are accepted
+func validFilterOp(term model.FilterTerm) bool {
+	switch term.Field {
+	case model.FilterPriority, model.FilterProgress, model.FilterDue:
+		switch term.Op {
+		case model.FilterEq, model.FilterLt, model.FilterLte, model.FilterGt, model.FilterGte:
+			return true
+		}
+
+		return false
+	default:
+		return true
+	}
+}
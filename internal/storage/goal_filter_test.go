@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nordew/Strive/internal/model"
+)
+
+func TestFilterConditionsTag(t *testing.T) {
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		filter     string
+		negate     bool
+		before     []any // before holds the arguments of conditions compiled earlier
+		conditions []string
+		args       []any
+	}{
+		{
+			name:       "tag",
+			filter:     "tag:work",
+			conditions: []string{"COALESCE(tags, '{}') @> ARRAY[$1::text]"},
+			args:       []any{"work"},
+		},
+		{
+			name:       "negated tag keeps untagged goals",
+			filter:     "!tag:work",
+			negate:     true,
+			conditions: []string{"NOT (COALESCE(tags, '{}') @> ARRAY[$1::text])"},
+			args:       []any{"work"},
+		},
+		{
+			name:       "negated quoted tag after another argument",
+			filter:     `!tag:"deep work"`,
+			negate:     true,
+			before:     []any{"user"},
+			conditions: []string{"NOT (COALESCE(tags, '{}') @> ARRAY[$2::text])"},
+			args:       []any{"user", "deep work"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expr, err := model.ParseFilter(tt.filter)
+			if err != nil {
+				t.Fatalf("ParseFilter(%q) error: %v", tt.filter, err)
+			}
+
+			if len(expr.Terms) != 1 || expr.Terms[0].Field != model.FilterTag || expr.Terms[0].Negate != tt.negate {
+				t.Fatalf("ParseFilter(%q) terms = %+v", tt.filter, expr.Terms)
+			}
+
+			conditions, args, err := filterConditions(expr, now, tt.before)
+			if err != nil {
+				t.Fatalf("filterConditions(%q) error: %v", tt.filter, err)
+			}
+
+			if !reflect.DeepEqual(conditions, tt.conditions) {
+				t.Errorf("conditions = %q, want %q", conditions, tt.conditions)
+			}
+
+			if !reflect.DeepEqual(args, tt.args) {
+				t.Errorf("args = %v, want %v", args, tt.args)
+			}
+		})
+	}
+}
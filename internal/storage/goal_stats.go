@@ -0,0 +1,65 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const statsVersionsTable = "stats_versions"
+
+// GetCompletionRecords returns the goals and chapters of the user that are not in the trash, including archived ones
+func (s *goalStorage) GetCompletionRecords(ctx context.Context, userID string) ([]model.CompletionRecord, error) {
+	query := fmt.Sprintf(`SELECT '%[1]s', created_at, completed_at, deadline, COALESCE(tags, '{}')
+		FROM %[3]s WHERE user_id = $1 AND deleted_at IS NULL
+	UNION ALL
+	SELECT '%[2]s', c.created_at, c.completed_at, c.deadline, '{}'::text[]
+		FROM %[4]s c JOIN %[3]s g ON g.id = c.goal_id
+		WHERE g.user_id = $1 AND g.deleted_at IS NULL AND c.deleted_at IS NULL`, model.RecordGoal, model.RecordChapter, goalsTable, chaptersTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get completion records: %w", err)
+	}
+	defer rows.Close()
+
+	var records []model.CompletionRecord
+	for rows.Next() {
+		var record model.CompletionRecord
+		if err := rows.Scan(&record.Kind, &record.CreatedAt, &record.CompletedAt, &record.Deadline, &record.Tags); err != nil {
+			return nil, fmt.Errorf("failed to scan completion record: %w", err)
+		}
+
+		records = append(records, record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over completion records: %w", err)
+	}
+
+	return records, nil
+}
+
+// GetStatsVersion returns the version of the statistics of the user, 0 until it is bumped for the first time
+func (s *goalStorage) GetStatsVersion(ctx context.Context, userID string) (int64, error) {
+	query := fmt.Sprintf("SELECT COALESCE((SELECT version FROM %s WHERE user_id = $1), 0)", statsVersionsTable)
+
+	var version int64
+	if err := conn(ctx, s.db).QueryRow(ctx, query, userID).Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to get stats version: %w", err)
+	}
+
+	return version, nil
+}
+
+// BumpStatsVersion increments the version of the statistics of the user, users that no longer exist are skipped
+func (s *goalStorage) BumpStatsVersion(ctx context.Context, userID string) error {
+	query := fmt.Sprintf(`INSERT INTO %[1]s (user_id, version) SELECT id, 1 FROM %[2]s WHERE id = $1
+	ON CONFLICT (user_id) DO UPDATE SET version = %[1]s.version + 1`, statsVersionsTable, usersTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, userID); err != nil {
+		return fmt.Errorf("failed to bump stats version: %w", err)
+	}
+
+	return nil
+}
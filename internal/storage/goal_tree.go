@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/nordew/Strive/internal/model"
+)
+
+var ErrGoalCycle = fmt.Errorf("goal cannot be moved under its own subtree")
+
+func (s *goalStorage) GetChildren(ctx context.Context, id string) ([]*model.Goal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE parent_id = $1 AND deleted_at IS NULL ORDER BY created_at", goalColumns, goalsTable)
+
+	goals, err := s.queryGoals(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal children: %w", err)
+	}
+
+	return goals, nil
+}
+
+// GetTree returns the goal and its descendants up to maxDepth levels below it, ordered by depth
+func (s *goalStorage) GetTree(ctx context.Context, rootID string, maxDepth int) ([]*model.Goal, error) {
+	query := fmt.Sprintf(`WITH RECURSIVE tree AS (
+		SELECT g.*, 0 AS depth FROM %[2]s g WHERE g.id = $1 AND g.deleted_at IS NULL
+		UNION ALL
+		SELECT g.*, t.depth + 1 FROM %[2]s g JOIN tree t ON g.parent_id = t.id WHERE t.depth < $2 AND g.deleted_at IS NULL
+	)
+	SELECT %[1]s FROM tree ORDER BY depth, created_at`, goalColumns, goalsTable)
+
+	goals, err := s.queryGoals(ctx, query, rootID, maxDepth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal tree: %w", err)
+	}
+
+	if len(goals) == 0 {
+		return nil, ErrGoalNotFound
+	}
+
+	return goals, nil
+}
+
+// GetAncestors returns the goal followed by its ancestors, nearest first
+func (s *goalStorage) GetAncestors(ctx context.Context, id string) ([]*model.Goal, error) {
+	query := fmt.Sprintf(`WITH RECURSIVE ancestors AS (
+		SELECT g.*, 0 AS depth FROM %[2]s g WHERE g.id = $1 AND g.deleted_at IS NULL
+		UNION ALL
+		SELECT g.*, a.depth + 1 FROM %[2]s g JOIN ancestors a ON g.id = a.parent_id WHERE g.deleted_at IS NULL
+	)
+	SELECT %[1]s FROM ancestors ORDER BY depth`, goalColumns, goalsTable)
+
+	goals, err := s.queryGoals(ctx, query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal ancestors: %w", err)
+	}
+
+	if len(goals) == 0 {
+		return nil, ErrGoalNotFound
+	}
+
+	return goals, nil
+}
+
+// Move re-parents the goal together with its subtree. An empty parentID makes the goal a root.
+// Moves are serialized per user so that two concurrent moves cannot produce a cycle.
+func (s *goalStorage) Move(ctx context.Context, id, parentID string) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("SELECT pg_advisory_xact_lock(hashtext(user_id::text)) FROM %s WHERE id = $1 AND deleted_at IS NULL", goalsTable)
+
+	result, err := tx.Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to lock goal tree: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+
+	if parentID != "" {
+		query = fmt.Sprintf(`WITH RECURSIVE ancestors AS (
+			SELECT id, parent_id FROM %[1]s WHERE id = $1
+			UNION ALL
+			SELECT g.id, g.parent_id FROM %[1]s g JOIN ancestors a ON g.id = a.parent_id
+		)
+		SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $2)`, goalsTable)
+
+		var cycle bool
+		if err := tx.QueryRow(ctx, query, parentID, id).Scan(&cycle); err != nil {
+			return fmt.Errorf("failed to check goal cycle: %w", err)
+		}
+
+		if cycle {
+			return ErrGoalCycle
+		}
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET parent_id = NULLIF($1, '')::uuid, updated_at = now() WHERE id = $2", goalsTable)
+
+	if _, err := tx.Exec(ctx, query, parentID, id); err != nil {
+		return fmt.Errorf("failed to move goal: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit goal move: %w", err)
+	}
+
+	return nil
+}
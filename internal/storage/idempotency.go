@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const idempotencyKeysTable = "idempotency_keys"
+
+var (
+	ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+	ErrIdempotencyKeyExists   = errors.New("idempotency key already exists")
+)
+
+type idempotencyStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewIdempotencyStorage(db *pgxpool.Pool) IdempotencyStorage {
+	return &idempotencyStorage{db: db}
+}
+
+// Create stores the record unless a key that has not expired yet exists in its scope, an expired one is replaced
+func (s *idempotencyStorage) Create(ctx context.Context, record *model.IdempotencyRecord) error {
+	query := fmt.Sprintf(`INSERT INTO %[1]s (scope, key, fingerprint, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (scope, key) DO UPDATE SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = '',
+			response_body = NULL, created_at = EXCLUDED.created_at, expires_at = EXCLUDED.expires_at
+		WHERE %[1]s.expires_at <= EXCLUDED.created_at`, idempotencyKeysTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, record.Scope, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrIdempotencyKeyExists
+	}
+
+	return nil
+}
+
+// Get returns the record of the key if it has not expired by now
+func (s *idempotencyStorage) Get(ctx context.Context, scope, key string, now time.Time) (*model.IdempotencyRecord, error) {
+	query := fmt.Sprintf(`SELECT scope, key, fingerprint, COALESCE(status_code, 0), content_type, COALESCE(response_body, ''::bytea), created_at, expires_at
+		FROM %s WHERE scope = $1 AND key = $2 AND expires_at > $3`, idempotencyKeysTable)
+
+	var record model.IdempotencyRecord
+	err := conn(ctx, s.db).QueryRow(ctx, query, scope, key, now).Scan(
+		&record.Scope,
+		&record.Key,
+		&record.Fingerprint,
+		&record.StatusCode,
+		&record.ContentType,
+		&record.Body,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrIdempotencyKeyNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	return &record, nil
+}
+
+// Complete stores the response of the record
+func (s *idempotencyStorage) Complete(ctx context.Context, record *model.IdempotencyRecord) error {
+	query := fmt.Sprintf("UPDATE %s SET status_code = $1, content_type = $2, response_body = $3 WHERE scope = $4 AND key = $5", idempotencyKeysTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, record.StatusCode, record.ContentType, record.Body, record.Scope, record.Key)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrIdempotencyKeyNotFound
+	}
+
+	return nil
+}
+
+func (s *idempotencyStorage) Delete(ctx context.Context, scope, key string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE scope = $1 AND key = $2", idempotencyKeysTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, scope, key); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *idempotencyStorage) DeleteExpired(ctx context.Context, now time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE expires_at <= $1", idempotencyKeysTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const (
+	keyResultsTable = "key_results"
+	checkInsTable   = "key_result_check_ins"
+)
+
+var ErrKeyResultNotFound = fmt.Errorf("key result not found")
+
+func (s *goalStorage) CreateKeyResult(ctx context.Context, keyResult *model.KeyResult) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, title, start_value, target_value, current_value, unit, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)", keyResultsTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, keyResult.ID, keyResult.GoalID, keyResult.Title, keyResult.StartValue, keyResult.TargetValue, keyResult.CurrentValue, keyResult.Unit, keyResult.CreatedAt, keyResult.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create key result: %w", err)
+	}
+
+	return nil
+}
+
+func (s *goalStorage) GetKeyResultByID(ctx context.Context, id string) (*model.KeyResult, error) {
+	var keyResult model.KeyResult
+
+	query := fmt.Sprintf("SELECT id, goal_id, title, start_value, target_value, current_value, unit, created_at, updated_at FROM %s WHERE id = $1", keyResultsTable)
+
+	err := conn(ctx, s.db).QueryRow(ctx, query, id).Scan(&keyResult.ID, &keyResult.GoalID, &keyResult.Title, &keyResult.StartValue, &keyResult.TargetValue, &keyResult.CurrentValue, &keyResult.Unit, &keyResult.CreatedAt, &keyResult.UpdatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrKeyResultNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get key result by id: %w", err)
+	}
+
+	return &keyResult, nil
+}
+
+func (s *goalStorage) GetKeyResultsByGoalID(ctx context.Context, goalID string) ([]*model.KeyResult, error) {
+	var keyResults []*model.KeyResult
+
+	query := fmt.Sprintf("SELECT id, goal_id, title, start_value, target_value, current_value, unit, created_at, updated_at FROM %s WHERE goal_id = $1 ORDER BY created_at", keyResultsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, goalID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key results by goal id: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyResult model.KeyResult
+
+		if err := rows.Scan(&keyResult.ID, &keyResult.GoalID, &keyResult.Title, &keyResult.StartValue, &keyResult.TargetValue, &keyResult.CurrentValue, &keyResult.Unit, &keyResult.CreatedAt, &keyResult.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan key result: %w", err)
+		}
+
+		keyResults = append(keyResults, &keyResult)
+	}
+
+	return keyResults, nil
+}
+
+func (s *goalStorage) GetKeyResultsByUserID(ctx context.Context, userID string) ([]*model.KeyResult, error) {
+	var keyResults []*model.KeyResult
+
+	query := fmt.Sprintf("SELECT k.id, k.goal_id, k.title, k.start_value, k.target_value, k.current_value, k.unit, k.created_at, k.updated_at FROM %s k JOIN %s g ON g.id = k.goal_id WHERE g.user_id = $1 AND g.deleted_at IS NULL ORDER BY k.created_at", keyResultsTable, goalsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key results by user id: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyResult model.KeyResult
+
+		if err := rows.Scan(&keyResult.ID, &keyResult.GoalID, &keyResult.Title, &keyResult.StartValue, &keyResult.TargetValue, &keyResult.CurrentValue, &keyResult.Unit, &keyResult.CreatedAt, &keyResult.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan key result: %w", err)
+		}
+
+		keyResults = append(keyResults, &keyResult)
+	}
+
+	return keyResults, nil
+}
+
+func (s *goalStorage) UpdateKeyResult(ctx context.Context, keyResult *model.KeyResult) error {
+	query := fmt.Sprintf("UPDATE %s SET title = $1, target_value = $2, current_value = $3, unit = $4, updated_at = $5 WHERE id = $6", keyResultsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, keyResult.Title, keyResult.TargetValue, keyResult.CurrentValue, keyResult.Unit, keyResult.UpdatedAt, keyResult.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update key result: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrKeyResultNotFound
+	}
+
+	return nil
+}
+
+func (s *goalStorage) DeleteKeyResult(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", keyResultsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete key result: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrKeyResultNotFound
+	}
+
+	return nil
+}
+
+// CreateCheckIn stores the check-in and moves the key result's current value to it in one transaction
+func (s *goalStorage) CreateCheckIn(ctx context.Context, checkIn *model.CheckIn) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("INSERT INTO %s (id, key_result_id, value, note, created_at) VALUES ($1, $2, $3, $4, $5)", checkInsTable)
+
+	if _, err := tx.Exec(ctx, query, checkIn.ID, checkIn.KeyResultID, checkIn.Value, checkIn.Note, checkIn.CreatedAt); err != nil {
+		return fmt.Errorf("failed to create check-in: %w", err)
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET current_value = $1, updated_at = $2 WHERE id = $3", keyResultsTable)
+
+	result, err := tx.Exec(ctx, query, checkIn.Value, checkIn.CreatedAt, checkIn.KeyResultID)
+	if err != nil {
+		return fmt.Errorf("failed to update key result value: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrKeyResultNotFound
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit check-in: %w", err)
+	}
+
+	return nil
+}
+
+func (s *goalStorage) GetCheckInsByKeyResultID(ctx context.Context, keyResultID string) ([]*model.CheckIn, error) {
+	var checkIns []*model.CheckIn
+
+	query := fmt.Sprintf("SELECT id, key_result_id, value, note, created_at FROM %s WHERE key_result_id = $1 ORDER BY created_at", checkInsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, keyResultID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get check-ins by key result id: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var checkIn model.CheckIn
+
+		if err := rows.Scan(&checkIn.ID, &checkIn.KeyResultID, &checkIn.Value, &checkIn.Note, &checkIn.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan check-in: %w", err)
+		}
+
+		checkIns = append(checkIns, &checkIn)
+	}
+
+	return checkIns, nil
+}
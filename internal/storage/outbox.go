@@ -0,0 +1,121 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"sort"
+	"time"
+)
+
+const outboxTable = "outbox"
+
+const outboxColumns = "id, event_type, user_id, payload, attempts, next_attempt_at, last_error, dispatched_at, dead_at, created_at"
+
+var ErrEventNotFound = fmt.Errorf("event not found")
+
+type outboxStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewOutboxStorage(db *pgxpool.Pool) OutboxStorage {
+	return &outboxStorage{db: db}
+}
+
+func (s *outboxStorage) Create(ctx context.Context, event *model.Event) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)", outboxTable, outboxColumns)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, event.ID, event.Type, event.UserID, event.Payload, event.Attempts, event.NextAttemptAt, event.LastError, event.DispatchedAt, event.DeadAt, event.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create outbox event: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimDue returns up to limit undispatched, live events that are due at now, oldest first, and pushes their next
+// attempt to leaseUntil, so that other dispatchers skip them while they are being handled
+func (s *outboxStorage) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Event, error) {
+	query := fmt.Sprintf(`UPDATE %[1]s SET next_attempt_at = $2 WHERE id IN (
+		SELECT id FROM %[1]s WHERE dispatched_at IS NULL AND dead_at IS NULL AND next_attempt_at <= $1 ORDER BY created_at LIMIT $3 FOR UPDATE SKIP LOCKED
+	) RETURNING %[2]s`, outboxTable, outboxColumns)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, now, leaseUntil, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*model.Event
+	for rows.Next() {
+		event, err := scanEvent(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+
+		events = append(events, event)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	// UPDATE ... RETURNING does not keep the order of the subquery
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].CreatedAt.Before(events[j].CreatedAt)
+	})
+
+	return events, nil
+}
+
+func (s *outboxStorage) Update(ctx context.Context, event *model.Event) error {
+	query := fmt.Sprintf("UPDATE %s SET attempts = $1, next_attempt_at = $2, last_error = $3, dispatched_at = $4, dead_at = $5 WHERE id = $6", outboxTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, event.Attempts, event.NextAttemptAt, event.LastError, event.DispatchedAt, event.DeadAt, event.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update outbox event: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrEventNotFound
+	}
+
+	return nil
+}
+
+// DeleteDispatchedBefore removes events dispatched before the given time and returns how many were removed
+func (s *outboxStorage) DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE dispatched_at < $1", outboxTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dispatched outbox events: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+// DeleteDeadBefore removes events that were given up on before the given time and returns how many were removed
+func (s *outboxStorage) DeleteDeadBefore(ctx context.Context, before time.Time) (int64, error) {
+	query := fmt.Sprintf("DELETE FROM %s WHERE dead_at < $1", outboxTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete dead outbox events: %w", err)
+	}
+
+	return result.RowsAffected(), nil
+}
+
+func scanEvent(row pgx.Row) (*model.Event, error) {
+	var event model.Event
+
+	err := row.Scan(&event.ID, &event.Type, &event.UserID, &event.Payload, &event.Attempts, &event.NextAttemptAt, &event.LastError, &event.DispatchedAt, &event.DeadAt, &event.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &event, nil
+}
@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const goalRevisionsTable = "goal_revisions"
+
+var ErrGoalRevisionNotFound = fmt.Errorf("goal revision not found")
+
+type revisionStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewRevisionStorage(db *pgxpool.Pool) RevisionStorage {
+	return &revisionStorage{db: db}
+}
+
+func (s *revisionStorage) Create(ctx context.Context, revision *model.GoalRevision) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, goal_id, number, actor_id, snapshot, created_at) VALUES ($1, $2, $3, $4, $5, $6)", goalRevisionsTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, revision.ID, revision.GoalID, revision.Number, revision.ActorID, revision.Snapshot, revision.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create goal revision: %w", err)
+	}
+
+	return nil
+}
+
+func (s *revisionStorage) GetLatest(ctx context.Context, goalID string) (*model.GoalRevision, error) {
+	query := fmt.Sprintf("SELECT id, goal_id, number, actor_id, snapshot, created_at FROM %s WHERE goal_id = $1 ORDER BY number DESC LIMIT 1", goalRevisionsTable)
+
+	return s.getRevision(ctx, query, goalID)
+}
+
+func (s *revisionStorage) GetByNumber(ctx context.Context, goalID string, number int) (*model.GoalRevision, error) {
+	query := fmt.Sprintf("SELECT id, goal_id, number, actor_id, snapshot, created_at FROM %s WHERE goal_id = $1 AND number = $2", goalRevisionsTable)
+
+	return s.getRevision(ctx, query, goalID, number)
+}
+
+// GetByGoalID returns up to limit revisions numbered below before without their snapshots, newest first
+func (s *revisionStorage) GetByGoalID(ctx context.Context, goalID string, before, limit int) ([]*model.GoalRevision, error) {
+	query := fmt.Sprintf("SELECT id, goal_id, number, actor_id, created_at FROM %s WHERE goal_id = $1 AND number < $2 ORDER BY number DESC LIMIT $3", goalRevisionsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, goalID, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal revisions: %w", err)
+	}
+	defer rows.Close()
+
+	var revisions []*model.GoalRevision
+	for rows.Next() {
+		var revision model.GoalRevision
+
+		err := rows.Scan(&revision.ID, &revision.GoalID, &revision.Number, &revision.ActorID, &revision.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan goal revision: %w", err)
+		}
+
+		revisions = append(revisions, &revision)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get goal revisions: %w", err)
+	}
+
+	return revisions, nil
+}
+
+func (s *revisionStorage) getRevision(ctx context.Context, query string, args ...any) (*model.GoalRevision, error) {
+	var revision model.GoalRevision
+
+	err := conn(ctx, s.db).QueryRow(ctx, query, args...).Scan(&revision.ID, &revision.GoalID, &revision.Number, &revision.ActorID, &revision.Snapshot, &revision.CreatedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGoalRevisionNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get goal revision: %w", err)
+	}
+
+	return &revision, nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"context"
 	"github.com/nordew/Strive/internal/model"
+	"time"
 )
 
 type (
@@ -19,14 +20,201 @@ type (
 		CreateChapter(ctx context.Context, chapter *model.Chapter) error
 		CreateComment(ctx context.Context, comment *model.Comment) error
 		GetByID(ctx context.Context, id string) (*model.Goal, error)
-		GetByUserID(ctx context.Context, userID string) ([]*model.Goal, error)
+		GetByUserID(ctx context.Context, userID string, filter model.GoalFilter) ([]*model.Goal, error)
+		GetByIDs(ctx context.Context, userID string, ids []string) ([]*model.Goal, error)
+		LockByTag(ctx context.Context, userID, tag string) ([]*model.Goal, error)
+		// StreamByUserID calls fn for every goal of the user with its chapters, stopping at the first error
+		StreamByUserID(ctx context.Context, userID string, fn func(goal *model.Goal) error) error
+		GetChildren(ctx context.Context, id string) ([]*model.Goal, error)
+		GetTree(ctx context.Context, rootID string, maxDepth int) ([]*model.Goal, error)
+		GetAncestors(ctx context.Context, id string) ([]*model.Goal, error)
 		GetChapterByID(ctx context.Context, id string) (*model.Chapter, error)
+		GetChaptersByGoalID(ctx context.Context, goalID string) ([]*model.Chapter, error)
 		GetCommentByID(ctx context.Context, id string) (*model.Comment, error)
 		Update(ctx context.Context, goal *model.Goal) error
+		UpdateProgress(ctx context.Context, id string, progress int) error
+		Move(ctx context.Context, id, parentID string) error
 		UpdateChapter(ctx context.Context, chapter *model.Chapter) error
+		SetChapterDependencies(ctx context.Context, chapterID string, dependsOn []string) error
+		LockGoalChapters(ctx context.Context, goalID string) error
+		GetLastChapterPosition(ctx context.Context, goalID string) (string, error)
+		UpdateChapterPosition(ctx context.Context, id, position string) error
+		// MoveChapterToGoal moves the chapter to chapter.GoalID, dropping its dependencies
+		MoveChapterToGoal(ctx context.Context, chapter *model.Chapter) error
 		UpdateComment(ctx context.Context, comment *model.Comment) error
+
+		// Trash moves the goal with its sub-goals to the trash, reads of goals and chapters skip trashed rows
+		Trash(ctx context.Context, id string, deletedAt time.Time) error
+		TrashChapter(ctx context.Context, id string, deletedAt time.Time) error
+		TrashComment(ctx context.Context, id string, deletedAt time.Time) error
+		GetTrashedByID(ctx context.Context, id string) (*model.Goal, error)
+		GetTrashedChapterByID(ctx context.Context, id string) (*model.Chapter, error)
+		GetTrashedCommentByID(ctx context.Context, id string) (*model.Comment, error)
+		// Restore takes the goal and the sub-goals trashed together with it out of the trash
+		Restore(ctx context.Context, id string) error
+		RestoreChapter(ctx context.Context, id string) error
+		// ReplaceTrashedTag renames the tag in trashed goals only, live goals are updated one by one by the service
+		ReplaceTrashedTag(ctx context.Context, userID, from, to string) error
+		RestoreComment(ctx context.Context, id string) error
+		GetTrash(ctx context.Context, userID string) ([]*model.TrashItem, error)
+		// Purge permanently deletes everything trashed before the given time and returns how many rows were deleted
+		Purge(ctx context.Context, before time.Time) (int64, error)
+
+		// Archive and Unarchive change the goals of the user that are not in the requested state yet and return them
+		Archive(ctx context.Context, userID string, ids []string, archivedAt time.Time) ([]*model.Goal, error)
+		Unarchive(ctx context.Context, userID string, ids []string) ([]*model.Goal, error)
+		GetArchivable(ctx context.Context, completedBefore time.Time) ([]*model.Goal, error)
+
+		// GetCompletionRecords returns what the statistics of the user are computed from
+		GetCompletionRecords(ctx context.Context, userID string) ([]model.CompletionRecord, error)
+		// GetStatsVersion and BumpStatsVersion version the statistics of the user, so caches of every instance
+		// notice the changes
+		GetStatsVersion(ctx context.Context, userID string) (int64, error)
+		BumpStatsVersion(ctx context.Context, userID string) error
+
+		CreateKeyResult(ctx context.Context, keyResult *model.KeyResult) error
+		GetKeyResultByID(ctx context.Context, id string) (*model.KeyResult, error)
+		GetKeyResultsByGoalID(ctx context.Context, goalID string) ([]*model.KeyResult, error)
+		GetKeyResultsByUserID(ctx context.Context, userID string) ([]*model.KeyResult, error)
+		UpdateKeyResult(ctx context.Context, keyResult *model.KeyResult) error
+		DeleteKeyResult(ctx context.Context, id string) error
+		CreateCheckIn(ctx context.Context, checkIn *model.CheckIn) error
+		GetCheckInsByKeyResultID(ctx context.Context, keyResultID string) ([]*model.CheckIn, error)
+	}
+
+	TemplateStorage interface {
+		Create(ctx context.Context, template *model.GoalTemplate) error
+		GetByID(ctx context.Context, id string) (*model.GoalTemplate, error)
+		GetAvailable(ctx context.Context, userID string) ([]*model.GoalTemplate, error)
+		Delete(ctx context.Context, id string) error
+	}
+
+	CalendarFeedStorage interface {
+		Upsert(ctx context.Context, feed *model.CalendarFeed) error
+		GetByUserID(ctx context.Context, userID string) (*model.CalendarFeed, error)
+		GetByToken(ctx context.Context, token string) (*model.CalendarFeed, error)
+		DeleteByUserID(ctx context.Context, userID string) error
+		// GetVersion changes whenever the content of the user's feed may have changed
+		GetVersion(ctx context.Context, userID string) (string, error)
+	}
+
+	CalDAVCredentialStorage interface {
+		Upsert(ctx context.Context, credential *model.CalDAVCredential) error
+		GetByUserID(ctx context.Context, userID string) (*model.CalDAVCredential, error)
+		DeleteByUserID(ctx context.Context, userID string) error
+	}
+
+	WebhookStorage interface {
+		Create(ctx context.Context, webhook *model.Webhook) error
+		GetByID(ctx context.Context, id string) (*model.Webhook, error)
+		GetByUserID(ctx context.Context, userID string) ([]*model.Webhook, error)
+		// GetSubscribed returns the active webhooks of the user that listen to the event
+		GetSubscribed(ctx context.Context, userID, event string) ([]*model.Webhook, error)
+		Update(ctx context.Context, webhook *model.Webhook) error
+		Delete(ctx context.Context, id string) error
+
+		CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+		GetDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error)
+		GetDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]*model.WebhookDelivery, error)
+		// ClaimDueDeliveries leases due pending deliveries until leaseUntil so concurrent dispatchers do not send them twice
+		ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error)
+		UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+		CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error
+		GetAttemptsByDeliveryID(ctx context.Context, deliveryID string) ([]model.WebhookAttempt, error)
+
+		// RecordMissedDeadlines returns deadlines that passed between since and now and were not returned before
+		RecordMissedDeadlines(ctx context.Context, since, now time.Time) ([]*model.MissedDeadline, error)
+	}
+
+	// OutboxStorage holds domain events until they are dispatched to the subscribers
+	OutboxStorage interface {
+		Create(ctx context.Context, event *model.Event) error
+		// ClaimDue leases due events until leaseUntil so concurrent dispatchers do not handle them twice
+		ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.Event, error)
+		Update(ctx context.Context, event *model.Event) error
+		DeleteDispatchedBefore(ctx context.Context, before time.Time) (int64, error)
+		DeleteDeadBefore(ctx context.Context, before time.Time) (int64, error)
+	}
+
+	AuditStorage interface {
+		Create(ctx context.Context, entry *model.AuditEntry) error
+		// GetByGoalID returns entries of the goal, its chapters and comments created before the given time, newest first
+		GetByGoalID(ctx context.Context, goalID string, before time.Time, limit int) ([]*model.AuditEntry, error)
+		DeleteBefore(ctx context.Context, before time.Time) (int64, error)
+	}
+
+	RevisionStorage interface {
+		Create(ctx context.Context, revision *model.GoalRevision) error
+		GetLatest(ctx context.Context, goalID string) (*model.GoalRevision, error)
+		GetByNumber(ctx context.Context, goalID string, number int) (*model.GoalRevision, error)
+		// GetByGoalID returns revisions numbered below before without their snapshots, newest first
+		GetByGoalID(ctx context.Context, goalID string, before, limit int) ([]*model.GoalRevision, error)
+	}
+
+	// IdempotencyStorage keeps the responses of requests sent with an Idempotency-Key until they expire
+	IdempotencyStorage interface {
+		// Create returns ErrIdempotencyKeyExists if the key is taken in its scope and has not expired yet
+		Create(ctx context.Context, record *model.IdempotencyRecord) error
+		Get(ctx context.Context, scope, key string, now time.Time) (*model.IdempotencyRecord, error)
+		Complete(ctx context.Context, record *model.IdempotencyRecord) error
+		Delete(ctx context.Context, scope, key string) error
+		DeleteExpired(ctx context.Context, now time.Time) (int64, error)
+	}
+
+	// TagStorage keeps the tags of users. Goals create the tags they use when they are written.
+	TagStorage interface {
+		// Create returns ErrTagExists if the user has a tag with the same name
+		Create(ctx context.Context, tag *model.Tag) error
+		GetByID(ctx context.Context, id string) (*model.Tag, error)
+		GetByUserID(ctx context.Context, userID string) ([]*model.Tag, error)
+		Suggest(ctx context.Context, userID, prefix string, limit int) ([]*model.Tag, error)
+		// Update and Merge only change the tags table, the service replaces the name in the goals using the tag
+		Update(ctx context.Context, tag *model.Tag) error
+		// Merge deletes source and touches target
+		Merge(ctx context.Context, source, target *model.Tag) error
+	}
+
+	// ViewStorage keeps the saved views of users, names are unique per user regardless of case
+	ViewStorage interface {
+		// Create returns ErrViewExists if the user has a view with the same name
+		Create(ctx context.Context, view *model.SavedView) error
+		GetByID(ctx context.Context, id string) (*model.SavedView, error)
+		GetByName(ctx context.Context, userID, name string) (*model.SavedView, error)
+		GetByUserID(ctx context.Context, userID string) ([]*model.SavedView, error)
+		Update(ctx context.Context, view *model.SavedView) error
 		Delete(ctx context.Context, id string) error
-		DeleteChapter(ctx context.Context, id string) error
-		DeleteComment(ctx context.Context, id string) error
+	}
+
+	// GamificationStorage keeps the XP users earned and the achievements they unlocked, both are written at most once
+	GamificationStorage interface {
+		CreateXPAward(ctx context.Context, award *model.XPAward) (bool, error)
+		GetAchievementProgress(ctx context.Context, userID string) (*model.AchievementProgress, error)
+		GetActivityDays(ctx context.Context, userID string, since time.Time) ([]time.Time, error)
+		UnlockAchievement(ctx context.Context, userID, code string, unlockedAt time.Time) (bool, error)
+		GetAchievements(ctx context.Context, userID string) (map[string]time.Time, error)
+	}
+
+	// FriendStorage keeps invites, friendships, privacy settings and the leaderboards computed from the XP totals
+	FriendStorage interface {
+		CreateInvite(ctx context.Context, invite *model.FriendInvite) error
+		TakeInvite(ctx context.Context, code string) (*model.FriendInvite, error)
+		DeleteExpiredInvites(ctx context.Context, userID string, now time.Time) error
+		CreateFriendship(ctx context.Context, userID, friendID string, createdAt time.Time) error
+		DeleteFriendship(ctx context.Context, userID, friendID string) error
+		GetFriends(ctx context.Context, userID string) ([]*model.Friend, error)
+		GetPrivacySettings(ctx context.Context, userID string) (*model.PrivacySettings, error)
+		SavePrivacySettings(ctx context.Context, settings *model.PrivacySettings) error
+		GetLeaderboard(ctx context.Context, userID string, leaderboard *model.Leaderboard) error
+	}
+
+	// FocusStorage keeps focus sessions, a user has at most one session that has not ended
+	FocusStorage interface {
+		Create(ctx context.Context, session *model.FocusSession) error
+		GetByID(ctx context.Context, id string) (*model.FocusSession, error)
+		GetActive(ctx context.Context, userID string) (*model.FocusSession, error)
+		GetDue(ctx context.Context, now time.Time) ([]*model.FocusSession, error)
+		Update(ctx context.Context, session *model.FocusSession) error
+		GetTotals(ctx context.Context, goalID string) (*model.FocusTotals, error)
+		GetGoalTotals(ctx context.Context, userID string) ([]*model.GoalFocus, error)
 	}
 )
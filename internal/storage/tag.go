@@ -0,0 +1,188 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const (
+	tagsTable = "tags"
+
+	uniqueViolation = "23505"
+)
+
+var (
+	ErrTagNotFound = fmt.Errorf("tag not found")
+	ErrTagExists   = fmt.Errorf("tag with this name already exists")
+)
+
+// tagColumns selects a tag of the tags table aliased t with the number of goals outside of the trash using it
+const tagColumns = `t.id, t.user_id, t.name, t.color, t.emoji,
+	(SELECT count(*) FROM goals g WHERE g.user_id = t.user_id AND g.tags @> ARRAY[t.name]::text[] AND g.deleted_at IS NULL) AS usage_count,
+	t.created_at, t.updated_at`
+
+type tagStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewTagStorage(db *pgxpool.Pool) TagStorage {
+	return &tagStorage{db: db}
+}
+
+func (s *tagStorage) Create(ctx context.Context, tag *model.Tag) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, user_id, name, color, emoji, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7)", tagsTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, tag.ID, tag.UserID, tag.Name, tag.Color, tag.Emoji, tag.CreatedAt, tag.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTagExists
+		}
+
+		return fmt.Errorf("failed to create tag: %w", err)
+	}
+
+	return nil
+}
+
+func (s *tagStorage) GetByID(ctx context.Context, id string) (*model.Tag, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s t WHERE t.id = $1", tagColumns, tagsTable)
+
+	tag, err := scanTag(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTagNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get tag: %w", err)
+	}
+
+	return tag, nil
+}
+
+// GetByUserID returns the tags of the user ordered by name
+func (s *tagStorage) GetByUserID(ctx context.Context, userID string) ([]*model.Tag, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s t WHERE t.user_id = $1 ORDER BY t.name", tagColumns, tagsTable)
+
+	return s.queryTags(ctx, query, userID)
+}
+
+// Suggest returns up to limit tags of the user starting with prefix, the most used first
+func (s *tagStorage) Suggest(ctx context.Context, userID, prefix string, limit int) ([]*model.Tag, error) {
+	query := fmt.Sprintf(`SELECT %s FROM %s t WHERE t.user_id = $1 AND left(t.name, char_length($2::text)) = $2::text
+	ORDER BY usage_count DESC, t.name LIMIT $3`, tagColumns, tagsTable)
+
+	return s.queryTags(ctx, query, userID, prefix, limit)
+}
+
+// Update writes the name, color and emoji of the tag
+func (s *tagStorage) Update(ctx context.Context, tag *model.Tag) error {
+	query := fmt.Sprintf("UPDATE %s SET name = $1, color = $2, emoji = $3, updated_at = $4 WHERE id = $5", tagsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, tag.Name, tag.Color, tag.Emoji, tag.UpdatedAt, tag.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrTagExists
+		}
+
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+
+	return nil
+}
+
+// Merge deletes source and sets the updated_at of target, the goals using source must be moved to target before
+func (s *tagStorage) Merge(ctx context.Context, source, target *model.Tag) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", tagsTable)
+
+	result, err := tx.Exec(ctx, query, source.ID)
+	if err != nil {
+		return fmt.Errorf("failed to delete tag: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrTagNotFound
+	}
+
+	query = fmt.Sprintf("UPDATE %s SET updated_at = $1 WHERE id = $2", tagsTable)
+
+	if _, err := tx.Exec(ctx, query, target.UpdatedAt, target.ID); err != nil {
+		return fmt.Errorf("failed to update tag: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func (s *tagStorage) queryTags(ctx context.Context, query string, args ...any) ([]*model.Tag, error) {
+	rows, err := conn(ctx, s.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tags: %w", err)
+	}
+	defer rows.Close()
+
+	var tags []*model.Tag
+	for rows.Next() {
+		tag, err := scanTag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tag: %w", err)
+		}
+
+		tags = append(tags, tag)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over tags: %w", err)
+	}
+
+	return tags, nil
+}
+
+func scanTag(row pgx.Row) (*model.Tag, error) {
+	var tag model.Tag
+	if err := row.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color, &tag.Emoji, &tag.UsageCount, &tag.CreatedAt, &tag.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &tag, nil
+}
+
+// ensureTags creates the tags of a goal the user has not used before
+func ensureTags(ctx context.Context, q querier, userID string, names []string, now time.Time) error {
+	if len(names) == 0 {
+		return nil
+	}
+
+	query := fmt.Sprintf(`INSERT INTO %s (id, user_id, name, created_at, updated_at)
+	SELECT gen_random_uuid(), $1, name, $3, $3 FROM unnest($2::text[]) AS name
+	ON CONFLICT (user_id, name) DO NOTHING`, tagsTable)
+
+	if _, err := q.Exec(ctx, query, userID, names, now); err != nil {
+		return fmt.Errorf("failed to create tags: %w", err)
+	}
+
+	return nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == uniqueViolation
+}
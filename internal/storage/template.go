@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const (
+	templatesTable        = "goal_templates"
+	templateChaptersTable = "template_chapters"
+)
+
+// templateColumns is the column list scanned by scanTemplate
+const templateColumns = "id, COALESCE(user_id::text, ''), title, description, COALESCE(tags, '{}'), is_public, deadline_offset_days, created_at, updated_at"
+
+var ErrTemplateNotFound = fmt.Errorf("template not found")
+
+type templateStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewTemplateStorage(db *pgxpool.Pool) TemplateStorage {
+	return &templateStorage{db: db}
+}
+
+// Create stores the template together with its chapter blueprints in one transaction
+func (s *templateStorage) Create(ctx context.Context, template *model.GoalTemplate) error {
+	tx, err := conn(ctx, s.db).Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := fmt.Sprintf("INSERT INTO %s (id, user_id, title, description, tags, is_public, deadline_offset_days, created_at, updated_at) VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7, $8, $9)", templatesTable)
+
+	_, err = tx.Exec(ctx, query, template.ID, template.UserID, template.Title, template.Description, template.Tags, template.IsPublic, template.DeadlineOffsetDays, template.CreatedAt, template.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create template: %w", err)
+	}
+
+	query = fmt.Sprintf("INSERT INTO %s (id, template_id, title, description, deadline_offset_days, priority, position) VALUES ($1, $2, $3, $4, $5, $6, $7)", templateChaptersTable)
+
+	for i, chapter := range template.Chapters {
+		if _, err := tx.Exec(ctx, query, chapter.ID, template.ID, chapter.Title, chapter.Description, chapter.DeadlineOffsetDays, chapter.Priority, i); err != nil {
+			return fmt.Errorf("failed to create template chapter: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit template: %w", err)
+	}
+
+	return nil
+}
+
+func (s *templateStorage) GetByID(ctx context.Context, id string) (*model.GoalTemplate, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", templateColumns, templatesTable)
+
+	template, err := scanTemplate(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTemplateNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get template by id: %w", err)
+	}
+
+	if err := s.loadChapters(ctx, []*model.GoalTemplate{template}); err != nil {
+		return nil, err
+	}
+
+	return template, nil
+}
+
+// GetAvailable returns built-in and public templates and the private templates of the user
+func (s *templateStorage) GetAvailable(ctx context.Context, userID string) ([]*model.GoalTemplate, error) {
+	var templates []*model.GoalTemplate
+
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id IS NULL OR is_public OR user_id = $1 ORDER BY user_id NULLS FIRST, title", templateColumns, templatesTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		template, err := scanTemplate(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan template: %w", err)
+		}
+
+		templates = append(templates, template)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get templates: %w", err)
+	}
+
+	if err := s.loadChapters(ctx, templates); err != nil {
+		return nil, err
+	}
+
+	return templates, nil
+}
+
+func (s *templateStorage) Delete(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", templatesTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrTemplateNotFound
+	}
+
+	return nil
+}
+
+func (s *templateStorage) loadChapters(ctx context.Context, templates []*model.GoalTemplate) error {
+	if len(templates) == 0 {
+		return nil
+	}
+
+	byID := make(map[string]*model.GoalTemplate, len(templates))
+	ids := make([]string, 0, len(templates))
+	for _, template := range templates {
+		byID[template.ID] = template
+		ids = append(ids, template.ID)
+	}
+
+	query := fmt.Sprintf("SELECT id, template_id, title, description, deadline_offset_days, priority FROM %s WHERE template_id = ANY($1::uuid[]) ORDER BY position", templateChaptersTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get template chapters: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var chapter model.ChapterBlueprint
+
+		if err := rows.Scan(&chapter.ID, &chapter.TemplateID, &chapter.Title, &chapter.Description, &chapter.DeadlineOffsetDays, &chapter.Priority); err != nil {
+			return fmt.Errorf("failed to scan template chapter: %w", err)
+		}
+
+		template := byID[chapter.TemplateID]
+		template.Chapters = append(template.Chapters, chapter)
+	}
+
+	return rows.Err()
+}
+
+func scanTemplate(row pgx.Row) (*model.GoalTemplate, error) {
+	template := &model.GoalTemplate{}
+	err := row.Scan(&template.ID, &template.UserID, &template.Title, &template.Description, &template.Tags, &template.IsPublic, &template.DeadlineOffsetDays, &template.CreatedAt, &template.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return template, nil
+}
@@ -0,0 +1,252 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+// Trash moves the goal and the sub-goals outside the trash to the trash. They share deletedAt, which
+// lets Restore bring back exactly the goals trashed together.
+func (s *goalStorage) Trash(ctx context.Context, id string, deletedAt time.Time) error {
+	query := fmt.Sprintf(`WITH RECURSIVE subtree AS (
+		SELECT id FROM %[1]s WHERE id = $1 AND deleted_at IS NULL
+		UNION ALL
+		SELECT g.id FROM %[1]s g JOIN subtree s ON g.parent_id = s.id WHERE g.deleted_at IS NULL
+	)
+	UPDATE %[1]s SET deleted_at = $2 WHERE id IN (SELECT id FROM subtree)`, goalsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id, deletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to trash goal: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrGoalNotFound
+	}
+
+	return nil
+}
+
+func (s *goalStorage) TrashChapter(ctx context.Context, id string, deletedAt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL", chaptersTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id, deletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to trash chapter: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrChapterNotFound
+	}
+
+	return nil
+}
+
+func (s *goalStorage) TrashComment(ctx context.Context, id string, deletedAt time.Time) error {
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL", commentsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id, deletedAt)
+	if err != nil {
+		return fmt.Errorf("failed to trash comment: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+func (s *goalStorage) GetTrashedByID(ctx context.Context, id string) (*model.Goal, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NOT NULL", goalColumns, goalsTable)
+
+	goal, err := scanGoal(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrGoalNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get trashed goal: %w", err)
+	}
+
+	return goal, nil
+}
+
+func (s *goalStorage) GetTrashedChapterByID(ctx context.Context, id string) (*model.Chapter, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NOT NULL", chapterColumns, chaptersTable)
+
+	chapter, err := scanChapter(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrChapterNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get trashed chapter: %w", err)
+	}
+
+	return chapter, nil
+}
+
+func (s *goalStorage) GetTrashedCommentByID(ctx context.Context, id string) (*model.Comment, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1 AND deleted_at IS NOT NULL", commentColumns, commentsTable)
+
+	comment, err := scanComment(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrCommentNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get trashed comment: %w", err)
+	}
+
+	return comment, nil
+}
+
+// Restore takes the goal and the sub-goals trashed together with it out of the trash
+func (s *goalStorage) Restore(ctx context.Context, id string) error {
+	query := fmt.Sprintf(`WITH RECURSIVE subtree AS (
+		SELECT id, deleted_at FROM %[1]s WHERE id = $1 AND deleted_at IS NOT NULL
+		UNION ALL
+		SELECT g.id, g.deleted_at FROM %[1]s g JOIN subtree s ON g.parent_id = s.id WHERE g.deleted_at = s.deleted_at
+	)
+	UPDATE %[1]s SET deleted_at = NULL WHERE id IN (SELECT id FROM subtree)
+	RETURNING user_id, COALESCE(tags, '{}')`, goalsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore goal: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		userID string
+		tags   []string
+	)
+	for rows.Next() {
+		var goalTags []string
+		if err := rows.Scan(&userID, &goalTags); err != nil {
+			return fmt.Errorf("failed to scan restored goal: %w", err)
+		}
+
+		tags = append(tags, goalTags...)
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to restore goal: %w", err)
+	}
+
+	if userID == "" {
+		return ErrGoalNotFound
+	}
+
+	// goals create the tags they use when they are written, restoring them counts as writing them
+	return ensureTags(ctx, conn(ctx, s.db), userID, tags, time.Now())
+}
+
+// ReplaceTrashedTag replaces the tag from with to in the trashed goals of the user, keeping the order and
+// dropping the duplicate when a goal has both, so restoring them does not bring back a renamed or merged tag
+func (s *goalStorage) ReplaceTrashedTag(ctx context.Context, userID, from, to string) error {
+	query := fmt.Sprintf(`UPDATE %s SET tags = ARRAY(
+		SELECT tag FROM unnest(array_replace(tags, $2, $3)) WITH ORDINALITY AS t(tag, n) GROUP BY tag ORDER BY min(n)
+	)
+	WHERE user_id = $1 AND tags @> ARRAY[$2::text] AND deleted_at IS NOT NULL`, goalsTable)
+
+	if _, err := conn(ctx, s.db).Exec(ctx, query, userID, from, to); err != nil {
+		return fmt.Errorf("failed to replace tag in trashed goals: %w", err)
+	}
+
+	return nil
+}
+
+func (s *goalStorage) RestoreChapter(ctx context.Context, id string) error {
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", chaptersTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore chapter: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrChapterNotFound
+	}
+
+	return nil
+}
+
+func (s *goalStorage) RestoreComment(ctx context.Context, id string) error {
+	query := fmt.Sprintf("UPDATE %s SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL", commentsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to restore comment: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrCommentNotFound
+	}
+
+	return nil
+}
+
+// GetTrash returns the trashed goals of the user, except sub-goals trashed together with their parent, and the
+// trashed chapters and comments of goals outside the trash, most recently deleted first
+func (s *goalStorage) GetTrash(ctx context.Context, userID string) ([]*model.TrashItem, error) {
+	query := fmt.Sprintf(`SELECT $2::text, g.id::text, g.id::text, g.title, g.deleted_at
+	FROM %[1]s g
+	WHERE g.user_id = $1 AND g.deleted_at IS NOT NULL
+		AND NOT EXISTS (SELECT 1 FROM %[1]s p WHERE p.id = g.parent_id AND p.deleted_at = g.deleted_at)
+	UNION ALL
+	SELECT $3::text, c.id::text, g.id::text, c.title, c.deleted_at
+	FROM %[2]s c JOIN %[1]s g ON g.id = c.goal_id
+	WHERE g.user_id = $1 AND c.deleted_at IS NOT NULL AND g.deleted_at IS NULL
+	UNION ALL
+	SELECT $4::text, m.id::text, g.id::text, m.content, m.deleted_at
+	FROM %[3]s m LEFT JOIN %[2]s c ON c.id = m.chapter_id JOIN %[1]s g ON g.id = COALESCE(m.goal_id, c.goal_id)
+	WHERE g.user_id = $1 AND m.deleted_at IS NOT NULL AND g.deleted_at IS NULL AND c.deleted_at IS NULL
+	ORDER BY 5 DESC`, goalsTable, chaptersTable, commentsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID, model.TrashGoal, model.TrashChapter, model.TrashComment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*model.TrashItem
+	for rows.Next() {
+		var item model.TrashItem
+
+		if err := rows.Scan(&item.Type, &item.ID, &item.GoalID, &item.Title, &item.DeletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan trash item: %w", err)
+		}
+
+		items = append(items, &item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get trash: %w", err)
+	}
+
+	return items, nil
+}
+
+// Purge permanently deletes goals, chapters and comments trashed before the given time and returns how many
+// were deleted. Chapters, comments, key results and revisions of purged goals are deleted with them.
+func (s *goalStorage) Purge(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for _, table := range []string{goalsTable, chaptersTable, commentsTable} {
+		query := fmt.Sprintf("DELETE FROM %s WHERE deleted_at < $1", table)
+
+		result, err := conn(ctx, s.db).Exec(ctx, query, before)
+		if err != nil {
+			return purged, fmt.Errorf("failed to purge %s: %w", table, err)
+		}
+
+		purged += result.RowsAffected()
+	}
+
+	return purged, nil
+}
@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+)
+
+const (
+	savedViewsTable  = "saved_views"
+	savedViewColumns = "id, user_id, name, filter, created_at, updated_at"
+)
+
+var (
+	ErrViewNotFound = fmt.Errorf("view not found")
+	ErrViewExists   = fmt.Errorf("view with this name already exists")
+)
+
+type viewStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewViewStorage(db *pgxpool.Pool) ViewStorage {
+	return &viewStorage{db: db}
+}
+
+func (s *viewStorage) Create(ctx context.Context, view *model.SavedView) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6)", savedViewsTable, savedViewColumns)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, view.ID, view.UserID, view.Name, view.Filter, view.CreatedAt, view.UpdatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrViewExists
+		}
+
+		return fmt.Errorf("failed to create view: %w", err)
+	}
+
+	return nil
+}
+
+func (s *viewStorage) GetByID(ctx context.Context, id string) (*model.SavedView, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", savedViewColumns, savedViewsTable)
+
+	return s.getView(ctx, query, id)
+}
+
+// GetByName finds a view of the user by its name, ignoring case
+func (s *viewStorage) GetByName(ctx context.Context, userID, name string) (*model.SavedView, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 AND lower(name) = lower($2)", savedViewColumns, savedViewsTable)
+
+	return s.getView(ctx, query, userID, name)
+}
+
+func (s *viewStorage) GetByUserID(ctx context.Context, userID string) ([]*model.SavedView, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY lower(name)", savedViewColumns, savedViewsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get views: %w", err)
+	}
+	defer rows.Close()
+
+	var views []*model.SavedView
+	for rows.Next() {
+		view, err := scanView(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan view: %w", err)
+		}
+
+		views = append(views, view)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to iterate over views: %w", err)
+	}
+
+	return views, nil
+}
+
+func (s *viewStorage) Update(ctx context.Context, view *model.SavedView) error {
+	query := fmt.Sprintf("UPDATE %s SET name = $1, filter = $2, updated_at = $3 WHERE id = $4", savedViewsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, view.Name, view.Filter, view.UpdatedAt, view.ID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrViewExists
+		}
+
+		return fmt.Errorf("failed to update view: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrViewNotFound
+	}
+
+	return nil
+}
+
+func (s *viewStorage) Delete(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", savedViewsTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrViewNotFound
+	}
+
+	return nil
+}
+
+func (s *viewStorage) getView(ctx context.Context, query string, args ...any) (*model.SavedView, error) {
+	view, err := scanView(conn(ctx, s.db).QueryRow(ctx, query, args...))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrViewNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	return view, nil
+}
+
+func scanView(row pgx.Row) (*model.SavedView, error) {
+	var view model.SavedView
+	if err := row.Scan(&view.ID, &view.UserID, &view.Name, &view.Filter, &view.CreatedAt, &view.UpdatedAt); err != nil {
+		return nil, err
+	}
+
+	return &view, nil
+}
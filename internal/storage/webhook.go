@@ -0,0 +1,319 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/nordew/Strive/internal/model"
+	"time"
+)
+
+const (
+	webhooksTable                = "webhooks"
+	webhookDeliveriesTable       = "webhook_deliveries"
+	webhookDeliveryAttemptsTable = "webhook_delivery_attempts"
+	deadlineMissesTable          = "deadline_misses"
+)
+
+const (
+	webhookColumns         = "id, user_id, url, secret, events, is_active, failure_count, disabled_at, created_at, updated_at"
+	webhookDeliveryColumns = "id, webhook_id, event, payload, status, attempts, next_attempt_at, last_status_code, last_error, created_at, updated_at"
+)
+
+var (
+	ErrWebhookNotFound         = fmt.Errorf("webhook not found")
+	ErrWebhookDeliveryNotFound = fmt.Errorf("webhook delivery not found")
+)
+
+type webhookStorage struct {
+	db *pgxpool.Pool
+}
+
+func NewWebhookStorage(db *pgxpool.Pool) WebhookStorage {
+	return &webhookStorage{db: db}
+}
+
+func (s *webhookStorage) Create(ctx context.Context, webhook *model.Webhook) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)", webhooksTable, webhookColumns)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, webhook.ID, webhook.UserID, webhook.URL, webhook.Secret, webhook.Events, webhook.IsActive, webhook.FailureCount, webhook.DisabledAt, webhook.CreatedAt, webhook.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	return nil
+}
+
+func (s *webhookStorage) GetByID(ctx context.Context, id string) (*model.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", webhookColumns, webhooksTable)
+
+	webhook, err := scanWebhook(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get webhook by id: %w", err)
+	}
+
+	return webhook, nil
+}
+
+func (s *webhookStorage) GetByUserID(ctx context.Context, userID string) ([]*model.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 ORDER BY created_at", webhookColumns, webhooksTable)
+
+	return s.queryWebhooks(ctx, query, userID)
+}
+
+// GetSubscribed returns the active webhooks of the user that listen to the event
+func (s *webhookStorage) GetSubscribed(ctx context.Context, userID, event string) ([]*model.Webhook, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE user_id = $1 AND is_active AND $2 = ANY(events)", webhookColumns, webhooksTable)
+
+	return s.queryWebhooks(ctx, query, userID, event)
+}
+
+func (s *webhookStorage) Update(ctx context.Context, webhook *model.Webhook) error {
+	query := fmt.Sprintf("UPDATE %s SET is_active = $1, failure_count = $2, disabled_at = $3, updated_at = $4 WHERE id = $5", webhooksTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, webhook.IsActive, webhook.FailureCount, webhook.DisabledAt, webhook.UpdatedAt, webhook.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+func (s *webhookStorage) Delete(ctx context.Context, id string) error {
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1", webhooksTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrWebhookNotFound
+	}
+
+	return nil
+}
+
+// CreateDelivery ignores deliveries that already exist
+func (s *webhookStorage) CreateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) ON CONFLICT (id) DO NOTHING", webhookDeliveriesTable, webhookDeliveryColumns)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, delivery.ID, delivery.WebhookID, delivery.Event, delivery.Payload, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError, delivery.CreatedAt, delivery.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook delivery: %w", err)
+	}
+
+	return nil
+}
+
+func (s *webhookStorage) GetDeliveryByID(ctx context.Context, id string) (*model.WebhookDelivery, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE id = $1", webhookDeliveryColumns, webhookDeliveriesTable)
+
+	delivery, err := scanWebhookDelivery(conn(ctx, s.db).QueryRow(ctx, query, id))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+
+		return nil, fmt.Errorf("failed to get webhook delivery by id: %w", err)
+	}
+
+	return delivery, nil
+}
+
+func (s *webhookStorage) GetDeliveriesByWebhookID(ctx context.Context, webhookID string, limit int) ([]*model.WebhookDelivery, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE webhook_id = $1 ORDER BY created_at DESC LIMIT $2", webhookDeliveryColumns, webhookDeliveriesTable)
+
+	return s.queryDeliveries(ctx, query, webhookID, limit)
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries that are due at now and pushes their next attempt
+// to leaseUntil, so that other dispatchers skip them while they are being sent
+func (s *webhookStorage) ClaimDueDeliveries(ctx context.Context, now, leaseUntil time.Time, limit int) ([]*model.WebhookDelivery, error) {
+	query := fmt.Sprintf(`UPDATE %[1]s SET next_attempt_at = $2 WHERE id IN (
+		SELECT id FROM %[1]s WHERE status = $3 AND next_attempt_at <= $1 ORDER BY next_attempt_at LIMIT $4 FOR UPDATE SKIP LOCKED
+	) RETURNING %[2]s`, webhookDeliveriesTable, webhookDeliveryColumns)
+
+	return s.queryDeliveries(ctx, query, now, leaseUntil, model.DeliveryPending, limit)
+}
+
+func (s *webhookStorage) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error {
+	query := fmt.Sprintf("UPDATE %s SET status = $1, attempts = $2, next_attempt_at = $3, last_status_code = $4, last_error = $5, updated_at = $6 WHERE id = $7", webhookDeliveriesTable)
+
+	result, err := conn(ctx, s.db).Exec(ctx, query, delivery.Status, delivery.Attempts, delivery.NextAttemptAt, delivery.LastStatusCode, delivery.LastError, delivery.UpdatedAt, delivery.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if result.RowsAffected() == 0 {
+		return ErrWebhookDeliveryNotFound
+	}
+
+	return nil
+}
+
+func (s *webhookStorage) CreateAttempt(ctx context.Context, attempt *model.WebhookAttempt) error {
+	query := fmt.Sprintf("INSERT INTO %s (id, delivery_id, status_code, error, duration_ms, attempted_at) VALUES ($1, $2, $3, $4, $5, $6)", webhookDeliveryAttemptsTable)
+
+	_, err := conn(ctx, s.db).Exec(ctx, query, attempt.ID, attempt.DeliveryID, attempt.StatusCode, attempt.Error, attempt.DurationMS, attempt.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook attempt: %w", err)
+	}
+
+	return nil
+}
+
+func (s *webhookStorage) GetAttemptsByDeliveryID(ctx context.Context, deliveryID string) ([]model.WebhookAttempt, error) {
+	query := fmt.Sprintf("SELECT id, delivery_id, status_code, error, duration_ms, attempted_at FROM %s WHERE delivery_id = $1 ORDER BY attempted_at", webhookDeliveryAttemptsTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	var attempts []model.WebhookAttempt
+	for rows.Next() {
+		var attempt model.WebhookAttempt
+
+		if err := rows.Scan(&attempt.ID, &attempt.DeliveryID, &attempt.StatusCode, &attempt.Error, &attempt.DurationMS, &attempt.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+
+		attempts = append(attempts, attempt)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhook attempts: %w", err)
+	}
+
+	return attempts, nil
+}
+
+// RecordMissedDeadlines finds open goals and chapters whose deadline passed between since and now, for users
+// that listen to deadline.missed. Every deadline is returned only once, later calls skip recorded ones.
+func (s *webhookStorage) RecordMissedDeadlines(ctx context.Context, since, now time.Time) ([]*model.MissedDeadline, error) {
+	query := fmt.Sprintf(`WITH due AS (
+		SELECT 'goal' AS entity_type, g.id AS entity_id, g.id AS goal_id, g.user_id, g.title, g.deadline
+		FROM %[1]s g
+		WHERE COALESCE(g.progress, 0) < 100 AND g.deadline > $1 AND g.deadline <= $2 AND g.deleted_at IS NULL
+		UNION ALL
+		SELECT 'chapter', c.id, c.goal_id, g.user_id, c.title, c.deadline
+		FROM %[2]s c JOIN %[1]s g ON g.id = c.goal_id
+		WHERE NOT COALESCE(c.is_done, FALSE) AND c.deadline > $1 AND c.deadline <= $2 AND c.deleted_at IS NULL AND g.deleted_at IS NULL
+	), subscribed AS (
+		SELECT due.* FROM due
+		WHERE EXISTS (SELECT 1 FROM %[3]s w WHERE w.user_id = due.user_id AND w.is_active AND $3 = ANY(w.events))
+	), recorded AS (
+		INSERT INTO %[4]s (entity_id, deadline, entity_type, user_id, recorded_at)
+		SELECT entity_id, deadline, entity_type, user_id, $2 FROM subscribed
+		ON CONFLICT DO NOTHING
+		RETURNING entity_id, deadline
+	)
+	SELECT d.entity_type, d.entity_id::text, d.goal_id::text, d.user_id::text, d.title, d.deadline
+	FROM subscribed d JOIN recorded r ON r.entity_id = d.entity_id AND r.deadline = d.deadline`,
+		goalsTable, chaptersTable, webhooksTable, deadlineMissesTable)
+
+	rows, err := conn(ctx, s.db).Query(ctx, query, since, now, model.EventDeadlineMissed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record missed deadlines: %w", err)
+	}
+	defer rows.Close()
+
+	var missed []*model.MissedDeadline
+	for rows.Next() {
+		var deadline model.MissedDeadline
+
+		if err := rows.Scan(&deadline.EntityType, &deadline.EntityID, &deadline.GoalID, &deadline.UserID, &deadline.Title, &deadline.Deadline); err != nil {
+			return nil, fmt.Errorf("failed to scan missed deadline: %w", err)
+		}
+
+		missed = append(missed, &deadline)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to record missed deadlines: %w", err)
+	}
+
+	return missed, nil
+}
+
+func (s *webhookStorage) queryWebhooks(ctx context.Context, query string, args ...any) ([]*model.Webhook, error) {
+	rows, err := conn(ctx, s.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*model.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+
+		webhooks = append(webhooks, webhook)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhooks: %w", err)
+	}
+
+	return webhooks, nil
+}
+
+func (s *webhookStorage) queryDeliveries(ctx context.Context, query string, args ...any) ([]*model.WebhookDelivery, error) {
+	rows, err := conn(ctx, s.db).Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*model.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to get webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func scanWebhook(row pgx.Row) (*model.Webhook, error) {
+	var webhook model.Webhook
+
+	err := row.Scan(&webhook.ID, &webhook.UserID, &webhook.URL, &webhook.Secret, &webhook.Events, &webhook.IsActive, &webhook.FailureCount, &webhook.DisabledAt, &webhook.CreatedAt, &webhook.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &webhook, nil
+}
+
+func scanWebhookDelivery(row pgx.Row) (*model.WebhookDelivery, error) {
+	var delivery model.WebhookDelivery
+
+	err := row.Scan(&delivery.ID, &delivery.WebhookID, &delivery.Event, &delivery.Payload, &delivery.Status, &delivery.Attempts, &delivery.NextAttemptAt, &delivery.LastStatusCode, &delivery.LastError, &delivery.CreatedAt, &delivery.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &delivery, nil
+}
@@ -1,4 +1,29 @@
 DROP TABLE IF EXISTS users CASCADE ;
+DROP TABLE IF EXISTS idempotency_keys CASCADE;
+DROP TABLE IF EXISTS focus_sessions CASCADE;
+DROP TABLE IF EXISTS privacy_settings CASCADE;
+DROP TABLE IF EXISTS friendships CASCADE;
+DROP TABLE IF EXISTS friend_invites CASCADE;
+DROP TABLE IF EXISTS stats_versions CASCADE;
+DROP TABLE IF EXISTS xp_totals CASCADE;
+DROP TABLE IF EXISTS user_achievements CASCADE;
+DROP TABLE IF EXISTS xp_awards CASCADE;
+DROP TABLE IF EXISTS tags CASCADE;
+DROP TABLE IF EXISTS saved_views CASCADE;
+DROP TABLE IF EXISTS goal_revisions CASCADE;
+DROP TABLE IF EXISTS audit_log CASCADE;
+DROP TABLE IF EXISTS outbox CASCADE;
+DROP TABLE IF EXISTS deadline_misses CASCADE;
+DROP TABLE IF EXISTS webhook_delivery_attempts CASCADE;
+DROP TABLE IF EXISTS webhook_deliveries CASCADE;
+DROP TABLE IF EXISTS webhooks CASCADE;
+DROP TABLE IF EXISTS calendar_feeds CASCADE;
+DROP TABLE IF EXISTS caldav_credentials CASCADE;
+DROP TABLE IF EXISTS template_chapters CASCADE;
+DROP TABLE IF EXISTS goal_templates CASCADE;
+DROP TABLE IF EXISTS key_result_check_ins CASCADE;
+DROP TABLE IF EXISTS key_results CASCADE;
 DROP TABLE IF EXISTS comments CASCADE;
+DROP TABLE IF EXISTS chapter_dependencies CASCADE;
 DROP TABLE IF EXISTS chapters CASCADE;
 DROP TABLE IF EXISTS goals CASCADE;
\ No newline at end of file
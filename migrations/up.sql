@@ -12,6 +12,8 @@ CREATE TABLE users (
 CREATE TABLE goals (
                        id UUID PRIMARY KEY,
                        user_id UUID NOT NULL,
+                       parent_id UUID REFERENCES goals(id) ON DELETE SET NULL,
+                       type VARCHAR(16) NOT NULL DEFAULT 'chapters',
                        title VARCHAR(255) NOT NULL,
                        description TEXT,
                        progress INT,
@@ -20,7 +22,11 @@ CREATE TABLE goals (
                        priority INT,
                        tags TEXT[],
                        created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+                       updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                       deleted_at TIMESTAMP,
+                       archived_at TIMESTAMP,
+                       completed_at TIMESTAMP,
+                       version INT NOT NULL DEFAULT 1
 );
 
 CREATE TABLE chapters (
@@ -31,8 +37,19 @@ CREATE TABLE chapters (
                           is_done BOOLEAN DEFAULT FALSE,
                           deadline TIMESTAMP,
                           priority INT,
+                          position VARCHAR(255) COLLATE "C" NOT NULL,
                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-                          updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+                          updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                          deleted_at TIMESTAMP,
+                          completed_at TIMESTAMP,
+                          version INT NOT NULL DEFAULT 1
+);
+
+CREATE TABLE chapter_dependencies (
+                                      chapter_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+                                      depends_on_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+                                      PRIMARY KEY (chapter_id, depends_on_id),
+                                      CHECK (chapter_id <> depends_on_id)
 );
 
 CREATE TABLE comments (
@@ -41,11 +58,324 @@ CREATE TABLE comments (
                           chapter_id UUID REFERENCES chapters(id) ON DELETE CASCADE,
                           content TEXT NOT NULL,
                           created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                          updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                          deleted_at TIMESTAMP
+);
+
+CREATE TABLE key_results (
+                             id UUID PRIMARY KEY,
+                             goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+                             title VARCHAR(255) NOT NULL,
+                             start_value DOUBLE PRECISION NOT NULL,
+                             target_value DOUBLE PRECISION NOT NULL,
+                             current_value DOUBLE PRECISION NOT NULL,
+                             unit VARCHAR(32) NOT NULL DEFAULT '',
+                             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                             updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE key_result_check_ins (
+                                      id UUID PRIMARY KEY,
+                                      key_result_id UUID NOT NULL REFERENCES key_results(id) ON DELETE CASCADE,
+                                      value DOUBLE PRECISION NOT NULL,
+                                      note TEXT NOT NULL DEFAULT '',
+                                      created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE goal_templates (
+                                id UUID PRIMARY KEY,
+                                user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+                                title VARCHAR(255) NOT NULL,
+                                description TEXT NOT NULL,
+                                tags TEXT[],
+                                is_public BOOLEAN NOT NULL DEFAULT FALSE,
+                                deadline_offset_days INT NOT NULL DEFAULT 0,
+                                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE template_chapters (
+                                   id UUID PRIMARY KEY,
+                                   template_id UUID NOT NULL REFERENCES goal_templates(id) ON DELETE CASCADE,
+                                   title VARCHAR(255) NOT NULL,
+                                   description TEXT NOT NULL,
+                                   deadline_offset_days INT NOT NULL,
+                                   priority INT NOT NULL DEFAULT 0,
+                                   position INT NOT NULL
+);
+
+CREATE TABLE calendar_feeds (
+                                user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                token VARCHAR(64) UNIQUE NOT NULL,
+                                created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE caldav_credentials (
+                                    user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                    password_hash VARCHAR(64) NOT NULL,
+                                    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhooks (
+                          id UUID PRIMARY KEY,
+                          user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                          url TEXT NOT NULL,
+                          secret VARCHAR(64) NOT NULL,
+                          events TEXT[] NOT NULL,
+                          is_active BOOLEAN NOT NULL DEFAULT TRUE,
+                          failure_count INT NOT NULL DEFAULT 0,
+                          disabled_at TIMESTAMP,
+                          created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
                           updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
 );
 
+CREATE TABLE webhook_deliveries (
+                                    id UUID PRIMARY KEY,
+                                    webhook_id UUID NOT NULL REFERENCES webhooks(id) ON DELETE CASCADE,
+                                    event VARCHAR(64) NOT NULL,
+                                    payload TEXT NOT NULL,
+                                    status VARCHAR(16) NOT NULL DEFAULT 'pending',
+                                    attempts INT NOT NULL DEFAULT 0,
+                                    next_attempt_at TIMESTAMP NOT NULL,
+                                    last_status_code INT NOT NULL DEFAULT 0,
+                                    last_error TEXT NOT NULL DEFAULT '',
+                                    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                                    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_delivery_attempts (
+                                           id UUID PRIMARY KEY,
+                                           delivery_id UUID NOT NULL REFERENCES webhook_deliveries(id) ON DELETE CASCADE,
+                                           status_code INT NOT NULL DEFAULT 0,
+                                           error TEXT NOT NULL DEFAULT '',
+                                           duration_ms BIGINT NOT NULL,
+                                           attempted_at TIMESTAMP NOT NULL
+);
+
+-- deadline_misses remembers reported deadlines so deadline.missed fires once per goal or chapter deadline
+CREATE TABLE deadline_misses (
+                                 entity_id UUID NOT NULL,
+                                 deadline TIMESTAMP NOT NULL,
+                                 entity_type VARCHAR(16) NOT NULL,
+                                 user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                 recorded_at TIMESTAMP NOT NULL,
+                                 PRIMARY KEY (entity_id, deadline)
+);
+
+CREATE TABLE outbox (
+                        id UUID PRIMARY KEY,
+                        event_type VARCHAR(64) NOT NULL,
+                        user_id UUID NOT NULL,
+                        payload JSONB NOT NULL,
+                        attempts INT NOT NULL DEFAULT 0,
+                        next_attempt_at TIMESTAMP NOT NULL,
+                        last_error TEXT NOT NULL DEFAULT '',
+                        dispatched_at TIMESTAMP,
+                        dead_at TIMESTAMP,
+                        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- audit_log has no foreign keys, the history of an entity outlives it
+CREATE TABLE audit_log (
+                           id UUID PRIMARY KEY,
+                           actor_id VARCHAR(64) NOT NULL,
+                           request_id VARCHAR(128) NOT NULL DEFAULT '',
+                           entity_type VARCHAR(16) NOT NULL,
+                           entity_id UUID NOT NULL,
+                           goal_id UUID,
+                           action VARCHAR(16) NOT NULL,
+                           changes JSONB NOT NULL,
+                           created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE goal_revisions (
+                                id UUID PRIMARY KEY,
+                                goal_id UUID NOT NULL REFERENCES goals(id) ON DELETE CASCADE,
+                                number INT NOT NULL,
+                                actor_id VARCHAR(64) NOT NULL,
+                                snapshot JSONB NOT NULL,
+                                created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                UNIQUE (goal_id, number)
+);
+
+-- goals reference tags by name, tags holds the color and emoji of each name
+CREATE TABLE tags (
+                      id UUID PRIMARY KEY,
+                      user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                      name VARCHAR(64) NOT NULL,
+                      color VARCHAR(7) NOT NULL DEFAULT '',
+                      emoji VARCHAR(16) NOT NULL DEFAULT '',
+                      created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                      updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                      UNIQUE (user_id, name)
+);
+
+CREATE TABLE saved_views (
+                             id UUID PRIMARY KEY,
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             name VARCHAR(64) NOT NULL,
+                             filter TEXT NOT NULL,
+                             created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                             updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- each goal and chapter awards XP once, completing it again after reopening it awards nothing
+CREATE TABLE xp_awards (
+                           id UUID PRIMARY KEY,
+                           user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                           source VARCHAR(16) NOT NULL,
+                           source_id UUID NOT NULL,
+                           points INT NOT NULL,
+                           on_time BOOLEAN NOT NULL DEFAULT FALSE,
+                           created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                           UNIQUE (source, source_id)
+);
+
+CREATE TABLE user_achievements (
+                                   user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                   code VARCHAR(64) NOT NULL,
+                                   unlocked_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                   PRIMARY KEY (user_id, code)
+);
+
+-- xp_totals sums up xp_awards per week and month, leaderboards read them instead of the awards
+CREATE TABLE xp_totals (
+                           user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                           period VARCHAR(8) NOT NULL,
+                           period_start DATE NOT NULL,
+                           xp INT NOT NULL DEFAULT 0,
+                           goals INT NOT NULL DEFAULT 0,
+                           chapters INT NOT NULL DEFAULT 0,
+                           PRIMARY KEY (user_id, period, period_start)
+);
+
+-- stats_versions is bumped whenever a goal of the user changes, statistics cached by any instance are keyed by it
+CREATE TABLE stats_versions (
+                                user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                version BIGINT NOT NULL DEFAULT 0
+);
+
+CREATE TABLE friend_invites (
+                                code VARCHAR(32) PRIMARY KEY,
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                expires_at TIMESTAMP NOT NULL
+);
+
+-- friendships are stored in both directions, so the friends of a user are found through the primary key
+CREATE TABLE friendships (
+                             user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             friend_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                             created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                             PRIMARY KEY (user_id, friend_id),
+                             CHECK (user_id <> friend_id)
+);
+
+CREATE TABLE privacy_settings (
+                                  user_id UUID PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+                                  hide_from_leaderboards BOOLEAN NOT NULL DEFAULT FALSE,
+                                  updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- focus_sessions reach their goal through the chapter, so moving a chapter moves its focus time too
+CREATE TABLE focus_sessions (
+                                id UUID PRIMARY KEY,
+                                user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                chapter_id UUID NOT NULL REFERENCES chapters(id) ON DELETE CASCADE,
+                                mode VARCHAR(16) NOT NULL,
+                                state VARCHAR(16) NOT NULL,
+                                planned_seconds INT NOT NULL DEFAULT 0,
+                                focused_seconds INT NOT NULL DEFAULT 0,
+                                started_at TIMESTAMP NOT NULL,
+                                resumed_at TIMESTAMP,
+                                ends_at TIMESTAMP,
+                                ended_at TIMESTAMP,
+                                completed BOOLEAN NOT NULL DEFAULT FALSE,
+                                created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- idempotency_keys.scope is a hash of the Authorization header, so keys of different clients never collide
+CREATE TABLE idempotency_keys (
+                                  scope VARCHAR(64) NOT NULL,
+                                  key VARCHAR(255) NOT NULL,
+                                  fingerprint VARCHAR(64) NOT NULL,
+                                  status_code INT,
+                                  content_type VARCHAR(255) NOT NULL DEFAULT '',
+                                  response_body BYTEA,
+                                  created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                  expires_at TIMESTAMP NOT NULL,
+                                  PRIMARY KEY (scope, key)
+);
+
 CREATE INDEX idx_goals_user_id ON goals(user_id);
+CREATE INDEX idx_goals_parent_id ON goals(parent_id);
 CREATE INDEX idx_chapters_goal_id ON chapters(goal_id);
+CREATE INDEX idx_chapter_dependencies_depends_on_id ON chapter_dependencies(depends_on_id);
 CREATE INDEX idx_comments_goal_id ON comments(goal_id);
 CREATE INDEX idx_comments_chapter_id ON comments(chapter_id);
-CREATE INDEX idx_users_telegram_id ON users (telegram_id);
\ No newline at end of file
+CREATE INDEX idx_users_telegram_id ON users (telegram_id);
+CREATE INDEX idx_key_results_goal_id ON key_results(goal_id);
+CREATE INDEX idx_key_result_check_ins_key_result_id ON key_result_check_ins(key_result_id);
+CREATE INDEX idx_webhooks_user_id ON webhooks(user_id);
+CREATE INDEX idx_webhook_deliveries_webhook_id ON webhook_deliveries(webhook_id);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX idx_webhook_delivery_attempts_delivery_id ON webhook_delivery_attempts(delivery_id);
+CREATE INDEX idx_outbox_due ON outbox(next_attempt_at) WHERE dispatched_at IS NULL AND dead_at IS NULL;
+CREATE INDEX idx_outbox_dispatched_at ON outbox(dispatched_at) WHERE dispatched_at IS NOT NULL;
+CREATE INDEX idx_outbox_dead_at ON outbox(dead_at) WHERE dead_at IS NOT NULL;
+CREATE INDEX idx_audit_log_goal_id_created_at ON audit_log(goal_id, created_at DESC);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+-- trashed chapters keep their position, only chapters outside the trash must be ranked uniquely
+CREATE UNIQUE INDEX idx_chapters_goal_id_position ON chapters(goal_id, position) WHERE deleted_at IS NULL;
+CREATE INDEX idx_goals_deleted_at ON goals(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_goals_user_id_archived_at ON goals(user_id, archived_at);
+CREATE INDEX idx_chapters_deleted_at ON chapters(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_comments_deleted_at ON comments(deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX idx_goals_tags ON goals USING GIN (tags);
+CREATE UNIQUE INDEX idx_saved_views_user_id_name ON saved_views(user_id, lower(name));
+CREATE INDEX idx_xp_awards_user_id_created_at ON xp_awards(user_id, created_at);
+CREATE INDEX idx_friend_invites_user_id ON friend_invites(user_id);
+-- a user focuses on one chapter at a time
+CREATE UNIQUE INDEX idx_focus_sessions_user_id_active ON focus_sessions(user_id) WHERE ended_at IS NULL;
+CREATE INDEX idx_focus_sessions_ends_at ON focus_sessions(ends_at) WHERE ended_at IS NULL;
+CREATE INDEX idx_focus_sessions_chapter_id ON focus_sessions(chapter_id);
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+CREATE INDEX idx_goal_templates_user_id ON goal_templates(user_id);
+CREATE INDEX idx_template_chapters_template_id ON template_chapters(template_id);
+
+-- built-in goal templates
+INSERT INTO goal_templates (id, user_id, title, description, tags, is_public, deadline_offset_days) VALUES
+    ('00000000-0000-4000-8000-000000000001', NULL, 'Learn a language', 'Reach conversational level in a new language.', ARRAY['learning', 'language'], TRUE, 180),
+    ('00000000-0000-4000-8000-000000000002', NULL, 'Run a half marathon', 'Go from casual jogging to finishing 21.1 km.', ARRAY['fitness', 'running'], TRUE, 112),
+    ('00000000-0000-4000-8000-000000000003', NULL, 'Read 12 books', 'Read one book every month for a year.', ARRAY['reading'], TRUE, 365),
+    ('00000000-0000-4000-8000-000000000004', NULL, 'Launch a side project', 'Ship a small product to real users.', ARRAY['work', 'project'], TRUE, 90);
+
+INSERT INTO template_chapters (id, template_id, title, description, deadline_offset_days, priority, position)
+SELECT gen_random_uuid(), template_id::uuid, title, description, deadline_offset_days, 0, position FROM (VALUES
+    ('00000000-0000-4000-8000-000000000001', 'Pick a course and set up a routine', 'Choose a textbook or app and block daily study time.', 7, 0),
+    ('00000000-0000-4000-8000-000000000001', 'Learn the alphabet and pronunciation', 'Get comfortable reading and pronouncing every sound.', 14, 1),
+    ('00000000-0000-4000-8000-000000000001', 'Master the 500 most common words', 'Use spaced repetition to build a core vocabulary.', 35, 2),
+    ('00000000-0000-4000-8000-000000000001', 'Learn basic grammar', 'Cover present tense, questions and negation.', 50, 3),
+    ('00000000-0000-4000-8000-000000000001', 'Hold a 5 minute conversation', 'Book a tutor or a language exchange partner.', 70, 4),
+    ('00000000-0000-4000-8000-000000000001', 'Read a graded reader', 'Finish a short book written for learners.', 90, 5),
+    ('00000000-0000-4000-8000-000000000001', 'Learn past and future tenses', 'Tell stories about yesterday and plans for tomorrow.', 110, 6),
+    ('00000000-0000-4000-8000-000000000001', 'Watch a film without subtitles', 'Pick something familiar and note new phrases.', 130, 7),
+    ('00000000-0000-4000-8000-000000000001', 'Write a one page essay', 'Have it corrected by a native speaker.', 155, 8),
+    ('00000000-0000-4000-8000-000000000001', 'Hold a 30 minute conversation', 'Talk about everyday topics without switching languages.', 180, 9),
+    ('00000000-0000-4000-8000-000000000002', 'Run 5 km without stopping', 'Build an aerobic base with easy runs three times a week.', 21, 0),
+    ('00000000-0000-4000-8000-000000000002', 'Run 10 km', 'Add one long run per week.', 49, 1),
+    ('00000000-0000-4000-8000-000000000002', 'Run 15 km', 'Keep increasing the long run by no more than 10% a week.', 77, 2),
+    ('00000000-0000-4000-8000-000000000002', 'Taper', 'Reduce volume and keep intensity for the last two weeks.', 105, 3),
+    ('00000000-0000-4000-8000-000000000002', 'Race day', 'Finish the half marathon.', 112, 4),
+    ('00000000-0000-4000-8000-000000000003', 'Build a reading list', 'Pick twelve books and order them.', 7, 0),
+    ('00000000-0000-4000-8000-000000000003', 'Finish books 1-3', 'Read every day, even if only ten pages.', 91, 1),
+    ('00000000-0000-4000-8000-000000000003', 'Finish books 4-6', 'Keep notes on what you want to remember.', 182, 2),
+    ('00000000-0000-4000-8000-000000000003', 'Finish books 7-9', 'Share a recommendation with a friend.', 273, 3),
+    ('00000000-0000-4000-8000-000000000003', 'Finish books 10-12', 'Write a short review of the year.', 365, 4),
+    ('00000000-0000-4000-8000-000000000004', 'Define the problem', 'Write down who the project is for and what it solves.', 7, 0),
+    ('00000000-0000-4000-8000-000000000004', 'Build the smallest useful version', 'Cut every feature that is not needed on day one.', 45, 1),
+    ('00000000-0000-4000-8000-000000000004', 'Invite first users', 'Get feedback from at least five people.', 60, 2),
+    ('00000000-0000-4000-8000-000000000004', 'Fix the biggest issues', 'Address what the first users struggled with.', 80, 3),
+    ('00000000-0000-4000-8000-000000000004', 'Launch publicly', 'Announce the project where its users are.', 90, 4)
+) AS blueprints (template_id, title, description, deadline_offset_days, position);
\ No newline at end of file
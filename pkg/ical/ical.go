@@ -0,0 +1,104 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+// maxLineOctets is the line length limit of RFC 5545, longer lines are folded
+const maxLineOctets = 75
+
+const (
+	dateLayout     = "20060102"
+	dateTimeLayout = "20060102T150405Z"
+)
+
+var textEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+// Writer writes iCalendar content lines with CRLF endings, escaping and folding
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+func (w *Writer) Begin(component string) {
+	w.line("BEGIN:" + component)
+}
+
+func (w *Writer) End(component string) {
+	w.line("END:" + component)
+}
+
+// Property writes a property with a raw value, the caller is responsible for escaping
+func (w *Writer) Property(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes a property with a TEXT value
+func (w *Writer) Text(name, value string) {
+	w.Property(name, EscapeText(value))
+}
+
+// DateTime writes a property with a UTC DATE-TIME value
+func (w *Writer) DateTime(name string, t time.Time) {
+	w.Property(name, t.UTC().Format(dateTimeLayout))
+}
+
+// Date writes a property with a DATE value
+func (w *Writer) Date(name string, t time.Time) {
+	w.Property(name+";VALUE=DATE", t.Format(dateLayout))
+}
+
+// Flush writes buffered lines and returns the first error that occurred
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+
+	w.err = w.w.Flush()
+	return w.err
+}
+
+func (w *Writer) line(line string) {
+	if w.err != nil {
+		return
+	}
+
+	_, w.err = w.w.WriteString(Fold(line) + "\r\n")
+}
+
+func EscapeText(value string) string {
+	return textEscaper.Replace(value)
+}
+
+// Fold splits a content line into lines of at most 75 octets without breaking UTF-8 sequences
+func Fold(line string) string {
+	if len(line) <= maxLineOctets {
+		return line
+	}
+
+	var folded strings.Builder
+	limit := maxLineOctets
+	width := 0
+
+	for _, r := range line {
+		size := len(string(r))
+		if width+size > limit {
+			folded.WriteString("\r\n ")
+			// continuation lines start with a space that counts towards the limit
+			limit = maxLineOctets - 1
+			width = 0
+		}
+
+		folded.WriteRune(r)
+		width += size
+	}
+
+	return folded.String()
+}
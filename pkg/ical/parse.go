@@ -0,0 +1,205 @@
+package ical
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var ErrMalformed = errors.New("malformed iCalendar data")
+
+var textUnescaper = strings.NewReplacer(`\\`, `\`, `\;`, ";", `\,`, ",", `\n`, "\n", `\N`, "\n")
+
+type (
+	// Component is a parsed BEGIN/END block such as VCALENDAR or VTODO
+	Component struct {
+		Name       string
+		Properties []Property
+		Components []*Component
+	}
+
+	Property struct {
+		Name   string
+		Params map[string]string
+		Value  string
+	}
+)
+
+// Parse reads a single top-level component, usually a VCALENDAR
+func Parse(r io.Reader) (*Component, error) {
+	lines, err := unfold(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		root  *Component
+		stack []*Component
+	)
+
+	for _, line := range lines {
+		property, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch property.Name {
+		case "BEGIN":
+			component := &Component{Name: strings.ToUpper(property.Value)}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Components = append(parent.Components, component)
+			} else if root != nil {
+				return nil, fmt.Errorf("%w: more than one top-level component", ErrMalformed)
+			} else {
+				root = component
+			}
+			stack = append(stack, component)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(property.Value) {
+				return nil, fmt.Errorf("%w: unexpected END:%s", ErrMalformed, property.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, fmt.Errorf("%w: property %s outside of a component", ErrMalformed, property.Name)
+			}
+			current := stack[len(stack)-1]
+			current.Properties = append(current.Properties, property)
+		}
+	}
+
+	if root == nil || len(stack) > 0 {
+		return nil, fmt.Errorf("%w: unterminated component", ErrMalformed)
+	}
+
+	return root, nil
+}
+
+// Property returns the first property with the name or nil
+func (c *Component) Property(name string) *Property {
+	for i := range c.Properties {
+		if c.Properties[i].Name == name {
+			return &c.Properties[i]
+		}
+	}
+
+	return nil
+}
+
+// Component returns the first child component with the name or nil
+func (c *Component) Component(name string) *Component {
+	for _, child := range c.Components {
+		if child.Name == name {
+			return child
+		}
+	}
+
+	return nil
+}
+
+// Text returns the unescaped TEXT value of the property
+func (p *Property) Text() string {
+	return textUnescaper.Replace(p.Value)
+}
+
+// Time parses a DATE or DATE-TIME value. Floating times and unknown TZIDs are read as UTC.
+func (p *Property) Time() (time.Time, error) {
+	if p.Params["VALUE"] == "DATE" || len(p.Value) == len(dateLayout) {
+		return time.Parse(dateLayout, p.Value)
+	}
+
+	if strings.HasSuffix(p.Value, "Z") {
+		return time.Parse(dateTimeLayout, p.Value)
+	}
+
+	location := time.UTC
+	if tzid := p.Params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+
+	return time.ParseInLocation(strings.TrimSuffix(dateTimeLayout, "Z"), p.Value, location)
+}
+
+// unfold joins continuation lines, accepting both CRLF and bare LF endings
+func unfold(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrMalformed, err)
+	}
+
+	return lines, nil
+}
+
+// parseLine splits a content line into name, parameters and value. Quoted parameter values may contain ':' and ';'.
+func parseLine(line string) (Property, error) {
+	property := Property{Params: map[string]string{}}
+
+	colon := -1
+	quoted := false
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+
+	if colon <= 0 {
+		return property, fmt.Errorf("%w: %q", ErrMalformed, line)
+	}
+
+	property.Value = line[colon+1:]
+
+	parts := splitParams(line[:colon])
+	property.Name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		name, value, _ := strings.Cut(param, "=")
+		property.Params[strings.ToUpper(name)] = strings.Trim(value, `"`)
+	}
+
+	return property, nil
+}
+
+func splitParams(value string) []string {
+	var (
+		parts  []string
+		start  int
+		quoted bool
+	)
+
+	for i, r := range value {
+		switch {
+		case r == '"':
+			quoted = !quoted
+		case r == ';' && !quoted:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, value[start:])
+}
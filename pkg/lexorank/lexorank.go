@@ -0,0 +1,72 @@
+package lexorank
+
+import (
+	"errors"
+	"strings"
+)
+
+// alphabet is ordered by byte value, so keys compare correctly with plain string comparison
+// (and with the "C" collation in Postgres)
+const alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const base = len(alphabet)
+
+var (
+	ErrInvalidKey   = errors.New("invalid rank key")
+	ErrInvalidOrder = errors.New("lower rank key must be less than upper rank key")
+)
+
+// Between returns a key that sorts strictly between lower and upper.
+// An empty lower means "before everything", an empty upper means "after everything".
+// Generated keys never end with the smallest digit, so there is always room before any key.
+func Between(lower, upper string) (string, error) {
+	if !valid(lower) || !valid(upper) {
+		return "", ErrInvalidKey
+	}
+
+	if upper != "" && lower >= upper {
+		return "", ErrInvalidOrder
+	}
+
+	var key strings.Builder
+	unbounded := upper == ""
+
+	for i := 0; ; i++ {
+		low := 0
+		if i < len(lower) {
+			low = strings.IndexByte(alphabet, lower[i])
+		}
+
+		high := base
+		if !unbounded {
+			if i >= len(upper) {
+				return "", ErrInvalidKey
+			}
+			high = strings.IndexByte(alphabet, upper[i])
+		}
+
+		if low == high {
+			key.WriteByte(alphabet[low])
+			continue
+		}
+
+		if mid := (low + high) / 2; mid > low {
+			key.WriteByte(alphabet[mid])
+			return key.String(), nil
+		}
+
+		// the digits are adjacent: keep the lower digit and find room after the rest of lower
+		key.WriteByte(alphabet[low])
+		unbounded = true
+	}
+}
+
+func valid(key string) bool {
+	for i := 0; i < len(key); i++ {
+		if strings.IndexByte(alphabet, key[i]) < 0 {
+			return false
+		}
+	}
+
+	return key == "" || key[len(key)-1] != alphabet[0]
+}